@@ -0,0 +1,182 @@
+package ai
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"sync"
+
+	"github.com/tarantino19/aig/pkg/prompts"
+)
+
+// ResponseStore persists provider responses between runs
+type ResponseStore interface {
+	Get(key string, v interface{}) (bool, error)
+	Put(key string, v interface{}) error
+}
+
+// CachingProvider wraps a Provider, serving repeated requests from a local store
+// and merging identical requests that are in flight at the same time
+type CachingProvider struct {
+	next  Provider
+	store ResponseStore
+
+	provider    string
+	model       string
+	temperature float64
+
+	mu       sync.Mutex
+	inflight map[string]*inflightCall
+}
+
+type inflightCall struct {
+	done chan struct{}
+	data []byte
+	err  error
+}
+
+// NewCachingProvider wraps next so that its responses are cached in store. The
+// configuration identifies the provider, model and temperature the cache is keyed by,
+// together with the version of the prompt template in use.
+func NewCachingProvider(next Provider, store ResponseStore, config ProviderConfig) *CachingProvider {
+	return &CachingProvider{
+		next:        next,
+		store:       store,
+		provider:    config.Provider,
+		model:       config.Model,
+		temperature: config.Temperature,
+		inflight:    make(map[string]*inflightCall),
+	}
+}
+
+// PromptHash returns a stable identifier for a prompt
+func PromptHash(prompt string) string {
+	sum := sha256.Sum256([]byte(prompt))
+	return hex.EncodeToString(sum[:])
+}
+
+// GenerateCommitMessage generates a commit message, reusing a cached response if available
+func (c *CachingProvider) GenerateCommitMessage(ctx context.Context, diff string, options CommitOptions) (*CommitMessage, error) {
+	prompt, err := commitMessagePrompt(diff, options)
+	if err != nil {
+		return nil, err
+	}
+	return cachedCall(c, "commit", prompt, func() (*CommitMessage, error) {
+		return c.next.GenerateCommitMessage(ctx, diff, options)
+	})
+}
+
+// GenerateSummary generates a summary, reusing a cached response if available
+func (c *CachingProvider) GenerateSummary(ctx context.Context, commits []Commit, options SummaryOptions) (*Summary, error) {
+	prompt, err := summaryPrompt(commits, options)
+	if err != nil {
+		return nil, err
+	}
+	return cachedCall(c, "summary", prompt, func() (*Summary, error) {
+		return c.next.GenerateSummary(ctx, commits, options)
+	})
+}
+
+// ReviewCode reviews a diff, reusing a cached response if available
+func (c *CachingProvider) ReviewCode(ctx context.Context, diff string, options ReviewOptions) (*Review, error) {
+	prompt, err := reviewPrompt(diff, options)
+	if err != nil {
+		return nil, err
+	}
+	return cachedCall(c, "review", prompt, func() (*Review, error) {
+		return c.next.ReviewCode(ctx, diff, options)
+	})
+}
+
+// GeneratePRDescription generates a PR description, reusing a cached response if available
+func (c *CachingProvider) GeneratePRDescription(ctx context.Context, analysis PRAnalysis) (*PRDescriptionAI, error) {
+	prompt, err := prDescriptionPrompt(analysis)
+	if err != nil {
+		return nil, err
+	}
+	return cachedCall(c, "pr", prompt, func() (*PRDescriptionAI, error) {
+		return c.next.GeneratePRDescription(ctx, analysis)
+	})
+}
+
+// PlanCommitSplit plans a commit split, reusing a cached response if available
+func (c *CachingProvider) PlanCommitSplit(ctx context.Context, hunks []SplitHunk, options CommitOptions) (*SplitPlan, error) {
+	prompt, err := splitPrompt(hunks, options)
+	if err != nil {
+		return nil, err
+	}
+	return cachedCall(c, "split", prompt, func() (*SplitPlan, error) {
+		return c.next.PlanCommitSplit(ctx, hunks, options)
+	})
+}
+
+// Close closes the wrapped provider
+func (c *CachingProvider) Close() error {
+	return c.next.Close()
+}
+
+func (c *CachingProvider) key(kind, prompt string) string {
+	h := sha256.New()
+	for _, part := range []string{
+		c.provider,
+		c.model,
+		strconv.FormatFloat(c.temperature, 'g', -1, 64),
+		kind,
+		prompts.Version(kind),
+		PromptHash(prompt),
+	} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// cachedCall returns the cached result for the prompt or performs the call once,
+// sharing its result with concurrent callers. Every caller gets its own copy.
+func cachedCall[T any](c *CachingProvider, kind, prompt string, call func() (*T, error)) (*T, error) {
+	key := c.key(kind, prompt)
+
+	var cached T
+	if found, err := c.store.Get(key, &cached); err == nil && found {
+		return &cached, nil
+	}
+
+	c.mu.Lock()
+	if pending, ok := c.inflight[key]; ok {
+		c.mu.Unlock()
+		<-pending.done
+		if pending.err != nil {
+			return nil, pending.err
+		}
+		var result T
+		if err := json.Unmarshal(pending.data, &result); err != nil {
+			return nil, err
+		}
+		return &result, nil
+	}
+	pending := &inflightCall{done: make(chan struct{})}
+	c.inflight[key] = pending
+	c.mu.Unlock()
+
+	result, err := call()
+	if err == nil {
+		pending.data, err = json.Marshal(result)
+	}
+	pending.err = err
+
+	c.mu.Lock()
+	delete(c.inflight, key)
+	c.mu.Unlock()
+	close(pending.done)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// A failure to cache never fails the request itself
+	_ = c.store.Put(key, result)
+
+	return result, nil
+}
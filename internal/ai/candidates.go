@@ -0,0 +1,85 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// MaxCandidates caps how many commit messages are requested at once
+const MaxCandidates = 9
+
+// GenerateCommitCandidates requests n alternative commit messages in parallel.
+// Each request carries its own variant number so prompts, and therefore cache
+// entries, differ. Duplicates and messages whose header line is listed in
+// options.Avoid are dropped.
+// Partial results are returned when at least one request succeeds.
+func GenerateCommitCandidates(ctx context.Context, provider Provider, diff string, options CommitOptions, n int) ([]*CommitMessage, error) {
+	if n < 1 {
+		n = 1
+	}
+	if n > MaxCandidates {
+		return nil, fmt.Errorf("at most %d candidates can be requested, got %d", MaxCandidates, n)
+	}
+
+	results := make([]*CommitMessage, n)
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			opts := options
+			opts.Variant = i + 1
+			results[i], errs[i] = provider.GenerateCommitMessage(ctx, diff, opts)
+		}(i)
+	}
+	wg.Wait()
+
+	seen := make(map[string]bool)
+	for _, header := range options.Avoid {
+		seen[candidateKey(header)] = true
+	}
+
+	var candidates []*CommitMessage
+	var firstErr error
+	for i, msg := range results {
+		if errs[i] != nil {
+			if firstErr == nil {
+				firstErr = errs[i]
+			}
+			continue
+		}
+		if msg == nil {
+			continue
+		}
+		key := candidateKey(msg.Header())
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		candidates = append(candidates, msg)
+	}
+
+	if len(candidates) == 0 {
+		if firstErr != nil {
+			return nil, firstErr
+		}
+		return nil, fmt.Errorf("no new commit messages were generated")
+	}
+	return candidates, nil
+}
+
+// candidateKey normalizes a header line for duplicate detection
+func candidateKey(header string) string {
+	return strings.ToLower(strings.TrimSuffix(strings.TrimSpace(header), "."))
+}
+
+// ParseCommitMessage splits a hand-written message into its parts, keeping the
+// text as written
+func ParseCommitMessage(text string, conventional bool) *CommitMessage {
+	msg := parseCommitMessage(text, conventional)
+	msg.FullMessage = strings.TrimSpace(text)
+	return msg
+}
@@ -0,0 +1,53 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// variantProvider answers commit requests from a table keyed by variant
+type variantProvider struct {
+	Provider
+	messages map[int]string
+}
+
+func (v *variantProvider) GenerateCommitMessage(ctx context.Context, diff string, options CommitOptions) (*CommitMessage, error) {
+	text, ok := v.messages[options.Variant]
+	if !ok {
+		return nil, errors.New("no message for variant")
+	}
+	return &CommitMessage{Subject: text, FullMessage: text}, nil
+}
+
+func TestGenerateCommitCandidates(t *testing.T) {
+	provider := &variantProvider{messages: map[int]string{
+		1: "fix: handle empty input",
+		2: "fix: handle empty input.",
+		3: "fix: reject blank names",
+		4: "refactor: validate input early",
+	}}
+	options := CommitOptions{Avoid: []string{"refactor: validate input early"}}
+
+	got, err := GenerateCommitCandidates(context.Background(), provider, "diff", options, 5)
+	if err != nil {
+		t.Fatalf("GenerateCommitCandidates: %v", err)
+	}
+	var headers []string
+	for _, msg := range got {
+		headers = append(headers, msg.Header())
+	}
+	want := []string{"fix: handle empty input", "fix: reject blank names"}
+	if len(headers) != len(want) {
+		t.Fatalf("candidates = %q, want %q", headers, want)
+	}
+	for i := range want {
+		if headers[i] != want[i] {
+			t.Errorf("candidate %d = %q, want %q", i, headers[i], want[i])
+		}
+	}
+
+	if _, err := GenerateCommitCandidates(context.Background(), &variantProvider{}, "diff", CommitOptions{}, 2); err == nil {
+		t.Error("expected an error when every request fails")
+	}
+}
@@ -3,6 +3,7 @@ package ai
 import (
 	"context"
 	"fmt"
+	"strings"
 )
 
 // Provider defines the interface for AI providers
@@ -19,6 +20,9 @@ type Provider interface {
 	// GeneratePRDescription generates a PR description from branch analysis
 	GeneratePRDescription(ctx context.Context, analysis PRAnalysis) (*PRDescriptionAI, error)
 	
+	// PlanCommitSplit groups staged hunks into a series of commits
+	PlanCommitSplit(ctx context.Context, hunks []SplitHunk, options CommitOptions) (*SplitPlan, error)
+	
 	// Close closes the provider connection
 	Close() error
 }
@@ -30,25 +34,50 @@ type ProviderConfig struct {
 	Model       string
 	Temperature float64
 	MaxTokens   int
+	Retry       RetryPolicy
+	RateLimit   RateLimit
+	Replay      ReplayConfig // used when Provider is replay
 }
 
 // NewProvider creates a new AI provider based on the configuration
 func NewProvider(config ProviderConfig) (Provider, error) {
+	policy := newRequestPolicy(config.Provider, config.Retry, config.RateLimit)
+
 	switch config.Provider {
 	case "openai":
-		return NewOpenAIProvider(config.APIKey, config.Model, config.Temperature, config.MaxTokens)
+		provider, err := NewOpenAIProvider(config.APIKey, config.Model, config.Temperature, config.MaxTokens)
+		if err != nil {
+			return nil, err
+		}
+		provider.policy = policy
+		return provider, nil
 	case "gemini":
-		return NewGeminiProvider(config.APIKey, config.Model, config.Temperature, config.MaxTokens)
+		provider, err := NewGeminiProvider(config.APIKey, config.Model, config.Temperature, config.MaxTokens)
+		if err != nil {
+			return nil, err
+		}
+		provider.policy = policy
+		return provider, nil
+	case "replay":
+		return newReplayProvider(config)
 	default:
-		return nil, fmt.Errorf("unsupported AI provider: %s. Supported providers: openai, gemini", config.Provider)
+		return nil, fmt.Errorf("unsupported AI provider: %s. Supported providers: openai, gemini, replay", config.Provider)
 	}
 }
 
 // CommitOptions contains options for commit message generation
 type CommitOptions struct {
 	Type         string
+	TypeHint     string // type suggested by the branch name, used when Type is empty
 	Scope        string
 	Conventional bool
+	Types        []string // allowed commit types
+	Scopes       []string // allowed scopes, any scope when empty
+	Examples     []string // recent commit subjects showing the repository's style
+	Variant      int      // number of the alternative when several are requested, from 1
+	Like         string   // a message the new one should resemble
+	Avoid        []string // rejected messages not to repeat
+	Squashed     []string // subjects of the commits being squashed into one
 }
 
 // CommitMessage represents a generated commit message
@@ -61,6 +90,36 @@ type CommitMessage struct {
 	FullMessage string
 }
 
+// Header returns the first line of the full message
+func (m *CommitMessage) Header() string {
+	header, _, _ := strings.Cut(strings.TrimSpace(m.FullMessage), "\n")
+	return strings.TrimSpace(header)
+}
+
+// Format assembles the full message from the header, body and footer. Call it
+// to refresh FullMessage after changing one of the parts.
+func (m *CommitMessage) Format() string {
+	var parts []string
+	switch {
+	case m.Type != "" && m.Scope != "":
+		parts = append(parts, fmt.Sprintf("%s(%s): %s", m.Type, m.Scope, m.Subject))
+	case m.Type != "":
+		parts = append(parts, fmt.Sprintf("%s: %s", m.Type, m.Subject))
+	default:
+		parts = append(parts, m.Subject)
+	}
+
+	if m.Body != "" {
+		parts = append(parts, "", m.Body)
+	}
+
+	if m.Footer != "" {
+		parts = append(parts, "", m.Footer)
+	}
+
+	return strings.Join(parts, "\n")
+}
+
 // Commit represents a git commit
 type Commit struct {
 	Hash    string
@@ -132,6 +191,8 @@ type Suggestion struct {
 type SecurityRisk struct {
 	Severity    string
 	Type        string
+	File        string
+	Line        int
 	Description string
 	Mitigation  string
 }
@@ -139,6 +200,8 @@ type SecurityRisk struct {
 // PerformanceIssue represents a performance issue
 type PerformanceIssue struct {
 	Type        string
+	File        string
+	Line        int
 	Description string
 	Impact      string
 	Solution    string
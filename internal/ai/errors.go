@@ -0,0 +1,88 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Kinds of provider failure. A *ProviderError matches its kind with errors.Is.
+var (
+	ErrRateLimited    = errors.New("rate limited")
+	ErrQuotaExhausted = errors.New("quota exhausted")
+	ErrAuth           = errors.New("authentication failed")
+	ErrContextTooLong = errors.New("prompt is too long for the model")
+	ErrContentBlocked = errors.New("blocked by the provider's safety filters")
+	ErrTimeout        = errors.New("request timed out")
+)
+
+// ProviderError is a failed request with the details the provider reported
+type ProviderError struct {
+	Kind       error // one of the Err* kinds, nil when the failure is not classified
+	Provider   string
+	Model      string
+	StatusCode int           // HTTP status, 0 when there was no response
+	Code       string        // the provider's error code, such as insufficient_quota
+	Message    string        // the provider's error message
+	RetryAfter time.Duration // how long the provider asked to wait, if it did
+	Err        error         // the error returned by the client library
+}
+
+func (e *ProviderError) Error() string {
+	what := "request failed"
+	if e.Kind != nil {
+		what = e.Kind.Error()
+	}
+
+	detail := e.Message
+	if detail == "" && e.Err != nil {
+		detail = e.Err.Error()
+	}
+
+	switch {
+	case e.StatusCode != 0 && e.Code != "":
+		return fmt.Sprintf("%s: %s (%d %s): %s", e.Provider, what, e.StatusCode, e.Code, detail)
+	case e.StatusCode != 0:
+		return fmt.Sprintf("%s: %s (%d): %s", e.Provider, what, e.StatusCode, detail)
+	default:
+		return fmt.Sprintf("%s: %s: %s", e.Provider, what, detail)
+	}
+}
+
+// Unwrap exposes both the kind and the client library's error to errors.Is and errors.As
+func (e *ProviderError) Unwrap() []error {
+	if e.Kind == nil {
+		return []error{e.Err}
+	}
+	return []error{e.Kind, e.Err}
+}
+
+// kindForStatus maps an HTTP status to the kind of failure it usually means
+func kindForStatus(status int) error {
+	switch status {
+	case 401, 403:
+		return ErrAuth
+	case 413:
+		return ErrContextTooLong
+	case 429:
+		return ErrRateLimited
+	case 408, 504:
+		return ErrTimeout
+	}
+	return nil
+}
+
+// timeoutKind classifies errors that happened before a response arrived
+func timeoutKind(err error) error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ErrTimeout
+	}
+	if isNetworkError(err) && !errors.Is(err, context.Canceled) {
+		var timeout interface{ Timeout() bool }
+		if errors.As(err, &timeout) && timeout.Timeout() {
+			return ErrTimeout
+		}
+	}
+	return nil
+}
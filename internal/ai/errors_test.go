@@ -0,0 +1,79 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/generative-ai-go/genai"
+	"github.com/sashabaranov/go-openai"
+	"google.golang.org/api/googleapi"
+)
+
+func TestOpenAIErrorKinds(t *testing.T) {
+	provider := &OpenAIProvider{model: "gpt-4o-mini"}
+
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{"rate limit", &openai.APIError{HTTPStatusCode: 429, Type: "requests"}, ErrRateLimited},
+		{"quota", &openai.APIError{HTTPStatusCode: 429, Type: "insufficient_quota"}, ErrQuotaExhausted},
+		{"bad key", &openai.APIError{HTTPStatusCode: 401, Code: "invalid_api_key"}, ErrAuth},
+		{"too long", &openai.APIError{HTTPStatusCode: 400, Code: "context_length_exceeded"}, ErrContextTooLong},
+		{"content policy", &openai.APIError{HTTPStatusCode: 400, Code: "content_policy_violation"}, ErrContentBlocked},
+		{"gateway timeout", &openai.RequestError{HTTPStatusCode: 504}, ErrTimeout},
+		{"deadline", context.DeadlineExceeded, ErrTimeout},
+	}
+	for _, tt := range tests {
+		err := provider.wrapError(tt.err)
+		if !errors.Is(err, tt.want) {
+			t.Errorf("%s: %v does not match %v", tt.name, err, tt.want)
+		}
+		var providerErr *ProviderError
+		if !errors.As(err, &providerErr) || providerErr.Provider != "openai" || providerErr.Model != "gpt-4o-mini" {
+			t.Errorf("%s: %v carries no provider details", tt.name, err)
+		}
+	}
+
+	var apiErr *openai.APIError
+	if err := provider.wrapError(&openai.APIError{HTTPStatusCode: 500}); !errors.As(err, &apiErr) {
+		t.Errorf("the client library's error is not reachable through %v", err)
+	}
+	if err := provider.wrapError(context.Canceled); err != context.Canceled {
+		t.Errorf("cancellation was wrapped: %v", err)
+	}
+}
+
+func TestGeminiErrorKinds(t *testing.T) {
+	provider := &GeminiProvider{modelName: "gemini-1.5-flash"}
+
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{"rate limit", &googleapi.Error{Code: 429, Message: "Resource has been exhausted (e.g. check quota)."}, ErrRateLimited},
+		{"daily quota", &googleapi.Error{Code: 429, Message: "Quota exceeded for metric: GenerateRequestsPerDayPerProjectPerModel"}, ErrQuotaExhausted},
+		{"bad key", &googleapi.Error{Code: 400, Message: "API key not valid. Please pass a valid API key."}, ErrAuth},
+		{"too long", &googleapi.Error{Code: 400, Message: "The input token count (1200000) exceeds the maximum number of tokens allowed (1048576)."}, ErrContextTooLong},
+		{"safety", &genai.BlockedError{Candidate: &genai.Candidate{FinishReason: genai.FinishReasonSafety}}, ErrContentBlocked},
+	}
+	for _, tt := range tests {
+		if err := provider.wrapError(tt.err); !errors.Is(err, tt.want) {
+			t.Errorf("%s: %v does not match %v", tt.name, err, tt.want)
+		}
+	}
+
+	err := provider.wrapError(&googleapi.Error{Code: 429, Header: http.Header{"Retry-After": {"12"}}})
+	var providerErr *ProviderError
+	if !errors.As(err, &providerErr) || providerErr.RetryAfter != 12*time.Second {
+		t.Errorf("Retry-After was not kept: %#v", providerErr)
+	}
+	if retryable, _ := classifyError(provider.wrapError(&googleapi.Error{Code: 429, Message: "per day"})); retryable {
+		t.Error("an exhausted quota is retried")
+	}
+}
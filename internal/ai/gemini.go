@@ -3,13 +3,14 @@ package ai
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"math"
+	"regexp"
+	"strconv"
 	"strings"
-	"time"
 
 	"github.com/google/generative-ai-go/genai"
-	"github.com/tarantino19/aig/pkg/prompts"
+	"google.golang.org/api/googleapi"
 	"google.golang.org/api/option"
 )
 
@@ -17,8 +18,10 @@ import (
 type GeminiProvider struct {
 	client      *genai.Client
 	model       *genai.GenerativeModel
+	modelName   string
 	temperature float32
 	maxTokens   int32
+	policy      *requestPolicy // retries and rate limit, nil to send once
 }
 
 // NewGeminiProvider creates a new Gemini AI provider
@@ -53,6 +56,7 @@ func NewGeminiProvider(apiKey, modelName string, temperature float64, maxTokens
 	return &GeminiProvider{
 		client:      client,
 		model:       model,
+		modelName:   modelName,
 		temperature: float32(temperature),
 		maxTokens:   int32(maxTokens),
 	}, nil
@@ -60,20 +64,16 @@ func NewGeminiProvider(apiKey, modelName string, temperature float64, maxTokens
 
 // GenerateCommitMessage generates a commit message from a git diff
 func (g *GeminiProvider) GenerateCommitMessage(ctx context.Context, diff string, options CommitOptions) (*CommitMessage, error) {
-	prompt := prompts.GetCommitMessagePrompt(diff, options.Type, options.Scope, options.Conventional)
-	
-	resp, err := g.generateWithRetry(ctx, prompt)
+	prompt, err := commitMessagePrompt(diff, options)
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate commit message: %w", err)
+		return nil, err
 	}
 	
-	if len(resp.Candidates) == 0 {
-		return nil, fmt.Errorf("no response from Gemini")
+	text, err := g.generateWithRetry(ctx, KindCommit, prompt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate commit message: %w", err)
 	}
 	
-	// Extract text from response
-	text := extractTextFromResponse(resp)
-	
 	// Parse the commit message
 	commitMsg := parseCommitMessage(text, options.Conventional)
 	
@@ -82,30 +82,16 @@ func (g *GeminiProvider) GenerateCommitMessage(ctx context.Context, diff string,
 
 // GenerateSummary generates a summary of commits
 func (g *GeminiProvider) GenerateSummary(ctx context.Context, commits []Commit, options SummaryOptions) (*Summary, error) {
-	// Convert ai.Commit to prompts.Commit
-	promptCommits := make([]prompts.Commit, len(commits))
-	for i, c := range commits {
-		promptCommits[i] = prompts.Commit{
-			Hash:    c.Hash,
-			Author:  c.Author,
-			Date:    c.Date,
-			Message: c.Message,
-		}
+	prompt, err := summaryPrompt(commits, options)
+	if err != nil {
+		return nil, err
 	}
 	
-	prompt := prompts.GetSummaryPrompt(promptCommits, options.GroupByType, options.Changelog)
-	
-	resp, err := g.generateWithRetry(ctx, prompt)
+	text, err := g.generateWithRetry(ctx, KindSummary, prompt)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate summary: %w", err)
 	}
 	
-	if len(resp.Candidates) == 0 {
-		return nil, fmt.Errorf("no response from Gemini")
-	}
-	
-	text := extractTextFromResponse(resp)
-	
 	// Try to parse as JSON first for structured response
 	var summary Summary
 	if err := json.Unmarshal([]byte(text), &summary); err != nil {
@@ -118,22 +104,16 @@ func (g *GeminiProvider) GenerateSummary(ctx context.Context, commits []Commit,
 
 // ReviewCode performs a code review on the given diff
 func (g *GeminiProvider) ReviewCode(ctx context.Context, diff string, options ReviewOptions) (*Review, error) {
-	prompt := prompts.GetReviewPrompt(diff, options.FocusAreas, options.Security, options.Performance)
-	
-	resp, err := g.generateWithRetry(ctx, prompt)
+	prompt, err := reviewPrompt(diff, options)
 	if err != nil {
-		return nil, fmt.Errorf("failed to review code: %w", err)
+		return nil, err
 	}
 	
-	if len(resp.Candidates) == 0 {
-		return nil, fmt.Errorf("no response from Gemini")
+	text, err := g.generateWithRetry(ctx, KindReview, prompt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to review code: %w", err)
 	}
 	
-		text := extractTextFromResponse(resp)
-	fmt.Println("--- Raw AI Response Start ---")
-	fmt.Println(text)
-	fmt.Println("--- Raw AI Response End ---")
-
 	// Parse the review response
 	review := parseReviewResponse(text, options)
 	
@@ -142,37 +122,16 @@ func (g *GeminiProvider) ReviewCode(ctx context.Context, diff string, options Re
 
 // GeneratePRDescription generates a PR description from branch analysis
 func (g *GeminiProvider) GeneratePRDescription(ctx context.Context, analysis PRAnalysis) (*PRDescriptionAI, error) {
-	// Convert ai.Commit to prompts.Commit
-	promptCommits := make([]prompts.Commit, len(analysis.Commits))
-	for i, c := range analysis.Commits {
-		promptCommits[i] = prompts.Commit{
-			Hash:    c.Hash,
-			Author:  c.Author,
-			Date:    c.Date,
-			Message: c.Message,
-		}
+	prompt, err := prDescriptionPrompt(analysis)
+	if err != nil {
+		return nil, err
 	}
 	
-	prompt := prompts.GetPRDescriptionPrompt(
-		analysis.CurrentBranch,
-		analysis.TargetBranch,
-		analysis.Diff,
-		promptCommits,
-		analysis.IssueNumbers,
-		analysis.Platform,
-	)
-	
-	resp, err := g.generateWithRetry(ctx, prompt)
+	text, err := g.generateWithRetry(ctx, KindPR, prompt)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate PR description: %w", err)
 	}
 	
-	if len(resp.Candidates) == 0 {
-		return nil, fmt.Errorf("no response from Gemini")
-	}
-	
-	text := extractTextFromResponse(resp)
-	
 	// Try to parse as JSON first
 	var prDesc PRDescriptionAI
 	if err := json.Unmarshal([]byte(text), &prDesc); err != nil {
@@ -183,40 +142,83 @@ func (g *GeminiProvider) GeneratePRDescription(ctx context.Context, analysis PRA
 	return &prDesc, nil
 }
 
-// generateWithRetry implements exponential backoff retry logic for rate limiting
-func (g *GeminiProvider) generateWithRetry(ctx context.Context, prompt string) (*genai.GenerateContentResponse, error) {
-	maxRetries := 3
-	baseDelay := time.Second
-	
-	for attempt := 0; attempt <= maxRetries; attempt++ {
+// PlanCommitSplit groups staged hunks into a series of commits
+func (g *GeminiProvider) PlanCommitSplit(ctx context.Context, hunks []SplitHunk, options CommitOptions) (*SplitPlan, error) {
+	prompt, err := splitPrompt(hunks, options)
+	if err != nil {
+		return nil, err
+	}
+
+	text, err := g.generateWithRetry(ctx, KindSplit, prompt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to plan commit split: %w", err)
+	}
+
+	return parseSplitPlan(text)
+}
+
+// generateWithRetry sends the prompt, retrying failures the request policy
+// allows, and returns the text of the response
+func (g *GeminiProvider) generateWithRetry(ctx context.Context, kind, prompt string) (string, error) {
+	return invoke(ctx, kind, "gemini", g.modelName, prompt, g.policy, func(ctx context.Context) (string, Usage, error) {
 		resp, err := g.model.GenerateContent(ctx, genai.Text(prompt))
 		if err != nil {
-			// Check if it's a rate limiting error
-			if strings.Contains(err.Error(), "429") || strings.Contains(err.Error(), "quota") {
-				if attempt == maxRetries {
-					return nil, fmt.Errorf("rate limit exceeded after %d attempts. Please try again later or check your Gemini API quota at https://ai.google.dev/gemini-api/docs/rate-limits", maxRetries+1)
-				}
-				
-				// Calculate exponential backoff delay
-				delay := time.Duration(math.Pow(2, float64(attempt))) * baseDelay
-				fmt.Printf("Rate limit hit, retrying in %v... (attempt %d/%d)\n", delay, attempt+1, maxRetries+1)
-				
-				select {
-				case <-time.After(delay):
-					continue
-				case <-ctx.Done():
-					return nil, ctx.Err()
-				}
+			return "", Usage{}, g.wrapError(err)
+		}
+
+		var usage Usage
+		if resp.UsageMetadata != nil {
+			usage = Usage{
+				PromptTokens:     int(resp.UsageMetadata.PromptTokenCount),
+				CompletionTokens: int(resp.UsageMetadata.CandidatesTokenCount),
 			}
-			
-			// For other errors, return immediately
-			return nil, err
 		}
-		
-		return resp, nil
+		if len(resp.Candidates) == 0 {
+			return "", usage, fmt.Errorf("no response from Gemini")
+		}
+		return extractTextFromResponse(resp), usage, nil
+	})
+}
+
+// wrapError turns an error of the Gemini client into a *ProviderError
+func (g *GeminiProvider) wrapError(err error) error {
+	if errors.Is(err, context.Canceled) {
+		return err
 	}
-	
-	return nil, fmt.Errorf("failed after %d retries", maxRetries+1)
+
+	providerErr := &ProviderError{Provider: "gemini", Model: g.modelName, Err: err}
+
+	var blocked *genai.BlockedError
+	var apiErr *googleapi.Error
+	switch {
+	case errors.As(err, &blocked):
+		providerErr.Kind = ErrContentBlocked
+		providerErr.Message = blocked.Error()
+	case errors.As(err, &apiErr):
+		providerErr.StatusCode = apiErr.Code
+		providerErr.Message = apiErr.Message
+		providerErr.RetryAfter = parseRetryAfter(apiErr.Header)
+		if len(apiErr.Errors) > 0 {
+			providerErr.Code = apiErr.Errors[0].Reason
+		}
+		providerErr.Kind = kindForStatus(apiErr.Code)
+
+		// Gemini reports several of these as 400 INVALID_ARGUMENT or a plain 429
+		message := strings.ToLower(apiErr.Message)
+		switch {
+		case strings.Contains(message, "api key not valid") || strings.Contains(message, "api_key_invalid"):
+			providerErr.Kind = ErrAuth
+		case apiErr.Code == 400 && strings.Contains(message, "token") &&
+			(strings.Contains(message, "exceeds") || strings.Contains(message, "too long")):
+			providerErr.Kind = ErrContextTooLong
+		case apiErr.Code == 429 && (strings.Contains(message, "per day") || strings.Contains(message, "perday")):
+			providerErr.Kind = ErrQuotaExhausted
+		}
+	default:
+		providerErr.Kind = timeoutKind(err)
+	}
+
+	return providerErr
 }
 
 // Close closes the Gemini client
@@ -295,26 +297,7 @@ func parseCommitMessage(text string, conventional bool) *CommitMessage {
 	}
 	
 	// Build full message
-	fullParts := []string{}
-	if commitMsg.Type != "" {
-		if commitMsg.Scope != "" {
-			fullParts = append(fullParts, fmt.Sprintf("%s(%s): %s", commitMsg.Type, commitMsg.Scope, commitMsg.Subject))
-		} else {
-			fullParts = append(fullParts, fmt.Sprintf("%s: %s", commitMsg.Type, commitMsg.Subject))
-		}
-	} else {
-		fullParts = append(fullParts, commitMsg.Subject)
-	}
-	
-	if commitMsg.Body != "" {
-		fullParts = append(fullParts, "", commitMsg.Body)
-	}
-	
-	if commitMsg.Footer != "" {
-		fullParts = append(fullParts, "", commitMsg.Footer)
-	}
-	
-	commitMsg.FullMessage = strings.Join(fullParts, "\n")
+	commitMsg.FullMessage = commitMsg.Format()
 	
 	return commitMsg
 }
@@ -380,61 +363,86 @@ func parseReviewResponse(text string, options ReviewOptions) *Review {
 	}
 
 	if issuesContent, ok := sections["issues"]; ok {
-		for _, line := range strings.Split(issuesContent, "\n") {
-			line = strings.TrimSpace(line)
-			if line != "" && (strings.HasPrefix(line, "-") || strings.HasPrefix(line, "*") || strings.HasPrefix(line, "•")) {
-				content := strings.TrimSpace(strings.TrimPrefix(strings.TrimPrefix(strings.TrimPrefix(line, "-"), "*"), "•"))
-				review.Issues = append(review.Issues, Issue{
-					Severity:    "medium", // Default severity
-					Type:        "general",  // Default type
-					Description: content,
-				})
-			}
+		for _, content := range bulletItems(issuesContent) {
+			file, line, description := splitFindingLocation(content)
+			review.Issues = append(review.Issues, Issue{
+				Severity:    "medium", // Default severity
+				Type:        "general",  // Default type
+				File:        file,
+				Line:        line,
+				Description: description,
+			})
 		}
 	}
 
 	if suggestionsContent, ok := sections["suggestions"]; ok {
-		for _, line := range strings.Split(suggestionsContent, "\n") {
-			line = strings.TrimSpace(line)
-			if line != "" && (strings.HasPrefix(line, "-") || strings.HasPrefix(line, "*") || strings.HasPrefix(line, "•")) {
-				content := strings.TrimSpace(strings.TrimPrefix(strings.TrimPrefix(strings.TrimPrefix(line, "-"), "*"), "•"))
-				review.Suggestions = append(review.Suggestions, Suggestion{
-					Type:        "general", // Default type
-					Description: content,
-				})
-			}
+		for _, content := range bulletItems(suggestionsContent) {
+			file, line, description := splitFindingLocation(content)
+			review.Suggestions = append(review.Suggestions, Suggestion{
+				Type:        "general", // Default type
+				File:        file,
+				Line:        line,
+				Description: description,
+			})
 		}
 	}
 
-	if securityContent, ok := sections["security risks"]; ok {
-		for _, line := range strings.Split(securityContent, "\n") {
-			line = strings.TrimSpace(line)
-			if line != "" && (strings.HasPrefix(line, "-") || strings.HasPrefix(line, "*") || strings.HasPrefix(line, "•")) {
-				content := strings.TrimSpace(strings.TrimPrefix(strings.TrimPrefix(strings.TrimPrefix(line, "-"), "*"), "•"))
-				review.SecurityRisks = append(review.SecurityRisks, SecurityRisk{
-					Severity:    "medium", // Default severity
-					Description: content,
-				})
-			}
+	if securityContent, ok := sections["security"]; ok {
+		for _, content := range bulletItems(securityContent) {
+			file, line, description := splitFindingLocation(content)
+			review.SecurityRisks = append(review.SecurityRisks, SecurityRisk{
+				Severity:    "medium", // Default severity
+				File:        file,
+				Line:        line,
+				Description: description,
+			})
 		}
 	}
 
-	if performanceContent, ok := sections["performance issues"]; ok {
-		for _, line := range strings.Split(performanceContent, "\n") {
-			line = strings.TrimSpace(line)
-			if line != "" && (strings.HasPrefix(line, "-") || strings.HasPrefix(line, "*") || strings.HasPrefix(line, "•")) {
-				content := strings.TrimSpace(strings.TrimPrefix(strings.TrimPrefix(strings.TrimPrefix(line, "-"), "*"), "•"))
-				review.Performance = append(review.Performance, PerformanceIssue{
-					Type:        "general", // Default type
-					Description: content,
-				})
-			}
+	if performanceContent, ok := sections["performance"]; ok {
+		for _, content := range bulletItems(performanceContent) {
+			file, line, description := splitFindingLocation(content)
+			review.Performance = append(review.Performance, PerformanceIssue{
+				Type:        "general", // Default type
+				File:        file,
+				Line:        line,
+				Description: description,
+			})
 		}
 	}
 
 	return review
 }
 
+// bulletItems returns the text of every bullet point in a review section
+func bulletItems(content string) []string {
+	var items []string
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && (strings.HasPrefix(line, "-") || strings.HasPrefix(line, "*") || strings.HasPrefix(line, "•")) {
+			items = append(items, strings.TrimSpace(strings.TrimPrefix(strings.TrimPrefix(strings.TrimPrefix(line, "-"), "*"), "•")))
+		}
+	}
+	return items
+}
+
+var findingLocationRegex = regexp.MustCompile(`^(?:\*\*)?[\[` + "`" + `]?([\w./-]+\.\w+|[\w./-]+/[\w.-]+):(\d+)(?:-\d+)?[\]` + "`" + `]?(?:\*\*)?\s*[:\-–]?\s*`)
+
+// splitFindingLocation extracts a leading "path/to/file:line" reference from a finding
+func splitFindingLocation(content string) (string, int, string) {
+	match := findingLocationRegex.FindStringSubmatch(content)
+	if match == nil {
+		return "", 0, content
+	}
+
+	line, err := strconv.Atoi(match[2])
+	if err != nil {
+		return "", 0, content
+	}
+
+	return match[1], line, strings.TrimSpace(content[len(match[0]):])
+}
+
 func parsePRDescriptionFromText(text string) *PRDescriptionAI {
 	// Simple fallback parsing when JSON fails
 	lines := strings.Split(text, "\n")
@@ -0,0 +1,99 @@
+package ai
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Request kinds, matching the prompt template names
+const (
+	KindCommit  = "commit"
+	KindSummary = "summary"
+	KindReview  = "review"
+	KindPR      = "pr"
+	KindSplit   = "split"
+)
+
+// Usage is the token count of a request
+type Usage struct {
+	PromptTokens     int
+	CompletionTokens int
+	Estimated        bool // the provider reported nothing, the counts are estimates
+}
+
+// Call describes a single request sent to a provider's API
+type Call struct {
+	Kind     string
+	Provider string
+	Model    string
+	Prompt   string
+	Response string
+	Usage    Usage
+	Started  time.Time
+	Duration time.Duration
+	Err      error
+}
+
+// CallHook observes every request sent to a provider, after it completes
+type CallHook func(ctx context.Context, call *Call)
+
+var (
+	hooksMu sync.RWMutex
+	hooks   []CallHook
+)
+
+// RegisterCallHook adds a hook that runs after every provider request
+func RegisterCallHook(hook CallHook) {
+	hooksMu.Lock()
+	defer hooksMu.Unlock()
+	hooks = append(hooks, hook)
+}
+
+// ResetCallHooks removes every registered hook
+func ResetCallHooks() {
+	hooksMu.Lock()
+	defer hooksMu.Unlock()
+	hooks = nil
+}
+
+// invoke is the path every provider request takes. send performs the request and
+// returns the response text and the token usage the provider reported; policy
+// retries and rate-limits it.
+func invoke(ctx context.Context, kind, provider, model, prompt string, policy *requestPolicy, send func(ctx context.Context) (string, Usage, error)) (string, error) {
+	call := &Call{
+		Kind:     kind,
+		Provider: provider,
+		Model:    model,
+		Prompt:   prompt,
+		Started:  time.Now(),
+	}
+
+	response, usage, err := policy.do(ctx, send)
+	call.Duration = time.Since(call.Started)
+	call.Response = response
+	call.Err = err
+
+	if usage.PromptTokens == 0 && usage.CompletionTokens == 0 {
+		usage = Usage{
+			PromptTokens:     EstimateTokens(prompt),
+			CompletionTokens: EstimateTokens(response),
+			Estimated:        true,
+		}
+	}
+	call.Usage = usage
+
+	hooksMu.RLock()
+	registered := append([]CallHook(nil), hooks...)
+	hooksMu.RUnlock()
+	for _, hook := range registered {
+		hook(ctx, call)
+	}
+
+	return response, err
+}
+
+// EstimateTokens approximates the token count of text at four bytes per token
+func EstimateTokens(text string) int {
+	return (len(text) + 3) / 4
+}
@@ -3,10 +3,11 @@ package ai
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
 
 	"github.com/sashabaranov/go-openai"
-	"github.com/tarantino19/aig/pkg/prompts"
 )
 
 // OpenAIProvider implements the Provider interface using OpenAI's GPT models
@@ -15,6 +16,7 @@ type OpenAIProvider struct {
 	model       string
 	temperature float32
 	maxTokens   int
+	policy      *requestPolicy // retries and rate limit, nil to send once
 }
 
 // NewOpenAIProvider creates a new OpenAI provider
@@ -23,7 +25,9 @@ func NewOpenAIProvider(apiKey, modelName string, temperature float64, maxTokens
 		return nil, fmt.Errorf("OpenAI API key is required")
 	}
 	
-	client := openai.NewClient(apiKey)
+	config := openai.DefaultConfig(apiKey)
+	config.HTTPClient = &http.Client{Transport: &retryAfterTransport{base: http.DefaultTransport}}
+	client := openai.NewClientWithConfig(config)
 	
 	// Default to gpt-4o-mini if no model specified
 	if modelName == "" {
@@ -40,9 +44,12 @@ func NewOpenAIProvider(apiKey, modelName string, temperature float64, maxTokens
 
 // GenerateCommitMessage generates a commit message from a git diff
 func (o *OpenAIProvider) GenerateCommitMessage(ctx context.Context, diff string, options CommitOptions) (*CommitMessage, error) {
-	prompt := prompts.GetCommitMessagePrompt(diff, options.Type, options.Scope, options.Conventional)
+	prompt, err := commitMessagePrompt(diff, options)
+	if err != nil {
+		return nil, err
+	}
 	
-	response, err := o.generateWithRetry(ctx, prompt)
+	response, err := o.generateWithRetry(ctx, KindCommit, prompt)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate commit message: %w", err)
 	}
@@ -55,20 +62,12 @@ func (o *OpenAIProvider) GenerateCommitMessage(ctx context.Context, diff string,
 
 // GenerateSummary generates a summary of commits
 func (o *OpenAIProvider) GenerateSummary(ctx context.Context, commits []Commit, options SummaryOptions) (*Summary, error) {
-	// Convert ai.Commit to prompts.Commit
-	promptCommits := make([]prompts.Commit, len(commits))
-	for i, c := range commits {
-		promptCommits[i] = prompts.Commit{
-			Hash:    c.Hash,
-			Author:  c.Author,
-			Date:    c.Date,
-			Message: c.Message,
-		}
+	prompt, err := summaryPrompt(commits, options)
+	if err != nil {
+		return nil, err
 	}
 	
-	prompt := prompts.GetSummaryPrompt(promptCommits, options.GroupByType, options.Changelog)
-	
-	response, err := o.generateWithRetry(ctx, prompt)
+	response, err := o.generateWithRetry(ctx, KindSummary, prompt)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate summary: %w", err)
 	}
@@ -85,9 +84,12 @@ func (o *OpenAIProvider) GenerateSummary(ctx context.Context, commits []Commit,
 
 // ReviewCode performs a code review on the given diff
 func (o *OpenAIProvider) ReviewCode(ctx context.Context, diff string, options ReviewOptions) (*Review, error) {
-	prompt := prompts.GetReviewPrompt(diff, options.FocusAreas, options.Security, options.Performance)
+	prompt, err := reviewPrompt(diff, options)
+	if err != nil {
+		return nil, err
+	}
 	
-	response, err := o.generateWithRetry(ctx, prompt)
+	response, err := o.generateWithRetry(ctx, KindReview, prompt)
 	if err != nil {
 		return nil, fmt.Errorf("failed to review code: %w", err)
 	}
@@ -100,27 +102,12 @@ func (o *OpenAIProvider) ReviewCode(ctx context.Context, diff string, options Re
 
 // GeneratePRDescription generates a PR description from branch analysis
 func (o *OpenAIProvider) GeneratePRDescription(ctx context.Context, analysis PRAnalysis) (*PRDescriptionAI, error) {
-	// Convert ai.Commit to prompts.Commit
-	promptCommits := make([]prompts.Commit, len(analysis.Commits))
-	for i, c := range analysis.Commits {
-		promptCommits[i] = prompts.Commit{
-			Hash:    c.Hash,
-			Author:  c.Author,
-			Date:    c.Date,
-			Message: c.Message,
-		}
+	prompt, err := prDescriptionPrompt(analysis)
+	if err != nil {
+		return nil, err
 	}
 	
-	prompt := prompts.GetPRDescriptionPrompt(
-		analysis.CurrentBranch,
-		analysis.TargetBranch,
-		analysis.Diff,
-		promptCommits,
-		analysis.IssueNumbers,
-		analysis.Platform,
-	)
-	
-	response, err := o.generateWithRetry(ctx, prompt)
+	response, err := o.generateWithRetry(ctx, KindPR, prompt)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate PR description: %w", err)
 	}
@@ -135,8 +122,30 @@ func (o *OpenAIProvider) GeneratePRDescription(ctx context.Context, analysis PRA
 	return &prDesc, nil
 }
 
-// generateWithRetry implements exponential backoff retry logic for rate limiting
-func (o *OpenAIProvider) generateWithRetry(ctx context.Context, prompt string) (string, error) {
+// PlanCommitSplit groups staged hunks into a series of commits
+func (o *OpenAIProvider) PlanCommitSplit(ctx context.Context, hunks []SplitHunk, options CommitOptions) (*SplitPlan, error) {
+	prompt, err := splitPrompt(hunks, options)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := o.generateWithRetry(ctx, KindSplit, prompt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to plan commit split: %w", err)
+	}
+
+	return parseSplitPlan(response)
+}
+
+// generateWithRetry sends the prompt, retrying failures the request policy allows
+func (o *OpenAIProvider) generateWithRetry(ctx context.Context, kind, prompt string) (string, error) {
+	return invoke(ctx, kind, "openai", o.model, prompt, o.policy, func(ctx context.Context) (string, Usage, error) {
+		return o.send(ctx, prompt)
+	})
+}
+
+// send performs a single chat completion request
+func (o *OpenAIProvider) send(ctx context.Context, prompt string) (string, Usage, error) {
 	req := openai.ChatCompletionRequest{
 		Model:       o.model,
 		Temperature: o.temperature,
@@ -151,23 +160,66 @@ func (o *OpenAIProvider) generateWithRetry(ctx context.Context, prompt string) (
 	
 	resp, err := o.client.CreateChatCompletion(ctx, req)
 	if err != nil {
-		// Check if it's specifically an OpenAI API error
-		if apiErr, ok := err.(*openai.APIError); ok {
-			// Only retry on actual rate limit errors (HTTP 429)
-			if apiErr.HTTPStatusCode == 429 {
-				return "", fmt.Errorf("rate limit exceeded. Please check your OpenAI API quota and billing at https://platform.openai.com/usage")
-			}
-		}
-		
-		// For other errors, return immediately with better error message
-		return "", fmt.Errorf("OpenAI API error: %w", err)
+		return "", Usage{}, o.wrapError(err)
 	}
 	
+	usage := Usage{PromptTokens: resp.Usage.PromptTokens, CompletionTokens: resp.Usage.CompletionTokens}
 	if len(resp.Choices) == 0 {
-		return "", fmt.Errorf("no response from OpenAI")
+		return "", usage, fmt.Errorf("no response from OpenAI")
+	}
+	if resp.Choices[0].FinishReason == openai.FinishReasonContentFilter {
+		return "", usage, &ProviderError{
+			Kind:     ErrContentBlocked,
+			Provider: "openai",
+			Model:    o.model,
+			Message:  "the response was stopped by the content filter",
+		}
 	}
 	
-	return resp.Choices[0].Message.Content, nil
+	return resp.Choices[0].Message.Content, usage, nil
+}
+
+// wrapError turns an error of the OpenAI client into a *ProviderError
+func (o *OpenAIProvider) wrapError(err error) error {
+	if errors.Is(err, context.Canceled) {
+		return err
+	}
+
+	providerErr := &ProviderError{Provider: "openai", Model: o.model, Err: err}
+
+	var apiErr *openai.APIError
+	var requestErr *openai.RequestError
+	switch {
+	case errors.As(err, &apiErr):
+		providerErr.StatusCode = apiErr.HTTPStatusCode
+		providerErr.Message = apiErr.Message
+		providerErr.Code = apiErr.Type
+		if apiErr.Code != nil {
+			providerErr.Code = fmt.Sprint(apiErr.Code)
+		}
+		providerErr.Kind = kindForStatus(apiErr.HTTPStatusCode)
+
+		switch {
+		case apiErr.Type == "insufficient_quota" || providerErr.Code == "insufficient_quota":
+			providerErr.Kind = ErrQuotaExhausted
+		case providerErr.Code == "context_length_exceeded" || providerErr.Code == "string_above_max_length":
+			providerErr.Kind = ErrContextTooLong
+		case providerErr.Code == "invalid_api_key":
+			providerErr.Kind = ErrAuth
+		case providerErr.Code == "content_filter" || providerErr.Code == "content_policy_violation":
+			providerErr.Kind = ErrContentBlocked
+		}
+	case errors.As(err, &requestErr):
+		providerErr.StatusCode = requestErr.HTTPStatusCode
+		providerErr.Kind = kindForStatus(requestErr.HTTPStatusCode)
+		if requestErr.HTTPStatusCode == 0 {
+			providerErr.Kind = timeoutKind(requestErr.Err)
+		}
+	default:
+		providerErr.Kind = timeoutKind(err)
+	}
+
+	return providerErr
 }
 
 // Close closes the OpenAI client (no-op for OpenAI client)
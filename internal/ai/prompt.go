@@ -0,0 +1,92 @@
+package ai
+
+import (
+	"github.com/tarantino19/aig/internal/tickets"
+	"github.com/tarantino19/aig/pkg/prompts"
+)
+
+// The prompt builders below are shared by every provider and by middleware
+// that needs to know the exact prompt a request will send
+
+func commitMessagePrompt(diff string, options CommitOptions) (string, error) {
+	return prompts.GetCommitMessagePrompt(prompts.CommitData{
+		Diff:         diff,
+		Type:         options.Type,
+		TypeHint:     options.TypeHint,
+		Scope:        options.Scope,
+		Conventional: options.Conventional,
+		Types:        options.Types,
+		Scopes:       options.Scopes,
+		Examples:     options.Examples,
+		Variant:      options.Variant,
+		Like:         options.Like,
+		Avoid:        options.Avoid,
+		Squashed:     options.Squashed,
+	})
+}
+
+func summaryPrompt(commits []Commit, options SummaryOptions) (string, error) {
+	return prompts.GetSummaryPrompt(prompts.SummaryData{
+		Commits:     toPromptCommits(commits),
+		GroupByType: options.GroupByType,
+		Changelog:   options.Changelog,
+	})
+}
+
+func reviewPrompt(diff string, options ReviewOptions) (string, error) {
+	return prompts.GetReviewPrompt(prompts.ReviewData{
+		Diff:        diff,
+		FocusAreas:  options.FocusAreas,
+		Security:    options.Security,
+		Performance: options.Performance,
+	})
+}
+
+func prDescriptionPrompt(analysis PRAnalysis) (string, error) {
+	return prompts.GetPRDescriptionPrompt(prompts.PRData{
+		CurrentBranch: analysis.CurrentBranch,
+		TargetBranch:  analysis.TargetBranch,
+		Diff:          analysis.Diff,
+		Commits:       toPromptCommits(analysis.Commits),
+		Issues:        issueReferences(analysis.IssueNumbers),
+		Platform:      analysis.Platform,
+	})
+}
+
+func splitPrompt(hunks []SplitHunk, options CommitOptions) (string, error) {
+	promptHunks := make([]prompts.Hunk, len(hunks))
+	for i, h := range hunks {
+		promptHunks[i] = prompts.Hunk{ID: h.ID, Path: h.Path, Diff: h.Diff}
+	}
+	return prompts.GetSplitPrompt(prompts.SplitData{
+		Hunks:        promptHunks,
+		TypeHint:     options.TypeHint,
+		Conventional: options.Conventional,
+		Types:        options.Types,
+		Scopes:       options.Scopes,
+		Examples:     options.Examples,
+	})
+}
+
+// toPromptCommits converts ai.Commit to prompts.Commit
+func toPromptCommits(commits []Commit) []prompts.Commit {
+	promptCommits := make([]prompts.Commit, len(commits))
+	for i, c := range commits {
+		promptCommits[i] = prompts.Commit{
+			Hash:    c.Hash,
+			Author:  c.Author,
+			Date:    c.Date,
+			Message: c.Message,
+		}
+	}
+	return promptCommits
+}
+
+// issueReferences writes issue keys the way they are linked: #123 or PROJ-123
+func issueReferences(keys []string) []string {
+	refs := make([]string, len(keys))
+	for i, key := range keys {
+		refs[i] = tickets.Reference(key)
+	}
+	return refs
+}
@@ -0,0 +1,153 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/tarantino19/aig/internal/redact"
+)
+
+// ErrNothingToSend is returned when every file of a diff is on the redaction deny list
+var ErrNothingToSend = errors.New("every changed file is on the redaction deny list, nothing was sent to the AI provider")
+
+// RedactingProvider wraps a Provider, removing secrets and personal data from
+// every request before it reaches the provider or the response cache. It must
+// be the outermost middleware.
+type RedactingProvider struct {
+	next     Provider
+	redactor *redact.Redactor
+	onRedact func(redact.Report)
+}
+
+// NewRedactingProvider wraps next so that requests are redacted by redactor.
+// onRedact, if set, is told about every request something was removed from.
+func NewRedactingProvider(next Provider, redactor *redact.Redactor, onRedact func(redact.Report)) *RedactingProvider {
+	return &RedactingProvider{next: next, redactor: redactor, onRedact: onRedact}
+}
+
+// GenerateCommitMessage generates a commit message from the redacted diff
+func (r *RedactingProvider) GenerateCommitMessage(ctx context.Context, diff string, options CommitOptions) (*CommitMessage, error) {
+	s := r.redactor.Session()
+	diff, err := r.diff(s, diff)
+	if err != nil {
+		return nil, err
+	}
+	options.Examples = redactAll(s, options.Examples)
+	options.Avoid = redactAll(s, options.Avoid)
+	options.Like = s.Text(options.Like)
+	options.Squashed = redactAll(s, options.Squashed)
+	r.report(s)
+
+	return r.next.GenerateCommitMessage(ctx, diff, options)
+}
+
+// GenerateSummary generates a summary of the redacted commits
+func (r *RedactingProvider) GenerateSummary(ctx context.Context, commits []Commit, options SummaryOptions) (*Summary, error) {
+	s := r.redactor.Session()
+	commits = redactCommits(s, commits)
+	r.report(s)
+
+	return r.next.GenerateSummary(ctx, commits, options)
+}
+
+// ReviewCode reviews the redacted diff
+func (r *RedactingProvider) ReviewCode(ctx context.Context, diff string, options ReviewOptions) (*Review, error) {
+	s := r.redactor.Session()
+	diff, err := r.diff(s, diff)
+	if err != nil {
+		return nil, err
+	}
+	r.report(s)
+
+	return r.next.ReviewCode(ctx, diff, options)
+}
+
+// GeneratePRDescription generates a PR description from the redacted analysis
+func (r *RedactingProvider) GeneratePRDescription(ctx context.Context, analysis PRAnalysis) (*PRDescriptionAI, error) {
+	s := r.redactor.Session()
+	diff, err := r.diff(s, analysis.Diff)
+	if err != nil {
+		return nil, err
+	}
+	analysis.Diff = diff
+	analysis.Commits = redactCommits(s, analysis.Commits)
+	analysis.CurrentBranch = s.Text(analysis.CurrentBranch)
+	analysis.TargetBranch = s.Text(analysis.TargetBranch)
+	r.report(s)
+
+	return r.next.GeneratePRDescription(ctx, analysis)
+}
+
+// PlanCommitSplit plans a commit split from the redacted hunks. Hunks of files
+// on the deny list are left out of the request.
+func (r *RedactingProvider) PlanCommitSplit(ctx context.Context, hunks []SplitHunk, options CommitOptions) (*SplitPlan, error) {
+	s := r.redactor.Session()
+	var redacted []SplitHunk
+	for _, h := range hunks {
+		h.Diff = s.Diff(h.Diff)
+		if strings.TrimSpace(h.Diff) == "" {
+			continue
+		}
+		redacted = append(redacted, h)
+	}
+	options.Examples = redactAll(s, options.Examples)
+	r.report(s)
+
+	if len(redacted) == 0 && len(hunks) > 0 {
+		return nil, ErrNothingToSend
+	}
+	return r.next.PlanCommitSplit(ctx, redacted, options)
+}
+
+// Withholds reports whether the contents of a file are never sent because it is
+// on the deny list
+func (r *RedactingProvider) Withholds(path string) bool {
+	return r.redactor.Denies(path)
+}
+
+// Close closes the wrapped provider
+func (r *RedactingProvider) Close() error {
+	return r.next.Close()
+}
+
+// diff redacts a diff, failing if the deny list left nothing of it
+func (r *RedactingProvider) diff(s *redact.Session, diff string) (string, error) {
+	redacted := s.Diff(diff)
+	if strings.TrimSpace(redacted) == "" && strings.TrimSpace(diff) != "" {
+		r.report(s)
+		return "", ErrNothingToSend
+	}
+	return redacted, nil
+}
+
+func (r *RedactingProvider) report(s *redact.Session) {
+	if report := s.Report(); r.onRedact != nil && !report.Empty() {
+		r.onRedact(report)
+	}
+}
+
+func redactAll(s *redact.Session, texts []string) []string {
+	if texts == nil {
+		return nil
+	}
+	redacted := make([]string, len(texts))
+	for i, text := range texts {
+		redacted[i] = s.Text(text)
+	}
+	return redacted
+}
+
+// redactCommits returns redacted copies of commits, leaving the caller's intact
+func redactCommits(s *redact.Session, commits []Commit) []Commit {
+	if commits == nil {
+		return nil
+	}
+	redacted := make([]Commit, len(commits))
+	for i, c := range commits {
+		c.Author = s.Text(c.Author)
+		c.Message = s.Text(c.Message)
+		redacted[i] = c
+	}
+	return redacted
+}
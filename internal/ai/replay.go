@@ -0,0 +1,248 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Replay modes
+const (
+	ReplayModeReplay = "replay" // answer from recorded fixtures, matched by prompt hash
+	ReplayModeRecord = "record" // send to the real provider and record every exchange
+	ReplayModeStatic = "static" // answer every request of a kind with the same canned response
+)
+
+// ErrNoRecording is returned in replay mode when no fixture matches the prompt
+var ErrNoRecording = errors.New("no recorded response")
+
+// ReplayConfig selects what the replay provider does
+type ReplayConfig struct {
+	Mode     string // replay, record or static
+	Dir      string // where fixtures are read and written
+	Provider string // the real provider used in record mode
+}
+
+// Fixture is a recorded request and response
+type Fixture struct {
+	Kind       string    `json:"kind"`
+	PromptHash string    `json:"prompt_hash"`
+	Provider   string    `json:"provider"`
+	Model      string    `json:"model"`
+	RecordedAt time.Time `json:"recorded_at"`
+	Prompt     string    `json:"prompt"`
+	Response   string    `json:"response"`
+}
+
+// FixturePath returns the file a request of kind with prompt is recorded in
+func FixturePath(dir, kind, prompt string) string {
+	return filepath.Join(dir, fmt.Sprintf("%s-%s.json", kind, PromptHash(prompt)[:16]))
+}
+
+// StaticPath returns the file holding the canned response for kind
+func StaticPath(dir, kind string) string {
+	return filepath.Join(dir, kind+".txt")
+}
+
+// ReplayProvider answers requests from files instead of an API. Prompts are built
+// and responses parsed exactly as for the real providers, so a replayed run
+// exercises everything but the network.
+type ReplayProvider struct {
+	mode string
+	dir  string
+}
+
+// NewReplayProvider creates a provider answering from the fixtures in dir, by
+// prompt hash in replay mode or per request kind in static mode
+func NewReplayProvider(mode, dir string) (*ReplayProvider, error) {
+	if mode == "" {
+		mode = ReplayModeReplay
+	}
+	if mode != ReplayModeReplay && mode != ReplayModeStatic {
+		return nil, fmt.Errorf("unsupported replay mode: %s", mode)
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("replay directory %s not found, record fixtures first with ai.replay.mode: record", dir)
+	}
+	return &ReplayProvider{mode: mode, dir: dir}, nil
+}
+
+// newReplayProvider creates the provider for ai.provider: replay. In record mode
+// that is the real provider, with a hook writing every exchange to the fixture
+// directory.
+func newReplayProvider(config ProviderConfig) (Provider, error) {
+	if config.Replay.Mode != ReplayModeRecord {
+		return NewReplayProvider(config.Replay.Mode, config.Replay.Dir)
+	}
+
+	upstream := config
+	upstream.Provider = config.Replay.Provider
+	if upstream.Provider == "" || upstream.Provider == "replay" {
+		return nil, fmt.Errorf("record mode needs the real provider to record, set ai.replay.provider")
+	}
+	provider, err := NewProvider(upstream)
+	if err != nil {
+		return nil, err
+	}
+
+	RegisterCallHook(func(ctx context.Context, call *Call) {
+		if call.Err != nil {
+			return
+		}
+		if err := WriteFixture(config.Replay.Dir, Fixture{
+			Kind:       call.Kind,
+			PromptHash: PromptHash(call.Prompt),
+			Provider:   call.Provider,
+			Model:      call.Model,
+			RecordedAt: call.Started.UTC(),
+			Prompt:     call.Prompt,
+			Response:   call.Response,
+		}); err != nil {
+			fmt.Fprintf(os.Stderr, "Could not record the response: %v\n", err)
+		}
+	})
+	return provider, nil
+}
+
+// WriteFixture saves a recorded exchange in dir
+func WriteFixture(dir string, fixture Fixture) error {
+	data, err := json.MarshalIndent(fixture, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode fixture: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create replay directory: %w", err)
+	}
+	path := FixturePath(dir, fixture.Kind, fixture.Prompt)
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write fixture: %w", err)
+	}
+	return nil
+}
+
+// respond returns the recorded response to prompt
+func (r *ReplayProvider) respond(kind, prompt string) (string, error) {
+	if r.mode == ReplayModeStatic {
+		path := StaticPath(r.dir, kind)
+		data, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			return "", fmt.Errorf("%w for %s requests: create %s", ErrNoRecording, kind, path)
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to read canned response: %w", err)
+		}
+		return string(data), nil
+	}
+
+	path := FixturePath(r.dir, kind, prompt)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("%w for this %s prompt (%s), the prompt changed or was never recorded; record it with ai.replay.mode: record",
+			ErrNoRecording, kind, filepath.Base(path))
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read fixture: %w", err)
+	}
+
+	var fixture Fixture
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		return "", fmt.Errorf("failed to parse fixture %s: %w", path, err)
+	}
+	// The file name only holds part of the hash
+	if fixture.PromptHash != PromptHash(prompt) {
+		return "", fmt.Errorf("%w for this %s prompt, %s belongs to another prompt", ErrNoRecording, kind, path)
+	}
+	return fixture.Response, nil
+}
+
+// GenerateCommitMessage answers a commit message request from the fixtures
+func (r *ReplayProvider) GenerateCommitMessage(ctx context.Context, diff string, options CommitOptions) (*CommitMessage, error) {
+	prompt, err := commitMessagePrompt(diff, options)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := r.respond(KindCommit, prompt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate commit message: %w", err)
+	}
+
+	return parseCommitMessage(response, options.Conventional), nil
+}
+
+// GenerateSummary answers a summary request from the fixtures
+func (r *ReplayProvider) GenerateSummary(ctx context.Context, commits []Commit, options SummaryOptions) (*Summary, error) {
+	prompt, err := summaryPrompt(commits, options)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := r.respond(KindSummary, prompt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate summary: %w", err)
+	}
+
+	var summary Summary
+	if err := json.Unmarshal([]byte(response), &summary); err != nil {
+		summary = parseSummaryText(response, commits, options)
+	}
+	return &summary, nil
+}
+
+// ReviewCode answers a review request from the fixtures
+func (r *ReplayProvider) ReviewCode(ctx context.Context, diff string, options ReviewOptions) (*Review, error) {
+	prompt, err := reviewPrompt(diff, options)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := r.respond(KindReview, prompt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to review code: %w", err)
+	}
+
+	return parseReviewResponse(response, options), nil
+}
+
+// GeneratePRDescription answers a PR description request from the fixtures
+func (r *ReplayProvider) GeneratePRDescription(ctx context.Context, analysis PRAnalysis) (*PRDescriptionAI, error) {
+	prompt, err := prDescriptionPrompt(analysis)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := r.respond(KindPR, prompt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate PR description: %w", err)
+	}
+
+	var prDesc PRDescriptionAI
+	if err := json.Unmarshal([]byte(response), &prDesc); err != nil {
+		return parsePRDescriptionFromText(response), nil
+	}
+	return &prDesc, nil
+}
+
+// PlanCommitSplit answers a split request from the fixtures
+func (r *ReplayProvider) PlanCommitSplit(ctx context.Context, hunks []SplitHunk, options CommitOptions) (*SplitPlan, error) {
+	prompt, err := splitPrompt(hunks, options)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := r.respond(KindSplit, prompt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to plan commit split: %w", err)
+	}
+
+	return parseSplitPlan(response)
+}
+
+// Close does nothing, there is no connection to close
+func (r *ReplayProvider) Close() error {
+	return nil
+}
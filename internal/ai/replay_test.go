@@ -0,0 +1,95 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const replayDiff = `diff --git a/main.go b/main.go
+--- a/main.go
++++ b/main.go
+@@ -1,3 +1,4 @@
+ package main
++// greet prints a greeting
+ func greet() {}
+`
+
+func TestRecordAndReplay(t *testing.T) {
+	t.Cleanup(ResetCallHooks)
+	dir := filepath.Join(t.TempDir(), "replay")
+	ctx := context.Background()
+	options := CommitOptions{Conventional: true}
+
+	recorder, err := newReplayProvider(ProviderConfig{
+		Provider: "replay",
+		APIKey:   "sk-test",
+		Replay:   ReplayConfig{Mode: ReplayModeRecord, Dir: dir, Provider: "openai"},
+	})
+	if err != nil {
+		t.Fatalf("record mode: %v", err)
+	}
+	if _, ok := recorder.(*OpenAIProvider); !ok {
+		t.Fatalf("record mode returned %T, want the real provider", recorder)
+	}
+
+	// Stand in for the API: every request goes through invoke, which runs the recorder
+	prompt, err := commitMessagePrompt(replayDiff, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = invoke(ctx, KindCommit, "openai", "gpt-4o-mini", prompt, nil, func(ctx context.Context) (string, Usage, error) {
+		return "docs(main): document greet", Usage{}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	replay, err := NewReplayProvider(ReplayModeReplay, dir)
+	if err != nil {
+		t.Fatalf("NewReplayProvider: %v", err)
+	}
+	msg, err := replay.GenerateCommitMessage(ctx, replayDiff, options)
+	if err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+	if msg.Type != "docs" || msg.Scope != "main" || msg.Subject != "document greet" {
+		t.Errorf("replayed message = %+v", msg)
+	}
+
+	// A different prompt has no recording
+	_, err = replay.GenerateCommitMessage(ctx, replayDiff, CommitOptions{Conventional: true, Type: "feat"})
+	if !errors.Is(err, ErrNoRecording) {
+		t.Errorf("changed prompt: err = %v, want ErrNoRecording", err)
+	}
+}
+
+func TestStaticReplay(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(StaticPath(dir, KindCommit), []byte("fix: handle empty input"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	static, err := NewReplayProvider(ReplayModeStatic, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, diff := range []string{replayDiff, "any other diff"} {
+		msg, err := static.GenerateCommitMessage(context.Background(), diff, CommitOptions{Conventional: true})
+		if err != nil {
+			t.Fatalf("static: %v", err)
+		}
+		if msg.FullMessage != "fix: handle empty input" {
+			t.Errorf("static message = %q", msg.FullMessage)
+		}
+	}
+
+	if _, err := static.ReviewCode(context.Background(), replayDiff, ReviewOptions{}); !errors.Is(err, ErrNoRecording) {
+		t.Errorf("missing canned review: err = %v, want ErrNoRecording", err)
+	}
+	if _, err := NewReplayProvider(ReplayModeReplay, filepath.Join(dir, "missing")); err == nil {
+		t.Error("a missing fixture directory was accepted")
+	}
+}
@@ -0,0 +1,265 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+	"golang.org/x/time/rate"
+	"google.golang.org/api/googleapi"
+)
+
+// RetryPolicy controls how failed requests are retried
+type RetryPolicy struct {
+	MaxAttempts  int           // attempts including the first, 1 disables retries
+	InitialDelay time.Duration // delay before the first retry, doubled for every further one
+	MaxDelay     time.Duration // upper bound of a single delay, including Retry-After
+}
+
+// DefaultRetryPolicy is used when no policy is configured
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:  4,
+	InitialDelay: time.Second,
+	MaxDelay:     30 * time.Second,
+}
+
+// RateLimit is a client-side limit on requests to a provider
+type RateLimit struct {
+	RequestsPerMinute float64 // 0 for no limit
+	Burst             int     // requests that may be sent at once
+}
+
+// retryOutput receives a line for every retry
+var retryOutput io.Writer = os.Stderr
+
+// requestPolicy applies the retry policy and rate limit to provider requests
+type requestPolicy struct {
+	provider string
+	retry    RetryPolicy
+	limiter  *rate.Limiter
+}
+
+// newRequestPolicy returns the policy for provider. Providers of the same name
+// share one limiter, so concurrent requests draw from the same bucket.
+func newRequestPolicy(provider string, retry RetryPolicy, limit RateLimit) *requestPolicy {
+	if retry.MaxAttempts < 1 {
+		retry.MaxAttempts = 1
+	}
+	return &requestPolicy{
+		provider: provider,
+		retry:    retry,
+		limiter:  sharedLimiter(provider, limit),
+	}
+}
+
+var (
+	limitersMu sync.Mutex
+	limiters   = make(map[string]*rate.Limiter)
+)
+
+func sharedLimiter(provider string, limit RateLimit) *rate.Limiter {
+	if limit.RequestsPerMinute <= 0 {
+		return nil
+	}
+	burst := max(limit.Burst, 1)
+	every := rate.Limit(limit.RequestsPerMinute / 60)
+
+	limitersMu.Lock()
+	defer limitersMu.Unlock()
+	limiter, ok := limiters[provider]
+	if !ok {
+		limiter = rate.NewLimiter(every, burst)
+		limiters[provider] = limiter
+	} else {
+		limiter.SetLimit(every)
+		limiter.SetBurst(burst)
+	}
+	return limiter
+}
+
+// do runs send until it succeeds, fails with an error that is not worth
+// retrying, or runs out of attempts. A nil policy sends once without a limit.
+func (p *requestPolicy) do(ctx context.Context, send func(ctx context.Context) (string, Usage, error)) (string, Usage, error) {
+	if p == nil {
+		return send(ctx)
+	}
+
+	for attempt := 1; ; attempt++ {
+		if p.limiter != nil {
+			if err := p.limiter.Wait(ctx); err != nil {
+				return "", Usage{}, fmt.Errorf("failed to wait for the %s rate limit: %w", p.provider, err)
+			}
+		}
+
+		hint := &retryAfterHint{}
+		response, usage, err := send(context.WithValue(ctx, retryAfterKey{}, hint))
+		if err == nil || attempt >= p.retry.MaxAttempts || ctx.Err() != nil {
+			return response, usage, err
+		}
+
+		retryable, retryAfter := classifyError(err)
+		if !retryable {
+			return response, usage, err
+		}
+		if retryAfter == 0 {
+			retryAfter = hint.delay
+		}
+
+		delay := p.backoff(attempt)
+		if retryAfter > 0 {
+			if retryAfter > p.retry.MaxDelay {
+				// Waiting that long is not what anyone expects from a CLI
+				return response, usage, fmt.Errorf("%s asked to retry after %s: %w", p.provider, retryAfter.Round(time.Second), err)
+			}
+			delay = retryAfter
+		}
+
+		fmt.Fprintf(retryOutput, "%s request failed (%v), retrying in %s (attempt %d/%d)\n",
+			p.provider, err, delay.Round(100*time.Millisecond), attempt+1, p.retry.MaxAttempts)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return "", Usage{}, ctx.Err()
+		}
+	}
+}
+
+// backoff returns the delay before retry number attempt: exponential, capped,
+// with up to half of it randomised so that clients do not retry in lockstep
+func (p *requestPolicy) backoff(attempt int) time.Duration {
+	delay := p.retry.InitialDelay
+	for i := 1; i < attempt && delay < p.retry.MaxDelay; i++ {
+		delay *= 2
+	}
+	if p.retry.MaxDelay > 0 && delay > p.retry.MaxDelay {
+		delay = p.retry.MaxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+	half := delay / 2
+	return half + rand.N(half+1)
+}
+
+// classifyError reports whether a failed request may succeed when sent again,
+// and how long the provider asked to wait if it said so
+func classifyError(err error) (bool, time.Duration) {
+	var providerErr *ProviderError
+	if errors.As(err, &providerErr) {
+		switch {
+		case errors.Is(providerErr.Kind, ErrRateLimited), errors.Is(providerErr.Kind, ErrTimeout):
+			return true, providerErr.RetryAfter
+		case providerErr.Kind != nil:
+			// Quota, authentication, length and safety failures repeat on every attempt
+			return false, 0
+		case providerErr.StatusCode != 0:
+			return retryableStatus(providerErr.StatusCode), providerErr.RetryAfter
+		}
+		return isNetworkError(providerErr.Err), 0
+	}
+
+	var apiErr *openai.APIError
+	if errors.As(err, &apiErr) {
+		return retryableStatus(apiErr.HTTPStatusCode), 0
+	}
+	var requestErr *openai.RequestError
+	if errors.As(err, &requestErr) {
+		if requestErr.HTTPStatusCode == 0 {
+			return isNetworkError(requestErr.Err), 0
+		}
+		return retryableStatus(requestErr.HTTPStatusCode), 0
+	}
+	var googleErr *googleapi.Error
+	if errors.As(err, &googleErr) {
+		return retryableStatus(googleErr.Code), parseRetryAfter(googleErr.Header)
+	}
+	return isNetworkError(err), 0
+}
+
+func retryableStatus(code int) bool {
+	switch code {
+	case http.StatusRequestTimeout, http.StatusTooManyRequests,
+		http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// isNetworkError reports timeouts and dropped connections
+func isNetworkError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	if errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	message := err.Error()
+	return strings.Contains(message, "connection reset") || strings.Contains(message, "broken pipe")
+}
+
+// parseRetryAfter reads Retry-After in seconds or as a date, and OpenAI's
+// retry-after-ms
+func parseRetryAfter(header http.Header) time.Duration {
+	if header == nil {
+		return 0
+	}
+	if ms, err := strconv.ParseFloat(header.Get("Retry-After-Ms"), 64); err == nil && ms > 0 {
+		return time.Duration(ms * float64(time.Millisecond))
+	}
+	value := header.Get("Retry-After")
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds > 0 {
+		return time.Duration(seconds * float64(time.Second))
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(time.Until(at), 0)
+	}
+	return 0
+}
+
+// The OpenAI client's errors do not carry response headers, so Retry-After is
+// picked up by the HTTP transport and handed back through the request context
+type retryAfterKey struct{}
+
+type retryAfterHint struct {
+	delay time.Duration
+}
+
+// retryAfterTransport records the Retry-After header of failed responses
+type retryAfterTransport struct {
+	base http.RoundTripper
+}
+
+func (t *retryAfterTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err == nil && resp.StatusCode >= 400 {
+		if hint, ok := req.Context().Value(retryAfterKey{}).(*retryAfterHint); ok {
+			hint.delay = parseRetryAfter(resp.Header)
+		}
+	}
+	return resp, err
+}
@@ -0,0 +1,95 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+	"google.golang.org/api/googleapi"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		retryable  bool
+		retryAfter time.Duration
+	}{
+		{"openai rate limit", &openai.APIError{HTTPStatusCode: 429}, true, 0},
+		{"openai server error", &openai.RequestError{HTTPStatusCode: 502}, true, 0},
+		{"openai bad request", &openai.APIError{HTTPStatusCode: 400}, false, 0},
+		{"openai auth", &openai.APIError{HTTPStatusCode: 401}, false, 0},
+		{"gemini quota with retry-after", &googleapi.Error{Code: 429, Header: http.Header{"Retry-After": {"7"}}}, true, 7 * time.Second},
+		{"gemini unavailable", &googleapi.Error{Code: 503}, true, 0},
+		{"gemini invalid argument", &googleapi.Error{Code: 400}, false, 0},
+		{"timeout", context.DeadlineExceeded, true, 0},
+		{"connection reset", errors.New("read tcp: connection reset by peer"), true, 0},
+		{"unexpected eof", io.ErrUnexpectedEOF, true, 0},
+		{"other", errors.New("no response from OpenAI"), false, 0},
+	}
+	for _, tt := range tests {
+		retryable, retryAfter := classifyError(tt.err)
+		if retryable != tt.retryable || retryAfter != tt.retryAfter {
+			t.Errorf("%s: classifyError = %v, %v, want %v, %v", tt.name, retryable, retryAfter, tt.retryable, tt.retryAfter)
+		}
+	}
+}
+
+func TestRequestPolicyRetries(t *testing.T) {
+	retryOutput = io.Discard
+	policy := newRequestPolicy("test", RetryPolicy{MaxAttempts: 3, InitialDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond}, RateLimit{})
+
+	var attempts int
+	response, _, err := policy.do(context.Background(), func(ctx context.Context) (string, Usage, error) {
+		attempts++
+		if attempts < 3 {
+			return "", Usage{}, &openai.APIError{HTTPStatusCode: 503}
+		}
+		return "ok", Usage{}, nil
+	})
+	if err != nil || response != "ok" || attempts != 3 {
+		t.Fatalf("do = %q, %v after %d attempts, want ok after 3", response, err, attempts)
+	}
+
+	attempts = 0
+	_, _, err = policy.do(context.Background(), func(ctx context.Context) (string, Usage, error) {
+		attempts++
+		return "", Usage{}, &openai.APIError{HTTPStatusCode: 401}
+	})
+	if err == nil || attempts != 1 {
+		t.Errorf("a non-retryable error was sent %d times, want 1", attempts)
+	}
+
+	attempts = 0
+	_, _, err = policy.do(context.Background(), func(ctx context.Context) (string, Usage, error) {
+		attempts++
+		return "", Usage{}, &googleapi.Error{Code: 429, Header: http.Header{"Retry-After": {"3600"}}}
+	})
+	if err == nil || attempts != 1 {
+		t.Errorf("a Retry-After beyond the maximum delay was retried %d times, want 1 attempt", attempts)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	policy := newRequestPolicy("test", RetryPolicy{MaxAttempts: 10, InitialDelay: time.Second, MaxDelay: 8 * time.Second}, RateLimit{})
+	for attempt, want := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 6: 8 * time.Second} {
+		got := policy.backoff(attempt)
+		if got < want/2 || got > want {
+			t.Errorf("backoff(%d) = %v, want between %v and %v", attempt, got, want/2, want)
+		}
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	if got := parseRetryAfter(http.Header{"Retry-After-Ms": {"1500"}}); got != 1500*time.Millisecond {
+		t.Errorf("retry-after-ms = %v", got)
+	}
+	date := time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)
+	if got := parseRetryAfter(http.Header{"Retry-After": {date}}); got < 58*time.Second || got > time.Minute {
+		t.Errorf("Retry-After date = %v, want about a minute", got)
+	}
+}
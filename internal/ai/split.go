@@ -0,0 +1,51 @@
+package ai
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// SplitHunk is one numbered part of the staged changes offered for grouping
+type SplitHunk struct {
+	ID   string
+	Path string
+	Diff string // the hunk as a diff of its file
+}
+
+// SplitCommit is one commit of a split plan
+type SplitCommit struct {
+	Hunks   []string `json:"hunks"`
+	Message string   `json:"message"`
+}
+
+// SplitPlan groups staged hunks into commits, in the order they should be made
+type SplitPlan struct {
+	Commits []SplitCommit `json:"commits"`
+}
+
+// parseSplitPlan reads the JSON plan from a response, ignoring any text or code
+// fence around it
+func parseSplitPlan(text string) (*SplitPlan, error) {
+	start := strings.Index(text, "{")
+	end := strings.LastIndex(text, "}")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("failed to parse split plan: no JSON object in response")
+	}
+
+	var plan SplitPlan
+	if err := json.Unmarshal([]byte(text[start:end+1]), &plan); err != nil {
+		return nil, fmt.Errorf("failed to parse split plan: %w", err)
+	}
+	if len(plan.Commits) == 0 {
+		return nil, fmt.Errorf("failed to parse split plan: no commits in response")
+	}
+
+	for i := range plan.Commits {
+		plan.Commits[i].Message = strings.TrimSpace(plan.Commits[i].Message)
+		for j, id := range plan.Commits[i].Hunks {
+			plan.Commits[i].Hunks[j] = strings.ToUpper(strings.TrimSpace(id))
+		}
+	}
+	return &plan, nil
+}
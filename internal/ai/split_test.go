@@ -0,0 +1,29 @@
+package ai
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseSplitPlan(t *testing.T) {
+	response := "```json\n" + `{"commits": [
+  {"hunks": ["h2", " H3"], "message": "refactor(list): extract counter\n\nKeeps Len simple.\n"},
+  {"hunks": ["H1"], "message": "docs(list): describe List"}
+]}` + "\n```"
+
+	plan, err := parseSplitPlan(response)
+	if err != nil {
+		t.Fatalf("parseSplitPlan: %v", err)
+	}
+	want := []SplitCommit{
+		{Hunks: []string{"H2", "H3"}, Message: "refactor(list): extract counter\n\nKeeps Len simple."},
+		{Hunks: []string{"H1"}, Message: "docs(list): describe List"},
+	}
+	if !reflect.DeepEqual(plan.Commits, want) {
+		t.Errorf("commits = %+v, want %+v", plan.Commits, want)
+	}
+
+	if _, err := parseSplitPlan("I would split this into two commits."); err == nil {
+		t.Error("expected an error for a response without JSON")
+	}
+}
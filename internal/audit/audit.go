@@ -0,0 +1,128 @@
+// Package audit keeps an append-only JSONL log of the requests sent to AI providers
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FileName is the name of the default audit log in the aig config directory
+const FileName = "audit.jsonl"
+
+// maxLineSize bounds a single entry when reading the log; entries that store the
+// full prompt can be large
+const maxLineSize = 64 * 1024 * 1024
+
+// Entry records a single request
+type Entry struct {
+	Time             time.Time `json:"time"`
+	Command          string    `json:"command"`
+	Kind             string    `json:"kind"`
+	Repo             string    `json:"repo,omitempty"`
+	Commit           string    `json:"commit,omitempty"`
+	Provider         string    `json:"provider"`
+	Model            string    `json:"model"`
+	PromptHash       string    `json:"prompt_hash"`
+	PromptBytes      int       `json:"prompt_bytes"`
+	ResponseBytes    int       `json:"response_bytes"`
+	PromptTokens     int       `json:"prompt_tokens"`
+	CompletionTokens int       `json:"completion_tokens"`
+	TokensEstimated  bool      `json:"tokens_estimated,omitempty"`
+	Files            []string  `json:"files,omitempty"`
+	DurationMS       int64     `json:"duration_ms"`
+	Error            string    `json:"error,omitempty"`
+
+	// Only recorded when the log is configured to store them
+	Prompt   string `json:"prompt,omitempty"`
+	Response string `json:"response,omitempty"`
+}
+
+// Log is an audit log file. Entries are only ever appended.
+type Log struct {
+	Path string
+}
+
+// Open returns the log at path
+func Open(path string) *Log {
+	return &Log{Path: path}
+}
+
+// Append writes an entry at the end of the log, creating it if needed
+func (l *Log) Append(entry Entry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit entry: %w", err)
+	}
+	data = append(data, '\n')
+
+	if err := os.MkdirAll(filepath.Dir(l.Path), 0700); err != nil {
+		return fmt.Errorf("failed to create audit log directory: %w", err)
+	}
+	file, err := os.OpenFile(l.Path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer file.Close()
+
+	// A single write keeps concurrent entries from interleaving
+	if _, err := file.Write(data); err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return nil
+}
+
+// Filter selects entries when reading the log
+type Filter struct {
+	Since   time.Time // zero for all entries
+	Command string    // empty for every command
+	Repo    string    // empty for every repository
+}
+
+func (f Filter) match(e Entry) bool {
+	return (f.Since.IsZero() || !e.Time.Before(f.Since)) &&
+		(f.Command == "" || e.Command == f.Command) &&
+		(f.Repo == "" || e.Repo == f.Repo)
+}
+
+// Read returns the entries matching filter, oldest first. A missing log has no
+// entries. Lines that cannot be parsed are reported as an error after reading.
+func (l *Log) Read(filter Filter) ([]Entry, error) {
+	file, err := os.Open(l.Path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer file.Close()
+
+	var entries []Entry
+	var bad int
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			bad++
+			continue
+		}
+		if filter.match(entry) {
+			entries = append(entries, entry)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return entries, fmt.Errorf("failed to read audit log: %w", err)
+	}
+	if bad > 0 {
+		return entries, fmt.Errorf("skipped %d unreadable line(s) in %s", bad, l.Path)
+	}
+	return entries, nil
+}
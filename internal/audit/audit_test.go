@@ -0,0 +1,39 @@
+package audit
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAppendAndRead(t *testing.T) {
+	log := Open(filepath.Join(t.TempDir(), "nested", FileName))
+
+	now := time.Now().UTC().Truncate(time.Second)
+	entries := []Entry{
+		{Time: now.Add(-48 * time.Hour), Command: "commit", Provider: "openai", Files: []string{"a.go"}},
+		{Time: now.Add(-time.Hour), Command: "review", Provider: "gemini", Prompt: "full prompt"},
+		{Time: now, Command: "commit", Provider: "openai"},
+	}
+	for _, e := range entries {
+		if err := log.Append(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	all, err := log.Read(Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 3 || all[0].Files[0] != "a.go" || all[1].Prompt != "full prompt" {
+		t.Fatalf("unexpected entries %+v", all)
+	}
+
+	recent, err := log.Read(Filter{Since: now.Add(-24 * time.Hour), Command: "commit"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recent) != 1 || !recent[0].Time.Equal(now) {
+		t.Errorf("unexpected filtered entries %+v", recent)
+	}
+}
@@ -0,0 +1,266 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Store is a simple on-disk key/value store kept under the user cache directory.
+// Every entry is a JSON file named after its key.
+type Store struct {
+	namespace string
+	dir       string
+	options   Options
+}
+
+// Options limits how long and how much a store keeps
+type Options struct {
+	TTL      time.Duration // entries older than this are treated as missing; zero keeps them forever
+	MaxBytes int64         // oldest entries are evicted above this size; zero means unlimited
+}
+
+// Stats describes the contents of a store
+type Stats struct {
+	Namespace string
+	Dir       string
+	Entries   int
+	Bytes     int64
+}
+
+// RootDir returns the directory holding all aig caches
+func RootDir() (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user cache directory: %w", err)
+	}
+	return filepath.Join(cacheDir, "aig"), nil
+}
+
+// Open returns the store for a namespace, creating its directory if needed
+func Open(namespace string) (*Store, error) {
+	return OpenWithOptions(namespace, Options{})
+}
+
+// OpenWithOptions returns the store for a namespace with expiry and size limits
+func OpenWithOptions(namespace string, options Options) (*Store, error) {
+	root, err := RootDir()
+	if err != nil {
+		return nil, err
+	}
+
+	dir := filepath.Join(root, namespace)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	return &Store{namespace: namespace, dir: dir, options: options}, nil
+}
+
+// Namespaces lists the stores that currently exist on disk
+func Namespaces() ([]string, error) {
+	root, err := RootDir()
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read cache directory: %w", err)
+	}
+
+	var namespaces []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			namespaces = append(namespaces, entry.Name())
+		}
+	}
+	sort.Strings(namespaces)
+	return namespaces, nil
+}
+
+// Key derives a cache key from its parts
+func Key(parts ...string) string {
+	h := sha256.New()
+	for _, part := range parts {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Get loads the entry for key into v. It reports false when there is no entry.
+func (s *Store) Get(key string, v interface{}) (bool, error) {
+	path := s.path(key)
+	if s.options.TTL > 0 {
+		info, err := os.Stat(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				return false, nil
+			}
+			return false, fmt.Errorf("failed to read cache entry: %w", err)
+		}
+		if time.Since(info.ModTime()) > s.options.TTL {
+			os.Remove(path)
+			return false, nil
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to read cache entry: %w", err)
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		// A corrupt entry is treated as a miss and overwritten later
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// Put stores v under key
+func (s *Store) Put(key string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode cache entry: %w", err)
+	}
+
+	path := s.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	// Write to a temporary file first so that readers never see partial entries
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+
+	if s.options.MaxBytes > 0 {
+		return s.Prune()
+	}
+
+	return nil
+}
+
+// Prune removes expired entries and then the oldest entries until the store
+// fits within its size limit
+func (s *Store) Prune() error {
+	type entry struct {
+		path    string
+		size    int64
+		modTime time.Time
+	}
+
+	var entries []entry
+	var total int64
+	err := s.walk(func(path string, info fs.FileInfo) error {
+		if s.options.TTL > 0 && time.Since(info.ModTime()) > s.options.TTL {
+			os.Remove(path)
+			return nil
+		}
+		entries = append(entries, entry{path: path, size: info.Size(), modTime: info.ModTime()})
+		total += info.Size()
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to prune cache %s: %w", s.namespace, err)
+	}
+
+	if s.options.MaxBytes <= 0 || total <= s.options.MaxBytes {
+		return nil
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].modTime.Before(entries[j].modTime)
+	})
+	for _, e := range entries {
+		if total <= s.options.MaxBytes {
+			break
+		}
+		if err := os.Remove(e.path); err == nil {
+			total -= e.size
+		}
+	}
+
+	return nil
+}
+
+// Stats counts the entries of the store and their total size
+func (s *Store) Stats() (Stats, error) {
+	stats := Stats{Namespace: s.namespace, Dir: s.dir}
+	err := s.walk(func(path string, info fs.FileInfo) error {
+		stats.Entries++
+		stats.Bytes += info.Size()
+		return nil
+	})
+	return stats, err
+}
+
+// Clear removes every entry of the store
+func (s *Store) Clear() error {
+	if err := os.RemoveAll(s.dir); err != nil {
+		return fmt.Errorf("failed to clear cache %s: %w", s.namespace, err)
+	}
+	return os.MkdirAll(s.dir, 0755)
+}
+
+// Dir returns the directory backing the store
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+func (s *Store) path(key string) string {
+	// Shard by the first two characters to keep directories small
+	shard := "00"
+	if len(key) >= 2 {
+		shard = key[:2]
+	}
+	return filepath.Join(s.dir, shard, key+".json")
+}
+
+func (s *Store) walk(fn func(path string, info fs.FileInfo) error) error {
+	return filepath.WalkDir(s.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(d.Name(), ".json") {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		return fn(path, info)
+	})
+}
@@ -0,0 +1,110 @@
+package cache
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func openTestStore(t *testing.T, options Options) *Store {
+	t.Helper()
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	store, err := OpenWithOptions("test", options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+// age moves the modification time of an entry into the past
+func age(t *testing.T, s *Store, key string, by time.Duration) {
+	t.Helper()
+	past := time.Now().Add(-by)
+	if err := os.Chtimes(s.path(key), past, past); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStoreGetPut(t *testing.T) {
+	s := openTestStore(t, Options{})
+
+	var got string
+	if found, err := s.Get(Key("missing"), &got); err != nil || found {
+		t.Fatalf("Get of a missing key = %v, %v", found, err)
+	}
+
+	key := Key("a", "b")
+	if err := s.Put(key, "value"); err != nil {
+		t.Fatal(err)
+	}
+	if found, err := s.Get(key, &got); err != nil || !found || got != "value" {
+		t.Errorf("Get = %q, %v, %v, want the stored value", got, found, err)
+	}
+
+	if Key("a", "b") == Key("ab") {
+		t.Error("Key must separate its parts")
+	}
+}
+
+func TestStoreTTL(t *testing.T) {
+	s := openTestStore(t, Options{TTL: time.Hour})
+
+	key := Key("old")
+	if err := s.Put(key, "value"); err != nil {
+		t.Fatal(err)
+	}
+	age(t, s, key, 2*time.Hour)
+
+	var got string
+	if found, err := s.Get(key, &got); err != nil || found {
+		t.Errorf("an expired entry should be a miss, got %v, %v", found, err)
+	}
+	if _, err := os.Stat(s.path(key)); !os.IsNotExist(err) {
+		t.Error("an expired entry should be removed")
+	}
+}
+
+func TestStoreSizeEviction(t *testing.T) {
+	s := openTestStore(t, Options{})
+
+	// Every entry is the 12 bytes of a JSON string of 10 characters
+	keys := []string{Key("oldest"), Key("middle"), Key("newest")}
+	for i, key := range keys {
+		if err := s.Put(key, "0123456789"); err != nil {
+			t.Fatal(err)
+		}
+		age(t, s, key, time.Duration(len(keys)-i)*time.Minute)
+	}
+
+	s.options.MaxBytes = 30
+	if err := s.Prune(); err != nil {
+		t.Fatal(err)
+	}
+
+	var got string
+	for i, key := range keys {
+		found, err := s.Get(key, &got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := i > 0; found != want {
+			t.Errorf("entry %d present = %v, want %v", i, found, want)
+		}
+	}
+
+	stats, err := s.Stats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Entries != 2 || stats.Bytes != 24 {
+		t.Errorf("stats = %+v, want 2 entries of 24 bytes", stats)
+	}
+
+	if err := s.Clear(); err != nil {
+		t.Fatal(err)
+	}
+	if stats, _ := s.Stats(); stats.Entries != 0 {
+		t.Errorf("%d entries left after Clear", stats.Entries)
+	}
+}
@@ -0,0 +1,295 @@
+package commands
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/tarantino19/aig/internal/ai"
+	"github.com/tarantino19/aig/internal/audit"
+	"github.com/tarantino19/aig/internal/config"
+	"github.com/tarantino19/aig/internal/git"
+	"github.com/tarantino19/aig/internal/ui"
+)
+
+var (
+	auditSince   string
+	auditCommand string
+	auditLimit   int
+	auditFull    bool
+	auditFormat  string
+	auditOutput  string
+)
+
+// NewAuditCmd creates the audit command
+func NewAuditCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Inspect the log of requests sent to AI providers",
+		Long: `With audit.enabled set, every request sent to an AI provider is appended to
+an audit log: when, by which command, from which repository and commit, to
+which provider and model, how large it was and which files it included. With
+audit.store_prompts the full prompt and response are recorded as well.`,
+	}
+
+	cmd.PersistentFlags().StringVar(&auditSince, "since", "", "Only entries newer than this, e.g. 24h, 7d or 2025-01-31")
+	cmd.PersistentFlags().StringVar(&auditCommand, "command", "", "Only entries of this command, e.g. commit")
+
+	cmd.AddCommand(newAuditShowCmd())
+	cmd.AddCommand(newAuditExportCmd())
+
+	return cmd
+}
+
+func newAuditShowCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "show",
+		Short: "Show recent audit log entries",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			entries, err := readAuditLog()
+			if err != nil {
+				return err
+			}
+			if len(entries) == 0 {
+				ui.ShowInfo("No audit log entries")
+				return nil
+			}
+
+			if auditLimit > 0 && len(entries) > auditLimit {
+				entries = entries[len(entries)-auditLimit:]
+			}
+
+			for _, e := range entries {
+				status := ""
+				if e.Error != "" {
+					status = "  failed: " + e.Error
+				}
+				fmt.Printf("%s  %-7s %-7s %s/%s  %d bytes  %d+%d tokens%s\n",
+					e.Time.Local().Format("2006-01-02 15:04:05"), e.Command, e.Kind, e.Provider, e.Model,
+					e.PromptBytes, e.PromptTokens, e.CompletionTokens, status)
+				if e.Repo != "" {
+					fmt.Printf("    repo %s @ %s\n", e.Repo, shortHash(e.Commit))
+				}
+				if len(e.Files) > 0 {
+					fmt.Printf("    files %s\n", strings.Join(e.Files, ", "))
+				}
+				if auditFull && e.Prompt != "" {
+					fmt.Printf("\n--- prompt %s ---\n%s\n--- response ---\n%s\n\n", e.PromptHash[:12], e.Prompt, e.Response)
+				}
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().IntVarP(&auditLimit, "number", "n", 20, "Number of entries to show, 0 for all")
+	cmd.Flags().BoolVar(&auditFull, "full", false, "Print stored prompts and responses")
+
+	return cmd
+}
+
+func newAuditExportCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export audit log entries as JSON Lines, JSON or CSV",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			entries, err := readAuditLog()
+			if err != nil {
+				return err
+			}
+
+			out := io.Writer(os.Stdout)
+			if auditOutput != "" && auditOutput != "-" {
+				file, err := os.Create(auditOutput)
+				if err != nil {
+					return fmt.Errorf("failed to create %s: %w", auditOutput, err)
+				}
+				defer file.Close()
+				out = file
+			}
+
+			switch auditFormat {
+			case "jsonl":
+				enc := json.NewEncoder(out)
+				for _, e := range entries {
+					if err := enc.Encode(e); err != nil {
+						return err
+					}
+				}
+			case "json":
+				if entries == nil {
+					entries = []audit.Entry{}
+				}
+				enc := json.NewEncoder(out)
+				enc.SetIndent("", "  ")
+				if err := enc.Encode(entries); err != nil {
+					return err
+				}
+			case "csv":
+				if err := writeAuditCSV(out, entries); err != nil {
+					return err
+				}
+			default:
+				return fmt.Errorf("unknown format %q, expected jsonl, json or csv", auditFormat)
+			}
+
+			if out != os.Stdout {
+				ui.ShowSuccess(fmt.Sprintf("Exported %d entries to %s", len(entries), auditOutput))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&auditFormat, "format", "jsonl", "Output format: jsonl, json or csv")
+	cmd.Flags().StringVarP(&auditOutput, "output", "o", "", "Write to a file instead of stdout")
+
+	return cmd
+}
+
+// readAuditLog reads the configured audit log with the command line filters
+func readAuditLog() ([]audit.Entry, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+
+	path, err := auditLogPath(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if !cfg.Audit.Enabled {
+		ui.ShowInfo("The audit log is disabled, enable it with 'aig config set audit.enabled true'")
+	}
+
+	filter := audit.Filter{Command: auditCommand}
+	if auditSince != "" {
+		filter.Since, err = parseSince(auditSince)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	entries, err := audit.Open(path).Read(filter)
+	if err != nil && entries != nil {
+		// Some lines could not be read; show the rest
+		ui.ShowWarning(err.Error())
+		return entries, nil
+	}
+	return entries, err
+}
+
+// parseSince parses a duration such as 24h or 7d, or a date
+func parseSince(value string) (time.Time, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil {
+			return time.Now().AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid --since %q, expected a duration such as 24h or 7d, or a date such as 2025-01-31", value)
+}
+
+func writeAuditCSV(out io.Writer, entries []audit.Entry) error {
+	w := csv.NewWriter(out)
+	header := []string{"time", "command", "kind", "repo", "commit", "provider", "model", "prompt_hash",
+		"prompt_bytes", "response_bytes", "prompt_tokens", "completion_tokens", "files", "duration_ms", "error"}
+	if err := w.Write(header); err != nil {
+		return err
+	}
+	for _, e := range entries {
+		record := []string{
+			e.Time.Format(time.RFC3339), e.Command, e.Kind, e.Repo, e.Commit, e.Provider, e.Model, e.PromptHash,
+			strconv.Itoa(e.PromptBytes), strconv.Itoa(e.ResponseBytes),
+			strconv.Itoa(e.PromptTokens), strconv.Itoa(e.CompletionTokens),
+			strings.Join(e.Files, ";"), strconv.FormatInt(e.DurationMS, 10), e.Error,
+		}
+		if err := w.Write(record); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// auditLogPath returns the configured audit log path
+func auditLogPath(cfg *config.Config) (string, error) {
+	return dataFilePath(cfg.Audit.Path, audit.FileName)
+}
+
+// auditHook returns a call hook appending every request to the audit log
+func auditHook(cfg *config.Config, command string) (ai.CallHook, error) {
+	path, err := auditLogPath(cfg)
+	if err != nil {
+		return nil, err
+	}
+	log := audit.Open(path)
+	storePrompts := cfg.Audit.StorePrompts
+
+	// Outside a repository, or before the first commit, these stay empty
+	repo, _ := git.GetRepoRoot()
+	head, _ := git.GetHeadCommit()
+
+	return func(ctx context.Context, call *ai.Call) {
+		entry := audit.Entry{
+			Time:             call.Started.UTC(),
+			Command:          command,
+			Kind:             call.Kind,
+			Repo:             repo,
+			Commit:           head,
+			Provider:         call.Provider,
+			Model:            call.Model,
+			PromptHash:       ai.PromptHash(call.Prompt),
+			PromptBytes:      len(call.Prompt),
+			ResponseBytes:    len(call.Response),
+			PromptTokens:     call.Usage.PromptTokens,
+			CompletionTokens: call.Usage.CompletionTokens,
+			TokensEstimated:  call.Usage.Estimated,
+			Files:            promptFiles(call.Prompt),
+			DurationMS:       call.Duration.Milliseconds(),
+		}
+		if call.Err != nil {
+			entry.Error = call.Err.Error()
+		}
+		if storePrompts {
+			entry.Prompt = call.Prompt
+			entry.Response = call.Response
+		}
+
+		if err := log.Append(entry); err != nil {
+			ui.ShowWarning(fmt.Sprintf("Could not write the audit log: %v", err))
+		}
+	}, nil
+}
+
+// promptFiles returns the paths of the file diffs included in a prompt
+func promptFiles(prompt string) []string {
+	var files []string
+	seen := make(map[string]bool)
+	for _, file := range git.ParseDiff(prompt) {
+		if path := file.Path(); !seen[path] {
+			seen[path] = true
+			files = append(files, path)
+		}
+	}
+	return files
+}
+
+func shortHash(hash string) string {
+	if len(hash) > 12 {
+		return hash[:12]
+	}
+	return hash
+}
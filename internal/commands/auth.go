@@ -0,0 +1,197 @@
+package commands
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/x/term"
+	"github.com/spf13/cobra"
+	"github.com/tarantino19/aig/internal/config"
+	"github.com/tarantino19/aig/internal/credentials"
+	"github.com/tarantino19/aig/internal/ui"
+)
+
+// knownProviders are the providers aig auth status reports on
+var knownProviders = []string{"openai", "gemini"}
+
+var (
+	authProvider string
+	authUseFile  bool
+)
+
+// NewAuthCmd creates the auth command
+func NewAuthCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "auth",
+		Short: "Manage AI provider credentials",
+		Long: `Store AI provider API keys in the system keyring (Secret Service on Linux,
+Keychain on macOS) or, where no keyring is available, in an encrypted file.
+
+Keys are resolved in the order: environment variables, keyring, encrypted
+file, config files.`,
+	}
+
+	cmd.AddCommand(newAuthLoginCmd())
+	cmd.AddCommand(newAuthLogoutCmd())
+	cmd.AddCommand(newAuthStatusCmd())
+
+	addProfileFlag(cmd)
+
+	return cmd
+}
+
+func newAuthLoginCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "login",
+		Short: "Store an API key",
+		Long: `Store an API key for a provider. The key is read from the terminal without
+echoing it, or from standard input when it is piped:
+
+  pass show openai | aig auth login --provider openai`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			provider, err := authTargetProvider()
+			if err != nil {
+				return err
+			}
+
+			store, err := authStore()
+			if err != nil {
+				return err
+			}
+
+			key, err := readAPIKey(provider)
+			if err != nil {
+				return err
+			}
+			if key == "" {
+				return fmt.Errorf("no API key entered")
+			}
+
+			if err := store.Set(provider, key); err != nil {
+				return fmt.Errorf("failed to store API key in %s: %w", store.Name(), err)
+			}
+
+			ui.ShowSuccess(fmt.Sprintf("Stored %s API key in %s", provider, store.Name()))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&authProvider, "provider", "", "Provider to store the key for (default: ai.provider)")
+	cmd.Flags().BoolVar(&authUseFile, "file", false, "Use the encrypted file even if a system keyring is available")
+
+	return cmd
+}
+
+func newAuthLogoutCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "logout",
+		Short: "Remove a stored API key",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			provider, err := authTargetProvider()
+			if err != nil {
+				return err
+			}
+
+			// Remove the key from every store so that no stale copy is picked up later
+			for _, store := range credentials.Stores() {
+				if err := store.Delete(provider); err != nil {
+					return fmt.Errorf("failed to remove API key from %s: %w", store.Name(), err)
+				}
+			}
+
+			ui.ShowSuccess(fmt.Sprintf("Removed stored %s API key", provider))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&authProvider, "provider", "", "Provider to remove the key for (default: ai.provider)")
+
+	return cmd
+}
+
+func newAuthStatusCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "status",
+		Short: "Show where each provider's API key comes from",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := config.Load()
+			if err != nil {
+				return fmt.Errorf("failed to load config: %w", err)
+			}
+
+			for _, provider := range knownProviders {
+				active := ""
+				if provider == cfg.AI.Provider {
+					active = " (active)"
+				}
+
+				if provider == cfg.AI.Provider && cfg.AI.APIKey != "" {
+					fmt.Printf("%s%s: %s from %s\n", provider, active, credentials.Mask(cfg.AI.APIKey), config.OriginOf("ai.api_key"))
+					continue
+				}
+
+				key, store, err := credentials.Lookup(provider)
+				switch {
+				case errors.Is(err, credentials.ErrNotFound):
+					fmt.Printf("%s%s: not configured\n", provider, active)
+				case err != nil:
+					fmt.Printf("%s%s: %v\n", provider, active, err)
+				default:
+					fmt.Printf("%s%s: %s from %s\n", provider, active, credentials.Mask(key), store)
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// authTargetProvider returns the provider named by --provider or the configured one
+func authTargetProvider() (string, error) {
+	if authProvider != "" {
+		return authProvider, nil
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return "", fmt.Errorf("failed to load config: %w", err)
+	}
+	return cfg.AI.Provider, nil
+}
+
+// authStore returns the store aig auth login writes to
+func authStore() (credentials.Store, error) {
+	if authUseFile {
+		return credentials.DefaultFile()
+	}
+	return credentials.Default()
+}
+
+// readAPIKey reads an API key from the terminal without echo, or from piped stdin
+func readAPIKey(provider string) (string, error) {
+	if term.IsTerminal(os.Stdin.Fd()) {
+		fmt.Printf("%s API key: ", provider)
+		key, err := term.ReadPassword(os.Stdin.Fd())
+		fmt.Println()
+		if err != nil {
+			return "", fmt.Errorf("failed to read API key: %w", err)
+		}
+		return strings.TrimSpace(string(key)), nil
+	}
+
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("failed to read API key: %w", err)
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// isSecretKey reports whether a config key holds a credential
+func isSecretKey(key string) bool {
+	return strings.HasSuffix(strings.ToLower(key), "api_key")
+}
@@ -0,0 +1,106 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/tarantino19/aig/internal/cache"
+	"github.com/tarantino19/aig/internal/ui"
+)
+
+// NewCacheCmd creates the cache command
+func NewCacheCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cache",
+		Short: "Manage cached AI results",
+		Long:  `Inspect or clear the AI results aig keeps in the user cache directory.`,
+	}
+
+	cmd.AddCommand(newCacheStatsCmd())
+	cmd.AddCommand(newCacheClearCmd())
+
+	return cmd
+}
+
+func newCacheStatsCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "stats",
+		Short: "Show cache size per namespace",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			namespaces, err := cache.Namespaces()
+			if err != nil {
+				return err
+			}
+
+			if len(namespaces) == 0 {
+				ui.ShowInfo("Cache is empty")
+				return nil
+			}
+
+			root, err := cache.RootDir()
+			if err != nil {
+				return err
+			}
+			ui.ShowInfo(fmt.Sprintf("Cache directory: %s", root))
+
+			for _, namespace := range namespaces {
+				store, err := cache.Open(namespace)
+				if err != nil {
+					return err
+				}
+				stats, err := store.Stats()
+				if err != nil {
+					return fmt.Errorf("failed to read cache %s: %w", namespace, err)
+				}
+				fmt.Printf("  %-10s %6d entries  %s\n", namespace, stats.Entries, formatBytes(stats.Bytes))
+			}
+			return nil
+		},
+	}
+}
+
+func newCacheClearCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "clear [namespace]",
+		Short: "Remove cached results",
+		Long:  `Removes all cached results, or only those of the given namespace (for example "review").`,
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			namespaces := args
+			if len(namespaces) == 0 {
+				var err error
+				namespaces, err = cache.Namespaces()
+				if err != nil {
+					return err
+				}
+			}
+
+			for _, namespace := range namespaces {
+				store, err := cache.Open(namespace)
+				if err != nil {
+					return err
+				}
+				if err := store.Clear(); err != nil {
+					return err
+				}
+			}
+
+			ui.ShowSuccess("Cache cleared")
+			return nil
+		},
+	}
+}
+
+// formatBytes renders a byte count in a human readable unit
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,154 @@
+package commands
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/x/term"
+	"github.com/tarantino19/aig/internal/ai"
+	"github.com/tarantino19/aig/internal/ui"
+)
+
+// chooseCommitMessage generates n candidate messages and lets the user pick,
+// edit or regenerate them. Rejected headers are passed back to the provider so
+// later rounds do not repeat them. A nil message means the user cancelled.
+func chooseCommitMessage(ctx context.Context, provider ai.Provider, diff string, options ai.CommitOptions, n int, prepare func(*ai.CommitMessage)) (*ai.CommitMessage, error) {
+	generate := func(options ai.CommitOptions) ([]*ai.CommitMessage, error) {
+		candidates, err := ai.GenerateCommitCandidates(ctx, provider, diff, options, n)
+		if errors.Is(err, ai.ErrContextTooLong) {
+			diff = trimForContext(diff)
+			candidates, err = ai.GenerateCommitCandidates(ctx, provider, diff, options, n)
+		}
+		for _, c := range candidates {
+			prepare(c)
+		}
+		return candidates, err
+	}
+
+	candidates, err := generate(options)
+	if err != nil {
+		return nil, err
+	}
+
+	// Without a terminal to drive the picker, fall back to a numbered list
+	if !interactive {
+		ui.ListCommitMessages(candidates)
+		return candidates[0], nil
+	}
+	if !term.IsTerminal(os.Stdin.Fd()) {
+		return readCandidateChoice(candidates)
+	}
+
+	for {
+		result, err := ui.PickCommitMessage(candidates)
+		if err != nil {
+			return nil, err
+		}
+		chosen := candidates[result.Index]
+
+		switch result.Action {
+		case ui.PickAccept:
+			return chosen, nil
+		case ui.PickEdit:
+			return editCommitMessage(chosen, options.Conventional)
+		case ui.PickCancel:
+			return nil, nil
+		case ui.PickMore:
+			for i, c := range candidates {
+				if i != result.Index {
+					options.Avoid = append(options.Avoid, c.Header())
+				}
+			}
+			options.Like = chosen.FullMessage
+		case ui.PickRegenerate:
+			for _, c := range candidates {
+				options.Avoid = append(options.Avoid, c.Header())
+			}
+			options.Like = ""
+		}
+
+		ui.ShowInfo("🤖 Generating new candidates...")
+		more, err := generate(options)
+		if err != nil {
+			ui.ShowWarning(fmt.Sprintf("Could not generate new candidates: %v", err))
+			continue
+		}
+		if result.Action == ui.PickMore {
+			// Keep the message the user liked at the top for comparison
+			kept := []*ai.CommitMessage{chosen}
+			for _, c := range more {
+				if !strings.EqualFold(c.Header(), chosen.Header()) {
+					kept = append(kept, c)
+				}
+			}
+			more = kept
+		}
+		candidates = more
+	}
+}
+
+// readCandidateChoice lists the candidates and reads a number from stdin
+func readCandidateChoice(candidates []*ai.CommitMessage) (*ai.CommitMessage, error) {
+	ui.ListCommitMessages(candidates)
+	fmt.Printf("\nChoose a message [1-%d, q to cancel] (1): ", len(candidates))
+
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return candidates[0], nil
+	}
+	line = strings.TrimSpace(line)
+	switch {
+	case line == "":
+		return candidates[0], nil
+	case line == "q":
+		return nil, nil
+	}
+
+	choice, err := strconv.Atoi(line)
+	if err != nil || choice < 1 || choice > len(candidates) {
+		return nil, fmt.Errorf("invalid choice %q", line)
+	}
+	return candidates[choice-1], nil
+}
+
+// editCommitMessage opens the message in the user's editor
+func editCommitMessage(msg *ai.CommitMessage, conventional bool) (*ai.CommitMessage, error) {
+	f, err := os.CreateTemp("", "aig-commit-*.txt")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(f.Name())
+
+	content := msg.FullMessage + "\n\n# Lines starting with '#' are ignored. An empty message cancels the commit.\n"
+	if _, err := f.WriteString(content); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to write temp file: %w", err)
+	}
+	f.Close()
+
+	if err := runEditor(f.Name()); err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(f.Name())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read edited message: %w", err)
+	}
+
+	var lines []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if !strings.HasPrefix(line, "#") {
+			lines = append(lines, line)
+		}
+	}
+	text := strings.TrimSpace(strings.Join(lines, "\n"))
+	if text == "" {
+		return nil, nil
+	}
+	return ai.ParseCommitMessage(text, conventional), nil
+}
@@ -1,11 +1,10 @@
 package commands
 
 import (
-	"context"
+	"errors"
 	"fmt"
 	"path/filepath"
 	"strings"
-	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/tarantino19/aig/internal/ai"
@@ -21,16 +20,26 @@ var (
 	conventional    bool
 	push           bool
 	dryRun         bool
+	learnStyle     bool
+	commitCandidates int
+	commitSplit      bool
+	commitAmend      bool
+	commitFixup      string
 )
 
 // NewCommitCmd creates the commit command
 func NewCommitCmd() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:     "commit",
+		Use:     "commit [--fixup [commit]]",
 		Aliases: []string{"c"},
 		Short:   "Generate AI-powered commit message for staged changes",
 		Long: `Analyzes your staged changes and generates an intelligent commit message
-following best practices and conventional commit format.`,
+following best practices and conventional commit format.
+
+With --amend the message of HEAD is regenerated from HEAD's changes plus the
+staged ones; with nothing staged this rewords HEAD. With --fixup the staged
+changes become a fixup! commit for the commit whose lines they touch, found
+with git blame, or for the commit given.`,
 		RunE: runCommit,
 	}
 
@@ -40,19 +49,54 @@ following best practices and conventional commit format.`,
 	cmd.Flags().BoolVarP(&conventional, "conventional", "c", true, "Force conventional commit format")
 	cmd.Flags().BoolVarP(&push, "push", "p", false, "Auto-push after commit")
 	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show what would be committed")
+	cmd.Flags().BoolVar(&learnStyle, "learn-style", false, "Imitate the style of recent commits (commit.learn_style)")
+	cmd.Flags().IntVarP(&commitCandidates, "candidates", "n", 1, "Number of candidate messages to choose from")
+	cmd.Flags().BoolVar(&commitSplit, "split", false, "Split the staged changes into several atomic commits")
+	cmd.Flags().BoolVar(&commitAmend, "amend", false, "Regenerate the message of HEAD and add the staged changes to it")
+	cmd.Flags().StringVar(&commitFixup, "fixup", "", "Create a fixup! commit for the given commit, or the one found by blame")
+	cmd.Flags().Lookup("fixup").NoOptDefVal = "auto"
+
+	addCacheFlag(cmd)
+	addProfileFlag(cmd)
+	addAIFlags(cmd, "commit")
+	addAllowSecretsFlag(cmd)
 
 	return cmd
 }
 
 func runCommit(cmd *cobra.Command, args []string) error {
+	if cmd.Flags().Changed("learn-style") {
+		config.SetOverride("commit.learn_style", learnStyle, "--learn-style")
+	}
+
+	if commitCandidates < 1 || commitCandidates > ai.MaxCandidates {
+		return fmt.Errorf("--candidates must be between 1 and %d", ai.MaxCandidates)
+	}
+	if commitSplit && commitCandidates > 1 {
+		return fmt.Errorf("--split and --candidates cannot be used together")
+	}
+	fixup := cmd.Flags().Changed("fixup")
+	if commitAmend && (commitSplit || fixup) || commitSplit && fixup {
+		return fmt.Errorf("only one of --amend, --fixup and --split can be used")
+	}
+
 	// Load configuration
 	cfg, err := config.Load()
 	if err != nil {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
 
+	// A fixup! commit takes its message from the target, no provider is needed
+	if fixup {
+		target := commitFixup
+		if target == "auto" && len(args) == 1 {
+			target = args[0]
+		}
+		return runCommitFixup(cfg, target)
+	}
+
 	// Check if API key is configured
-	if cfg.AI.APIKey == "" || cfg.AI.APIKey == "your-gemini-api-key-here" || cfg.AI.APIKey == "your-openai-api-key-here" {
+	if cfg.AI.NeedsAPIKey() && (cfg.AI.APIKey == "" || cfg.AI.APIKey == "your-gemini-api-key-here" || cfg.AI.APIKey == "your-openai-api-key-here") {
 		ui.ShowError(fmt.Errorf("%s API key not configured", strings.Title(cfg.AI.Provider)))
 		ui.ShowInfo("Please set your API key in one of these ways:")
 		
@@ -61,13 +105,13 @@ func runCommit(cmd *cobra.Command, args []string) error {
 			ui.ShowInfo("1. Set environment variable: export AIG_OPENAI_API_KEY=your-key")
 			ui.ShowInfo("2. Set environment variable: export OPENAI_API_KEY=your-key")
 			ui.ShowInfo("3. Edit .env file and add: AIG_OPENAI_API_KEY=your-key")
-			ui.ShowInfo("4. Use: aig config set ai.api_key your-key")
+			ui.ShowInfo("4. Use: aig auth login")
 			ui.ShowInfo("\nGet your API key from: https://platform.openai.com/api-keys")
 		case "gemini":
 			ui.ShowInfo("1. Set environment variable: export AIG_GEMINI_API_KEY=your-key")
 			ui.ShowInfo("2. Set environment variable: export GEMINI_API_KEY=your-key")
 			ui.ShowInfo("3. Edit .env file and add: AIG_GEMINI_API_KEY=your-key")
-			ui.ShowInfo("4. Use: aig config set ai.api_key your-key")
+			ui.ShowInfo("4. Use: aig auth login")
 			ui.ShowInfo("\nGet your API key from: https://makersuite.google.com/app/apikey")
 		}
 		return nil
@@ -79,20 +123,40 @@ func runCommit(cmd *cobra.Command, args []string) error {
 		ui.ShowWarning("Could not get current branch name, proceeding without it.")
 	}
 
-	// Extract commit details from branch name
-	extractedType, ticketNumber := git.ExtractCommitDetails(branchName)
-	if extractedType != "" {
-		commitType = extractedType
+	// The branch name suggests a type; an explicit --type always wins
+	branchRules, err := git.ParseBranchRules(cfg.Commit.BranchTypes)
+	if err != nil {
+		return fmt.Errorf("invalid commit.branch_types: %w", err)
+	}
+	typeHint := git.MatchBranchType(branchName, branchRules)
+
+	matcher, err := ticketMatcher(cfg)
+	if err != nil {
+		return err
 	}
+	ticketKey := matcher.Find(branchName)
 
-	// Get staged changes
-	diff, err := git.GetStagedDiff()
+	// Get staged changes, or everything HEAD will hold when amending
+	var diff, previousMessage string
+	if commitAmend {
+		previousMessage, err = git.GetCommitMessage("HEAD")
+		if err != nil {
+			return fmt.Errorf("nothing to amend: %w", err)
+		}
+		diff, err = git.GetAmendDiff()
+	} else {
+		diff, err = git.GetStagedDiff()
+	}
 	if err != nil {
 		return fmt.Errorf("failed to get staged changes: %w", err)
 	}
 
 	if diff == "" {
-		ui.ShowWarning("No staged changes found. Stage your changes with 'git add' first")
+		if commitAmend {
+			ui.ShowWarning("HEAD and the staged changes are empty, there is nothing to describe")
+		} else {
+			ui.ShowWarning("No staged changes found. Stage your changes with 'git add' first")
+		}
 		return nil
 	}
 
@@ -102,42 +166,120 @@ func runCommit(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
+	// Never commit credentials, and never send them to the provider either
+	if err := checkStagedSecrets(cfg, diff); err != nil {
+		return err
+	}
+
+	// Files listed in .aigignore never leave the machine
+	aiDiff, err := filterIgnoredPaths(diff)
+	if err != nil {
+		return fmt.Errorf("failed to apply ignore file: %w", err)
+	}
+
+	// Learn the house style from history; configured scopes take precedence
+	scopes := cfg.Commit.Scopes
+	var examples []string
+	if cfg.Commit.LearnStyle {
+		style, err := git.LearnCommitStyle(cfg.Commit.StyleSample, cfg.Commit.StyleExamples)
+		if err != nil {
+			ui.ShowWarning(fmt.Sprintf("Could not read commit history, proceeding without style examples: %v", err))
+		} else {
+			examples = style.Examples
+			if len(scopes) == 0 {
+				scopes = style.Scopes
+			}
+		}
+	}
+
 	// Create AI provider using the factory
-	provider, err := ai.NewProvider(ai.ProviderConfig{
-		Provider:    cfg.AI.Provider,
-		APIKey:      cfg.AI.APIKey,
-		Model:       cfg.AI.Model,
-		Temperature: cfg.AI.Temperature,
-		MaxTokens:   cfg.AI.MaxTokens,
-	})
+	provider, err := newAIProvider(cfg, "commit")
 	if err != nil {
-		return fmt.Errorf("failed to create AI provider: %w", err)
+		return err
 	}
 	defer provider.Close()
 
 	// Generate commit message using AI
 	ui.ShowInfo(fmt.Sprintf("🤖 Analyzing staged changes with %s...", strings.Title(cfg.AI.Provider)))
 	
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := aiContext(cmd.Context(), cfg)
 	defer cancel()
 
-	commitMsg, err := provider.GenerateCommitMessage(ctx, diff, ai.CommitOptions{
-		Type:         commitType,
-		Scope:        commitScope,
-		Conventional: conventional,
-	})
+	if commitSplit {
+		if aiDiff == "" {
+			return fmt.Errorf("every staged file is listed in .aigignore, nothing can be sent to plan a split")
+		}
+		return runCommitSplit(ctx, cfg, provider, aiDiff, ai.CommitOptions{
+			Type:         commitType,
+			TypeHint:     typeHint,
+			Scope:        commitScope,
+			Conventional: conventional,
+			Types:        cfg.Commit.Types,
+			Scopes:       scopes,
+			Examples:     examples,
+		}, ticketKey)
+	}
+
+	var commitMsg *ai.CommitMessage
+	picked := false
+	if aiDiff == "" {
+		ui.ShowWarning("All staged files are listed in .aigignore, nothing was sent to the AI provider")
+		commitMsg = generateFallbackCommitMessage(diff, ai.CommitOptions{
+			Type:         commitType,
+			TypeHint:     typeHint,
+			Scope:        commitScope,
+			Conventional: conventional,
+			Types:        cfg.Commit.Types,
+			Scopes:       scopes,
+		})
+	} else {
+		commitOptions := ai.CommitOptions{
+			Type:         commitType,
+			TypeHint:     typeHint,
+			Scope:        commitScope,
+			Conventional: conventional,
+			Types:        cfg.Commit.Types,
+			Scopes:       scopes,
+			Examples:     examples,
+		}
+		if commitCandidates > 1 {
+			commitMsg, err = chooseCommitMessage(ctx, provider, aiDiff, commitOptions, commitCandidates, func(msg *ai.CommitMessage) {
+				placeTicket(msg, ticketKey, cfg.Commit)
+			})
+			if err == nil && commitMsg == nil {
+				ui.ShowInfo("Commit cancelled")
+				return nil
+			}
+			picked = err == nil && interactive
+		} else {
+			commitMsg, err = provider.GenerateCommitMessage(ctx, aiDiff, commitOptions)
+			if errors.Is(err, ai.ErrContextTooLong) {
+				commitMsg, err = provider.GenerateCommitMessage(ctx, trimForContext(aiDiff), commitOptions)
+			}
+		}
+	}
+	if errors.Is(err, ai.ErrNothingToSend) {
+		ui.ShowWarning(err.Error())
+		commitMsg, err = generateFallbackCommitMessage(diff, ai.CommitOptions{
+			Type:         commitType,
+			TypeHint:     typeHint,
+			Scope:        commitScope,
+			Conventional: conventional,
+		}), nil
+	}
 	if err != nil {
-		// Check if it's specifically a rate limit or quota error
-		errorStr := strings.ToLower(err.Error())
-		if strings.Contains(errorStr, "rate limit exceeded") || 
-		   strings.Contains(errorStr, "quota exceeded") ||
-		   strings.Contains(errorStr, "insufficient_quota") ||
-		   strings.Contains(errorStr, "429") {
-			ui.ShowWarning("⚠️  API quota exceeded. Falling back to manual mode...")
+		// Failures that a hand-written message gets around
+		if errors.Is(err, ai.ErrRateLimited) ||
+		   errors.Is(err, ai.ErrQuotaExhausted) ||
+		   errors.Is(err, ai.ErrTimeout) ||
+		   errors.Is(err, ai.ErrContentBlocked) {
+			ui.ShowWarning(fmt.Sprintf("⚠️  %v. Falling back to manual mode...", err))
+			showProviderRemedy(err, cfg)
 			
 			// Provide a fallback manual commit message
 			fallbackMsg := generateFallbackCommitMessage(diff, ai.CommitOptions{
 				Type:         commitType,
+				TypeHint:     typeHint,
 				Scope:        commitScope,
 				Conventional: conventional,
 			})
@@ -163,12 +305,18 @@ func runCommit(cmd *cobra.Command, args []string) error {
 			
 			commitMsg = fallbackMsg
 		} else {
+			showProviderRemedy(err, cfg)
 			return fmt.Errorf("failed to generate commit message: %w", err)
 		}
 	}
 
-	if ticketNumber != "" {
-		commitMsg.Subject = fmt.Sprintf("%s-%s", ticketNumber, commitMsg.Subject)
+	placeTicket(commitMsg, ticketKey, cfg.Commit)
+
+	if commitAmend {
+		fmt.Printf("\nReplacing the message of HEAD:\n%s\n", previousMessage)
+		if pushed, err := git.IsPushed("HEAD"); err == nil && pushed {
+			ui.ShowWarning("HEAD is already on a remote, amending it rewrites published history and needs a force push")
+		}
 	}
 
 	// Display the generated commit message
@@ -182,8 +330,8 @@ func runCommit(cmd *cobra.Command, args []string) error {
 		fmt.Printf("\nFooter:\n%s\n", commitMsg.Footer)
 	}
 
-	// In interactive mode, ask for confirmation
-	if interactive {
+	// In interactive mode, ask for confirmation unless the message was just picked
+	if interactive && !picked {
 		fmt.Print("\nUse this commit message? [Y/n]: ")
 		var response string
 		fmt.Scanln(&response)
@@ -195,12 +343,20 @@ func runCommit(cmd *cobra.Command, args []string) error {
 	}
 
 	// Create the commit
-	if err := git.CreateCommit(commitMsg.FullMessage); err != nil {
-		return fmt.Errorf("failed to commit: %w", err)
+	if commitAmend {
+		// Sign-offs and other trailers of the old message survive the rewrite
+		message := git.AppendTrailers(commitMsg.FullMessage, git.ParseTrailers(previousMessage))
+		if err := git.AmendCommit(message); err != nil {
+			return fmt.Errorf("failed to amend commit: %w", err)
+		}
+		ui.ShowSuccess("Commit amended successfully!")
+	} else {
+		if err := git.CreateCommit(commitMsg.FullMessage); err != nil {
+			return fmt.Errorf("failed to commit: %w", err)
+		}
+		ui.ShowSuccess("Commit created successfully!")
 	}
 
-	ui.ShowSuccess("Commit created successfully!")
-
 	// Auto-push if requested
 	if push {
 		ui.ShowInfo("Pushing to remote...")
@@ -249,6 +405,8 @@ func generateFallbackCommitMessage(diff string, options ai.CommitOptions) *ai.Co
 	
 	if options.Type != "" {
 		commitType = options.Type
+	} else if options.TypeHint != "" {
+		commitType = options.TypeHint
 	} else {
 		// Infer type from changes
 		if len(addedFiles) > 0 {
@@ -1,12 +1,17 @@
 package commands
 
 import (
+	"bytes"
 	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"github.com/tarantino19/aig/internal/config"
+	"github.com/tarantino19/aig/internal/credentials"
 	"github.com/tarantino19/aig/internal/ui"
 )
 
@@ -19,62 +24,244 @@ func NewConfigCmd() *cobra.Command {
 	}
 
 	cmd.AddCommand(newConfigSetCmd())
+	cmd.AddCommand(newConfigUnsetCmd())
+	cmd.AddCommand(newConfigEditCmd())
+	cmd.AddCommand(newConfigValidateCmd())
 	cmd.AddCommand(newConfigGetCmd())
 	cmd.AddCommand(newConfigListCmd())
 	cmd.AddCommand(newConfigPathCmd())
+	cmd.AddCommand(newConfigProfileCmd())
+
+	addProfileFlag(cmd)
 
 	return cmd
 }
 
+var (
+	configRepo       bool
+	configPrivate    bool
+	configShowOrigin bool
+)
+
+// addConfigFileFlags registers the flags selecting the config file a command writes to
+func addConfigFileFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&configRepo, "repo", false, "Use the repository's shared "+config.RepoConfigFile)
+	cmd.Flags().BoolVar(&configPrivate, "private", false, "Use the repository's private $GIT_DIR/"+config.PrivateConfigFile)
+}
+
 func newConfigSetCmd() *cobra.Command {
-	return &cobra.Command{
+	cmd := &cobra.Command{
 		Use:   "set <key> <value>",
 		Short: "Set a configuration value",
-		Args:  cobra.ExactArgs(2),
+		Long: `Set a configuration value in the global config file.
+
+The value is converted to the key's type and validated. Lists are written
+comma-separated or as [a, b].
+
+Use --repo to write it to .aig.yaml at the repository root, which is shared
+with everyone working on the repository, or --private to write it to
+$GIT_DIR/aig.yaml, which is never committed.`,
+		Args: cobra.ExactArgs(2),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			key := args[0]
-			value := args[1]
-
-			// Set the value
-			viper.Set(key, value)
-
-			// Write config
-			if err := viper.WriteConfig(); err != nil {
-				// If config file doesn't exist, create it
-				if _, ok := err.(viper.ConfigFileNotFoundError); ok {
-					configPath := viper.ConfigFileUsed()
-					if configPath == "" {
-						homeDir, _ := os.UserHomeDir()
-						configPath = filepath.Join(homeDir, ".config", "aig", "config.yaml")
-					}
-					
-					// Create directory if needed
-					configDir := filepath.Dir(configPath)
-					if err := os.MkdirAll(configDir, 0755); err != nil {
-						return fmt.Errorf("failed to create config directory: %w", err)
-					}
-					
-					// Write new config file
-					if err := viper.WriteConfigAs(configPath); err != nil {
-						return fmt.Errorf("failed to write config: %w", err)
-					}
-				} else {
-					return fmt.Errorf("failed to write config: %w", err)
+			key := strings.ToLower(args[0])
+			raw := args[1]
+
+			if isSecretKey(key) {
+				return storeAPIKey(key, raw)
+			}
+
+			value, err := config.ParseValue(key, raw)
+			if err != nil {
+				return err
+			}
+			if err := checkModel(key, value); err != nil {
+				return err
+			}
+
+			configPath, err := configTargetPath(configRepo, configPrivate)
+			if err != nil {
+				return err
+			}
+
+			if err := config.SetFileValue(configPath, key, value); err != nil {
+				return fmt.Errorf("failed to write config: %w", err)
+			}
+
+			ui.ShowSuccess(fmt.Sprintf("Set %s = %v in %s", key, value, configPath))
+			return nil
+		},
+	}
+
+	addConfigFileFlags(cmd)
+
+	return cmd
+}
+
+// checkModel rejects a model that does not belong to the configured provider and
+// warns when a provider change leaves an incompatible model behind
+func checkModel(key string, value interface{}) error {
+	section := strings.TrimSuffix(strings.TrimSuffix(key, "provider"), "model")
+	if !strings.HasSuffix(section, "ai.") {
+		return nil
+	}
+
+	// The check needs the current values; a config that fails to load is still editable
+	if _, err := config.Load(); err != nil {
+		return nil
+	}
+
+	provider := viper.GetString(section + "provider")
+	model := viper.GetString(section + "model")
+	if strings.HasPrefix(section, "profiles.") && provider == "" {
+		provider = viper.GetString("ai.provider")
+	}
+
+	if strings.HasSuffix(key, ".model") {
+		return config.ValidateModel(provider, value.(string))
+	}
+	if err := config.ValidateModel(value.(string), model); err != nil {
+		ui.ShowWarning(fmt.Sprintf("%s: %v, update %smodel as well", key, err, section))
+	}
+	return nil
+}
+
+func newConfigUnsetCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "unset <key>",
+		Short: "Remove a configuration value",
+		Long: `Remove a value from the global config file so that the next source in the
+precedence order applies. Use --repo or --private to remove it from a
+repository config file.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			key := strings.ToLower(args[0])
+
+			configPath, err := configTargetPath(configRepo, configPrivate)
+			if err != nil {
+				return err
+			}
+
+			deleted, err := config.DeleteFileKey(configPath, key)
+			if err != nil {
+				return fmt.Errorf("failed to write config: %w", err)
+			}
+			if !deleted {
+				if err := config.CheckKey(key); err != nil {
+					return err
+				}
+				ui.ShowInfo(fmt.Sprintf("%s is not set in %s", key, configPath))
+				return nil
+			}
+
+			ui.ShowSuccess(fmt.Sprintf("Removed %s from %s", key, configPath))
+			return nil
+		},
+	}
+
+	addConfigFileFlags(cmd)
+
+	return cmd
+}
+
+func newConfigEditCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "edit",
+		Short: "Edit the config file in $EDITOR",
+		Long: `Open the global config file in $VISUAL or $EDITOR and validate it when the
+editor exits. Invalid changes can be re-edited or discarded. Use --repo or
+--private to edit a repository config file.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			configPath, err := configTargetPath(configRepo, configPrivate)
+			if err != nil {
+				return err
+			}
+			return editConfigFile(configPath)
+		},
+	}
+
+	addConfigFileFlags(cmd)
+
+	return cmd
+}
+
+func newConfigValidateCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "validate",
+		Short: "Check every config file for unknown keys and invalid values",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			globalPath, err := config.GlobalConfigPath()
+			if err != nil {
+				return fmt.Errorf("failed to get config directory: %w", err)
+			}
+
+			problems := 0
+			for _, path := range []string{globalPath, config.RepoConfigPath(), config.PrivateConfigPath()} {
+				if path == "" {
+					continue
+				}
+				errs, err := config.ValidateFile(path)
+				if err != nil {
+					return err
 				}
+				for _, e := range errs {
+					fmt.Printf("%s: %v\n", path, e)
+				}
+				problems += len(errs)
+			}
+
+			cfg, err := config.Load()
+			if err != nil {
+				return err
+			}
+			for _, e := range config.ValidateConfig(cfg) {
+				fmt.Printf("merged config: %v\n", e)
+				problems++
 			}
 
-			ui.ShowSuccess(fmt.Sprintf("Set %s = %s", key, value))
+			if problems > 0 {
+				return fmt.Errorf("found %d problem(s) in the configuration", problems)
+			}
+
+			ui.ShowSuccess("Configuration is valid")
 			return nil
 		},
 	}
 }
 
+// configTargetPath returns the config file selected by the --repo and --private flags
+func configTargetPath(repo, private bool) (string, error) {
+	switch {
+	case repo && private:
+		return "", fmt.Errorf("--repo and --private cannot be used together")
+	case repo:
+		path := config.RepoConfigPath()
+		if path == "" {
+			return "", fmt.Errorf("--repo requires a git repository")
+		}
+		return path, nil
+	case private:
+		path := config.PrivateConfigPath()
+		if path == "" {
+			return "", fmt.Errorf("--private requires a git repository")
+		}
+		return path, nil
+	default:
+		return config.GlobalConfigPath()
+	}
+}
+
 func newConfigGetCmd() *cobra.Command {
 	return &cobra.Command{
 		Use:   "get <key>",
 		Short: "Get a configuration value",
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if _, err := config.Load(); err != nil {
+				return fmt.Errorf("failed to load config: %w", err)
+			}
+
 			key := args[0]
 			value := viper.Get(key)
 
@@ -82,62 +269,184 @@ func newConfigGetCmd() *cobra.Command {
 				return fmt.Errorf("configuration key '%s' not found", key)
 			}
 
-			fmt.Printf("%s = %v\n", key, value)
+			fmt.Printf("%s = %v\n", key, displayValue(key, value))
 			return nil
 		},
 	}
 }
 
 func newConfigListCmd() *cobra.Command {
-	return &cobra.Command{
+	cmd := &cobra.Command{
 		Use:   "list",
 		Short: "List all configuration values",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			settings := viper.AllSettings()
+			if _, err := config.Load(); err != nil {
+				return fmt.Errorf("failed to load config: %w", err)
+			}
 
-			if len(settings) == 0 {
+			keys := config.Keys()
+			if len(keys) == 0 {
 				ui.ShowInfo("No configuration values set")
 				return nil
 			}
 
 			ui.ShowInfo("Current configuration:")
-			printSettings(settings, "")
+			for _, key := range keys {
+				value := displayValue(key, viper.Get(key))
+				if configShowOrigin {
+					fmt.Printf("  %s = %v\t[%s]\n", key, value, config.OriginOf(key))
+				} else {
+					fmt.Printf("  %s = %v\n", key, value)
+				}
+			}
 			return nil
 		},
 	}
+
+	cmd.Flags().BoolVar(&configShowOrigin, "show-origin", false, "Show where each value comes from")
+
+	return cmd
 }
 
 func newConfigPathCmd() *cobra.Command {
 	return &cobra.Command{
 		Use:   "path",
-		Short: "Show configuration file path",
+		Short: "Show configuration file paths",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			configFile := viper.ConfigFileUsed()
-			if configFile == "" {
-				homeDir, _ := os.UserHomeDir()
-				configFile = filepath.Join(homeDir, ".config", "aig", "config.yaml")
-				ui.ShowInfo(fmt.Sprintf("Default config path: %s", configFile))
-			} else {
-				ui.ShowInfo(fmt.Sprintf("Config file: %s", configFile))
+			globalPath, err := config.GlobalConfigPath()
+			if err != nil {
+				return fmt.Errorf("failed to get config directory: %w", err)
+			}
+			ui.ShowInfo(fmt.Sprintf("Global config: %s", globalPath))
+
+			if repoPath := config.RepoConfigPath(); repoPath != "" {
+				ui.ShowInfo(fmt.Sprintf("Repository config: %s", repoPath))
+			}
+			if privatePath := config.PrivateConfigPath(); privatePath != "" {
+				ui.ShowInfo(fmt.Sprintf("Private repository config: %s", privatePath))
 			}
 			return nil
 		},
 	}
 }
 
-// Helper function to recursively print settings
-func printSettings(settings map[string]interface{}, prefix string) {
-	for key, value := range settings {
-		fullKey := key
-		if prefix != "" {
-			fullKey = prefix + "." + key
+// displayValue masks credentials so that they are never printed in full
+func displayValue(key string, value interface{}) interface{} {
+	if !isSecretKey(key) {
+		return value
+	}
+	if secret, ok := value.(string); ok {
+		return credentials.Mask(secret)
+	}
+	return value
+}
+
+// storeAPIKey keeps 'config set ai.api_key' working while storing the key in the
+// credential store instead of a plaintext config file
+func storeAPIKey(key, value string) error {
+	if strings.ToLower(key) != "ai.api_key" {
+		return fmt.Errorf("API keys are not stored in config files, use 'aig auth login --provider <provider>'")
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	store, err := credentials.Default()
+	if err != nil {
+		return err
+	}
+	if err := store.Set(cfg.AI.Provider, value); err != nil {
+		return fmt.Errorf("failed to store API key in %s: %w", store.Name(), err)
+	}
+
+	ui.ShowSuccess(fmt.Sprintf("Stored %s API key in %s", cfg.AI.Provider, store.Name()))
+	return nil
+}
+
+// editConfigFile opens a copy of a config file in the user's editor and only
+// replaces the file once the edited copy passes validation
+func editConfigFile(configPath string) error {
+	original, err := os.ReadFile(configPath)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read %s: %w", configPath, err)
+	}
+
+	tmp, err := os.CreateTemp("", "aig-config-*.yaml")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(original); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write temporary file: %w", err)
+	}
+	tmp.Close()
+
+	for {
+		if err := runEditor(tmp.Name()); err != nil {
+			return err
+		}
+
+		problems, err := config.ValidateFile(tmp.Name())
+		if err != nil {
+			problems = append(problems, err)
+		}
+		if len(problems) == 0 {
+			break
 		}
 
-		switch v := value.(type) {
-		case map[string]interface{}:
-			printSettings(v, fullKey)
-		default:
-			fmt.Printf("  %s = %v\n", fullKey, value)
+		for _, problem := range problems {
+			ui.ShowError(problem)
 		}
+		fmt.Print("\nEdit again? [Y/n]: ")
+		var response string
+		fmt.Scanln(&response)
+		if response != "" && response != "y" && response != "Y" {
+			ui.ShowWarning("Changes discarded")
+			return nil
+		}
+	}
+
+	edited, err := os.ReadFile(tmp.Name())
+	if err != nil {
+		return fmt.Errorf("failed to read edited config: %w", err)
+	}
+	if bytes.Equal(edited, original) {
+		ui.ShowInfo("No changes made")
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	if err := os.WriteFile(configPath, edited, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", configPath, err)
 	}
-} 
\ No newline at end of file
+
+	ui.ShowSuccess(fmt.Sprintf("Saved %s", configPath))
+	return nil
+}
+
+// runEditor opens path in $VISUAL or $EDITOR, falling back to vi
+func runEditor(path string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+
+	// The editor setting may carry arguments, such as "code --wait"
+	parts := strings.Fields(editor)
+	cmd := exec.Command(parts[0], append(parts[1:], path)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("editor %s failed: %w", editor, err)
+	}
+	return nil
+}
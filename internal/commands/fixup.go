@@ -0,0 +1,124 @@
+package commands
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/tarantino19/aig/internal/config"
+	"github.com/tarantino19/aig/internal/git"
+	"github.com/tarantino19/aig/internal/ui"
+)
+
+// maxFixupChoices is how many blamed commits are offered when the target is chosen automatically
+const maxFixupChoices = 3
+
+// runCommitFixup commits the staged changes as a fixup! commit. With target
+// "auto" the commit is picked by blaming the lines the staged changes touch;
+// commits already on a remote are never picked.
+func runCommitFixup(cfg *config.Config, target string) error {
+	diff, err := git.GetStagedDiff()
+	if err != nil {
+		return fmt.Errorf("failed to get staged changes: %w", err)
+	}
+	if diff == "" {
+		ui.ShowWarning("No staged changes found. Stage your changes with 'git add' first")
+		return nil
+	}
+	if err := checkStagedSecrets(cfg, diff); err != nil {
+		return err
+	}
+
+	var hash string
+	if target == "auto" {
+		hash, err = chooseFixupTarget(diff)
+		if err != nil || hash == "" {
+			return err
+		}
+	} else {
+		hash, err = git.ResolveCommit(target)
+		if err != nil {
+			return err
+		}
+		if pushed, err := git.IsPushed(hash); err == nil && pushed {
+			ui.ShowWarning(fmt.Sprintf("%s is already on a remote, folding the fixup in will rewrite published history", shortHash(hash)))
+		}
+	}
+
+	message, err := git.GetCommitMessage(hash)
+	if err != nil {
+		return err
+	}
+
+	// Keep signing off in repositories whose commits carry a sign-off
+	signoff := git.HasTrailer(message, "Signed-off-by")
+	if err := git.CreateFixupCommit(hash, signoff); err != nil {
+		return fmt.Errorf("failed to commit: %w", err)
+	}
+
+	ui.ShowSuccess(fmt.Sprintf("Created fixup! commit for %s %s", shortHash(hash), strings.SplitN(message, "\n", 2)[0]))
+	ui.ShowInfo(fmt.Sprintf("Fold it in with: git rebase -i --autosquash %s^", shortHash(hash)))
+	return nil
+}
+
+// chooseFixupTarget ranks the commits that last touched the staged lines and
+// lets the user confirm one. An empty hash means the user cancelled.
+func chooseFixupTarget(diff string) (string, error) {
+	candidates, err := git.FindFixupTargets(diff)
+	if err != nil {
+		return "", fmt.Errorf("failed to find fixup target: %w", err)
+	}
+
+	var unpushed []git.FixupCandidate
+	skipped := 0
+	for _, c := range candidates {
+		pushed, err := git.IsPushed(c.Hash)
+		if err != nil {
+			return "", err
+		}
+		if pushed {
+			skipped++
+			continue
+		}
+		unpushed = append(unpushed, c)
+	}
+
+	if len(unpushed) == 0 {
+		if skipped > 0 {
+			return "", fmt.Errorf("the staged lines were last changed by %d commit(s) already on a remote; name the commit with --fixup <commit> to fix it up anyway", skipped)
+		}
+		return "", fmt.Errorf("could not find a commit the staged lines belong to (new files have no history); name one with --fixup <commit>")
+	}
+	if skipped > 0 {
+		ui.ShowInfo(fmt.Sprintf("Skipping %d commit(s) that are already on a remote", skipped))
+	}
+	if len(unpushed) > maxFixupChoices {
+		unpushed = unpushed[:maxFixupChoices]
+	}
+
+	fmt.Println("Commits that last changed the staged lines:")
+	for i, c := range unpushed {
+		fmt.Printf("  %d. %s %s (%d)\n", i+1, shortHash(c.Hash), c.Subject, c.Score)
+	}
+	if !interactive {
+		return unpushed[0].Hash, nil
+	}
+
+	fmt.Printf("\nFix up which commit? [1-%d, q to cancel] (1): ", len(unpushed))
+	var response string
+	fmt.Scanln(&response)
+	response = strings.TrimSpace(response)
+	switch response {
+	case "":
+		return unpushed[0].Hash, nil
+	case "q":
+		ui.ShowInfo("Commit cancelled")
+		return "", nil
+	}
+
+	choice, err := strconv.Atoi(response)
+	if err != nil || choice < 1 || choice > len(unpushed) {
+		return "", fmt.Errorf("invalid choice %q", response)
+	}
+	return unpushed[choice-1].Hash, nil
+}
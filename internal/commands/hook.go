@@ -0,0 +1,193 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/tarantino19/aig/internal/config"
+	"github.com/tarantino19/aig/internal/git"
+	"github.com/tarantino19/aig/internal/secrets"
+	"github.com/tarantino19/aig/internal/ui"
+)
+
+// hookMarker identifies the pre-commit hook written by aig
+const hookMarker = "# Installed by aig"
+
+// preCommitHook runs the secret scan before every commit
+const preCommitHook = `#!/bin/sh
+` + hookMarker + `: blocks commits that contain secrets.
+# Bypass once with: git commit --no-verify
+exec aig hook pre-commit
+`
+
+// allowSecretsEnvVar passes --allow-secrets on to the hook that runs when aig commits
+const allowSecretsEnvVar = "AIG_ALLOW_SECRETS"
+
+var (
+	allowSecrets bool
+	hookForce    bool
+)
+
+// NewHookCmd creates the hook command
+func NewHookCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "hook",
+		Short: "Manage the git pre-commit hook",
+		Long: `Install a pre-commit hook that refuses to commit staged changes containing
+secrets such as API keys, tokens and private keys. The scan runs offline.
+
+Reviewed findings can be allowed by adding their fingerprint, or path:<pattern>,
+to the repository's .aigsecrets file, or by putting aig:allow-secret on the line.`,
+	}
+
+	cmd.AddCommand(newHookInstallCmd())
+	cmd.AddCommand(newHookUninstallCmd())
+	cmd.AddCommand(newHookPreCommitCmd())
+
+	return cmd
+}
+
+func newHookInstallCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "install",
+		Short: "Install the pre-commit hook in the current repository",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path, err := preCommitHookPath()
+			if err != nil {
+				return err
+			}
+
+			if existing, err := os.ReadFile(path); err == nil && !strings.Contains(string(existing), hookMarker) && !hookForce {
+				return fmt.Errorf("%s already exists and was not written by aig, use --force to replace it", path)
+			}
+
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				return fmt.Errorf("failed to create hooks directory: %w", err)
+			}
+			if err := os.WriteFile(path, []byte(preCommitHook), 0755); err != nil {
+				return fmt.Errorf("failed to write hook: %w", err)
+			}
+
+			ui.ShowSuccess(fmt.Sprintf("Installed %s", path))
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&hookForce, "force", false, "Replace an existing pre-commit hook")
+
+	return cmd
+}
+
+func newHookUninstallCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "uninstall",
+		Short: "Remove the pre-commit hook installed by aig",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path, err := preCommitHookPath()
+			if err != nil {
+				return err
+			}
+
+			existing, err := os.ReadFile(path)
+			if os.IsNotExist(err) {
+				ui.ShowInfo("No pre-commit hook installed")
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("failed to read hook: %w", err)
+			}
+			if !strings.Contains(string(existing), hookMarker) {
+				return fmt.Errorf("%s was not written by aig, remove it yourself", path)
+			}
+
+			if err := os.Remove(path); err != nil {
+				return fmt.Errorf("failed to remove hook: %w", err)
+			}
+			ui.ShowSuccess(fmt.Sprintf("Removed %s", path))
+			return nil
+		},
+	}
+}
+
+func newHookPreCommitCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "pre-commit",
+		Short:        "Scan the staged changes for secrets, as the pre-commit hook does",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := config.Load()
+			if err != nil {
+				return fmt.Errorf("failed to load config: %w", err)
+			}
+
+			diff, err := git.GetStagedDiff()
+			if err != nil {
+				return fmt.Errorf("failed to get staged changes: %w", err)
+			}
+
+			return checkStagedSecrets(cfg, diff)
+		},
+	}
+
+	cmd.Flags().BoolVar(&allowSecrets, "allow-secrets", false, "Report secrets without blocking the commit")
+
+	return cmd
+}
+
+// addAllowSecretsFlag registers --allow-secrets on a command that commits
+func addAllowSecretsFlag(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&allowSecrets, "allow-secrets", false, "Commit even if the staged changes contain secrets")
+}
+
+// checkStagedSecrets scans the staged diff and fails if it contains secrets,
+// unless the scan is disabled or --allow-secrets was given
+func checkStagedSecrets(cfg *config.Config, diff string) error {
+	if !cfg.Secrets.Enabled || diff == "" {
+		return nil
+	}
+
+	allow := secrets.ParseAllowlist(nil)
+	if root, err := git.GetRepoRoot(); err == nil {
+		if allow, err = secrets.LoadAllowlist(root); err != nil {
+			return err
+		}
+	}
+
+	scanner, err := secrets.NewScanner(cfg.Secrets.Detectors, cfg.Secrets.Patterns, allow)
+	if err != nil {
+		return fmt.Errorf("invalid secrets config: %w", err)
+	}
+
+	findings := scanner.ScanDiff(diff)
+	if len(findings) == 0 {
+		return nil
+	}
+
+	for _, finding := range findings {
+		fmt.Fprintf(os.Stderr, "  %s\n", finding)
+	}
+	if allowSecrets || os.Getenv(allowSecretsEnvVar) == "1" {
+		ui.ShowWarning(fmt.Sprintf("Committing %d secret(s) because of --allow-secrets", len(findings)))
+		// The pre-commit hook run by the commit inherits the decision
+		os.Setenv(allowSecretsEnvVar, "1")
+		return nil
+	}
+
+	ui.ShowInfo(fmt.Sprintf("If these are not secrets, add their fingerprints to %s or use --allow-secrets", secrets.AllowlistFile))
+	return fmt.Errorf("staged changes contain %d secret(s), refusing to commit", len(findings))
+}
+
+// preCommitHookPath returns the path of the repository's pre-commit hook
+func preCommitHookPath() (string, error) {
+	dir, err := git.GetHooksDir()
+	if err != nil {
+		return "", fmt.Errorf("not in a git repository: %w", err)
+	}
+	return filepath.Join(dir, "pre-commit"), nil
+}
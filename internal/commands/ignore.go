@@ -0,0 +1,34 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/tarantino19/aig/internal/git"
+	"github.com/tarantino19/aig/internal/ignore"
+	"github.com/tarantino19/aig/internal/ui"
+)
+
+// filterIgnoredPaths removes files matched by the repository's .aigignore from a
+// diff so that they are never sent to the AI provider
+func filterIgnoredPaths(diff string) (string, error) {
+	repoRoot, err := git.GetRepoRoot()
+	if err != nil {
+		// Outside a repository there is no .aigignore to apply
+		return diff, nil
+	}
+
+	matcher, err := ignore.Load(repoRoot)
+	if err != nil {
+		return "", err
+	}
+	if matcher.Empty() {
+		return diff, nil
+	}
+
+	filtered, skipped := git.FilterDiff(diff, matcher.Match)
+	if len(skipped) > 0 {
+		ui.ShowInfo(fmt.Sprintf("Skipping %d file(s) listed in %s", len(skipped), ignore.FileName))
+	}
+
+	return filtered, nil
+}
@@ -2,15 +2,16 @@ package commands
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"regexp"
 	"strings"
-	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/tarantino19/aig/internal/ai"
 	"github.com/tarantino19/aig/internal/config"
 	"github.com/tarantino19/aig/internal/git"
+	"github.com/tarantino19/aig/internal/tickets"
 	"github.com/tarantino19/aig/internal/ui"
 )
 
@@ -35,26 +36,36 @@ an intelligent PR/MR description with summary, changes, issue links, and checkli
 	}
 
 	cmd.Flags().StringVarP(&prTargetBranch, "target", "t", "main", "Target branch for comparison")
-	cmd.Flags().StringVarP(&prPlatform, "platform", "p", "github", "Platform (github|gitlab|bitbucket)")
+	cmd.Flags().StringVarP(&prPlatform, "platform", "p", "github", "Platform (github|gitlab|bitbucket), defaults to pr.platform")
 	cmd.Flags().StringVar(&prTemplate, "template", "standard", "Template type (standard|minimal|detailed)")
 	cmd.Flags().BoolVarP(&prDraft, "draft", "d", false, "Generate draft PR description")
 	cmd.Flags().BoolVarP(&prInteractive, "interactive", "i", true, "Interactive mode for editing")
 	cmd.Flags().BoolVarP(&prCopyToClipboard, "copy", "c", false, "Copy description to clipboard")
 
+	addCacheFlag(cmd)
+	addProfileFlag(cmd)
+	addAIFlags(cmd, "pr")
+
 	return cmd
 }
 
 func runPR(cmd *cobra.Command, args []string) error {
+	// An explicit --platform wins over every config file
+	if cmd.Flags().Changed("platform") {
+		config.SetOverride("pr.platform", prPlatform, "--platform")
+	}
+
 	// Load configuration
 	cfg, err := config.Load()
 	if err != nil {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
+	prPlatform = cfg.PR.Platform
 
 	// Check if API key is configured
-	if cfg.AI.APIKey == "" || cfg.AI.APIKey == "your-gemini-api-key-here" || cfg.AI.APIKey == "your-openai-api-key-here" {
+	if cfg.AI.NeedsAPIKey() && (cfg.AI.APIKey == "" || cfg.AI.APIKey == "your-gemini-api-key-here" || cfg.AI.APIKey == "your-openai-api-key-here") {
 		ui.ShowError(fmt.Errorf("%s API key not configured", strings.Title(cfg.AI.Provider)))
-		ui.ShowInfo("Please configure your API key first using 'aig config set ai.api_key YOUR_KEY'")
+		ui.ShowInfo("Please configure your API key first using 'aig auth login'")
 		return nil
 	}
 
@@ -81,6 +92,11 @@ func runPR(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
+	diff, err = filterIgnoredPaths(diff)
+	if err != nil {
+		return fmt.Errorf("failed to apply ignore file: %w", err)
+	}
+
 	// Get commits in current branch that are not in target
 	commits, err := git.GetCommits(git.CommitOptions{
 		Branch: fmt.Sprintf("%s..%s", prTargetBranch, currentBranch),
@@ -93,28 +109,26 @@ func runPR(cmd *cobra.Command, args []string) error {
 	ui.ShowInfo(fmt.Sprintf("📊 Found %d commits and analyzing diff...", len(commits)))
 
 	// Extract issue numbers from branch name and commits
-	issueNumbers := extractIssueNumbers(currentBranch, commits)
+	matcher, err := ticketMatcher(cfg)
+	if err != nil {
+		return err
+	}
+	issueNumbers := extractIssueNumbers(matcher, currentBranch, commits)
 
 	// Create AI provider
-	provider, err := ai.NewProvider(ai.ProviderConfig{
-		Provider:    cfg.AI.Provider,
-		APIKey:      cfg.AI.APIKey,
-		Model:       cfg.AI.Model,
-		Temperature: cfg.AI.Temperature,
-		MaxTokens:   cfg.AI.MaxTokens,
-	})
+	provider, err := newAIProvider(cfg, "pr")
 	if err != nil {
-		return fmt.Errorf("failed to create AI provider: %w", err)
+		return err
 	}
 	defer provider.Close()
 
 	// Generate PR description
 	ui.ShowInfo(fmt.Sprintf("🤖 Generating PR description with %s...", strings.Title(cfg.AI.Provider)))
 	
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	ctx, cancel := aiContext(cmd.Context(), cfg)
 	defer cancel()
 
-	prDescription, err := generatePRDescription(ctx, provider, PRAnalysis{
+	analysis := PRAnalysis{
 		CurrentBranch: currentBranch,
 		TargetBranch:  prTargetBranch,
 		Diff:          diff,
@@ -123,8 +137,14 @@ func runPR(cmd *cobra.Command, args []string) error {
 		Platform:      prPlatform,
 		Template:      prTemplate,
 		IsDraft:       prDraft,
-	})
+	}
+	prDescription, err := generatePRDescription(ctx, provider, analysis)
+	if errors.Is(err, ai.ErrContextTooLong) {
+		analysis.Diff = trimForContext(analysis.Diff)
+		prDescription, err = generatePRDescription(ctx, provider, analysis)
+	}
 	if err != nil {
+		showProviderRemedy(err, cfg)
 		return fmt.Errorf("failed to generate PR description: %w", err)
 	}
 
@@ -168,23 +188,10 @@ type PRAnalysis struct {
 }
 
 // PRDescription represents a generated PR description
-type PRDescription struct {
-	Title         string
-	Summary       string
-	Changes       []string
-	IssueLinks    []string
-	TestingNotes  string
-	Checklist     []ChecklistItem
-	BreakingChanges []string
-	Screenshots   bool
-	Platform      string
-}
+type PRDescription = ui.PRDescription
 
 // ChecklistItem represents a checklist item in the PR
-type ChecklistItem struct {
-	Text    string
-	Checked bool
-}
+type ChecklistItem = ui.ChecklistItem
 
 func generatePRDescription(ctx context.Context, provider ai.Provider, analysis PRAnalysis) (*PRDescription, error) {
 	// For now, we'll use the existing AI interface. Later we can extend it for PR-specific generation
@@ -209,7 +216,7 @@ func generatePRDescription(ctx context.Context, provider ai.Provider, analysis P
 	if commitMsg.Subject != "" {
 		prDesc.Title = capitalizeFirst(commitMsg.Subject)
 	} else {
-		prDesc.Title = generateTitleFromBranch(analysis.CurrentBranch)
+		prDesc.Title = generateTitleFromBranch(analysis.CurrentBranch, analysis.IssueNumbers)
 	}
 
 	// Use commit body as base summary, or generate from commits
@@ -230,47 +237,20 @@ func generatePRDescription(ctx context.Context, provider ai.Provider, analysis P
 	return prDesc, nil
 }
 
-func extractIssueNumbers(branchName string, commits []git.Commit) []string {
-	issueSet := make(map[string]bool)
-	
-	// Extract from branch name
-	branchIssues := extractIssuesFromText(branchName)
-	for _, issue := range branchIssues {
-		issueSet[issue] = true
-	}
-	
-	// Extract from commit messages
+func extractIssueNumbers(matcher *tickets.Matcher, branchName string, commits []git.Commit) []string {
+	texts := []string{branchName}
 	for _, commit := range commits {
-		commitIssues := extractIssuesFromText(commit.Message)
-		for _, issue := range commitIssues {
-			issueSet[issue] = true
-		}
+		texts = append(texts, commit.Message)
 	}
 	
-	// Convert set to slice
+	// Keep the order in which they were found, branch first
 	var issues []string
-	for issue := range issueSet {
-		issues = append(issues, issue)
-	}
-	
-	return issues
-}
-
-func extractIssuesFromText(text string) []string {
-	// Match various issue number patterns: #123, fixes #123, closes #456, resolves #789
-	patterns := []string{
-		`#(\d+)`,
-		`(?i)(?:fix|fixes|fixed|close|closes|closed|resolve|resolves|resolved)\s+#(\d+)`,
-		`(?i)(?:fix|fixes|fixed|close|closes|closed|resolve|resolves|resolved)\s+(\d+)`,
-	}
-	
-	var issues []string
-	for _, pattern := range patterns {
-		re := regexp.MustCompile(pattern)
-		matches := re.FindAllStringSubmatch(text, -1)
-		for _, match := range matches {
-			if len(match) > 1 {
-				issues = append(issues, match[1])
+	issueSet := make(map[string]bool)
+	for _, text := range texts {
+		for _, issue := range matcher.FindAll(text) {
+			if !issueSet[issue] {
+				issueSet[issue] = true
+				issues = append(issues, issue)
 			}
 		}
 	}
@@ -278,7 +258,7 @@ func extractIssuesFromText(text string) []string {
 	return issues
 }
 
-func generateTitleFromBranch(branchName string) string {
+func generateTitleFromBranch(branchName string, issues []string) string {
 	// Remove prefixes and clean up branch name
 	title := branchName
 	prefixes := []string{"feature/", "feat/", "fix/", "bugfix/", "hotfix/", "chore/", "docs/"}
@@ -291,7 +271,9 @@ func generateTitleFromBranch(branchName string) string {
 	}
 	
 	// Remove issue numbers and dates
-	title = regexp.MustCompile(`\d{4,5}-?`).ReplaceAllString(title, "")
+	for _, issue := range issues {
+		title = regexp.MustCompile(`(?i)#?`+regexp.QuoteMeta(issue)+`-?`).ReplaceAllString(title, "")
+	}
 	title = regexp.MustCompile(`\d{8}`).ReplaceAllString(title, "")
 	
 	// Replace hyphens/underscores with spaces and capitalize
@@ -350,13 +332,14 @@ func formatIssueLinks(issueNumbers []string, platform string) []string {
 	
 	links := make([]string, len(issueNumbers))
 	for i, issue := range issueNumbers {
+		ref := tickets.Reference(issue)
 		switch platform {
 		case "gitlab":
-			links[i] = fmt.Sprintf("Closes #%s", issue)
+			links[i] = fmt.Sprintf("Closes %s", ref)
 		case "bitbucket":
-			links[i] = fmt.Sprintf("Fixes #%s", issue)
+			links[i] = fmt.Sprintf("Fixes %s", ref)
 		default: // github
-			links[i] = fmt.Sprintf("Fixes #%s", issue)
+			links[i] = fmt.Sprintf("Fixes %s", ref)
 		}
 	}
 	
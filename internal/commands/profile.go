@@ -0,0 +1,229 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/tarantino19/aig/internal/config"
+	"github.com/tarantino19/aig/internal/ui"
+)
+
+var (
+	profileName        string
+	profileUseRepo     bool
+	profileUsePrivate  bool
+	profileProvider    string
+	profileModel       string
+	profileTemperature float64
+)
+
+// addProfileFlag registers --profile on a command and its subcommands
+func addProfileFlag(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringVar(&profileName, "profile", "", "Configuration profile to use (overrides AIG_PROFILE)")
+
+	next := cmd.PersistentPreRunE
+	cmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		if profileName != "" {
+			config.SelectProfile(profileName)
+		}
+		if next != nil {
+			return next(cmd, args)
+		}
+		return nil
+	}
+}
+
+func newConfigProfileCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "profile",
+		Short: "Manage configuration profiles",
+		Long: `Profiles are named sets of ai, review and ui settings stored under
+'profiles' in the config file. The active profile is chosen by --profile,
+then AIG_PROFILE, then the 'profile' key of the config files.`,
+	}
+
+	cmd.AddCommand(newProfileListCmd())
+	cmd.AddCommand(newProfileUseCmd())
+	cmd.AddCommand(newProfileCreateCmd())
+	cmd.AddCommand(newProfileDeleteCmd())
+
+	return cmd
+}
+
+func newProfileListCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List configuration profiles",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if _, err := config.Load(); err != nil {
+				return fmt.Errorf("failed to load config: %w", err)
+			}
+
+			names := config.ProfileNames()
+			if len(names) == 0 {
+				ui.ShowInfo("No profiles configured. Create one with 'aig config profile create <name>'")
+				return nil
+			}
+
+			active, origin := config.ActiveProfile()
+			for _, name := range names {
+				if name == active {
+					fmt.Printf("* %s\t[%s]\n", name, origin)
+				} else {
+					fmt.Printf("  %s\n", name)
+				}
+			}
+			return nil
+		},
+	}
+}
+
+func newProfileUseCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "use <name>",
+		Short: "Make a profile the default",
+		Long: `Make a profile the default by setting the 'profile' key in the global config.
+
+Use --repo to make it the default for everyone working on the repository, or
+--private to make it your own default for the repository only.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := args[0]
+
+			if _, err := config.Load(); err != nil {
+				return fmt.Errorf("failed to load config: %w", err)
+			}
+			if !contains(config.ProfileNames(), name) {
+				return fmt.Errorf("profile %q not found", name)
+			}
+
+			configPath, err := configTargetPath(profileUseRepo, profileUsePrivate)
+			if err != nil {
+				return err
+			}
+
+			if err := config.SetFileValue(configPath, "profile", name); err != nil {
+				return fmt.Errorf("failed to write config: %w", err)
+			}
+
+			ui.ShowSuccess(fmt.Sprintf("Using profile %s (%s)", name, configPath))
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&profileUseRepo, "repo", false, "Set the default for the repository in "+config.RepoConfigFile)
+	cmd.Flags().BoolVar(&profileUsePrivate, "private", false, "Set your own default for the repository in $GIT_DIR/"+config.PrivateConfigFile)
+
+	return cmd
+}
+
+func newProfileCreateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "create <name>",
+		Short: "Create a configuration profile",
+		Long: `Create a profile in the global config file. Without flags the profile
+starts as a copy of the current AI provider and model. Further settings can be
+added with 'aig config set profiles.<name>.<key> <value>'.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := args[0]
+
+			cfg, err := config.Load()
+			if err != nil {
+				return fmt.Errorf("failed to load config: %w", err)
+			}
+			if contains(config.ProfileNames(), name) {
+				return fmt.Errorf("profile %q already exists", name)
+			}
+
+			provider, model := profileProvider, profileModel
+			if provider == "" && model == "" {
+				provider, model = cfg.AI.Provider, cfg.AI.Model
+			}
+
+			values := map[string]interface{}{}
+			if provider != "" {
+				values["ai.provider"] = provider
+			}
+			if model != "" {
+				values["ai.model"] = model
+			}
+			if cmd.Flags().Changed("temperature") {
+				values["ai.temperature"] = profileTemperature
+			}
+
+			for key, value := range values {
+				if err := config.ValidateValue(key, value); err != nil {
+					return err
+				}
+			}
+			if provider != "" {
+				if err := config.ValidateModel(provider, model); err != nil {
+					return err
+				}
+			}
+
+			configPath, err := config.GlobalConfigPath()
+			if err != nil {
+				return fmt.Errorf("failed to get config directory: %w", err)
+			}
+			for _, key := range []string{"ai.provider", "ai.model", "ai.temperature"} {
+				value, ok := values[key]
+				if !ok {
+					continue
+				}
+				if err := config.SetFileValue(configPath, "profiles."+name+"."+key, value); err != nil {
+					return fmt.Errorf("failed to write config: %w", err)
+				}
+			}
+
+			ui.ShowSuccess(fmt.Sprintf("Created profile %s", name))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&profileProvider, "provider", "", "AI provider for the profile")
+	cmd.Flags().StringVar(&profileModel, "model", "", "AI model for the profile")
+	cmd.Flags().Float64Var(&profileTemperature, "temperature", 0, "Sampling temperature for the profile")
+
+	return cmd
+}
+
+func newProfileDeleteCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "delete <name>",
+		Short: "Delete a configuration profile",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := args[0]
+
+			configPath, err := config.GlobalConfigPath()
+			if err != nil {
+				return fmt.Errorf("failed to get config directory: %w", err)
+			}
+
+			// A profile that no longer loads must still be deletable, so errors are ignored
+			_, _ = config.Load()
+			active, origin := config.ActiveProfile()
+
+			deleted, err := config.DeleteFileKey(configPath, "profiles."+name)
+			if err != nil {
+				return fmt.Errorf("failed to write config: %w", err)
+			}
+			if !deleted {
+				return fmt.Errorf("profile %q not found in %s", name, configPath)
+			}
+
+			if active == name && origin.Kind == config.OriginGlobal {
+				if _, err := config.DeleteFileKey(configPath, "profile"); err != nil {
+					return fmt.Errorf("failed to write config: %w", err)
+				}
+			} else if active == name {
+				ui.ShowWarning(fmt.Sprintf("Profile %s is still selected by %s", name, origin))
+			}
+
+			ui.ShowSuccess(fmt.Sprintf("Deleted profile %s", name))
+			return nil
+		},
+	}
+}
@@ -0,0 +1,255 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/tarantino19/aig/internal/config"
+	"github.com/tarantino19/aig/internal/git"
+	"github.com/tarantino19/aig/internal/ui"
+	"github.com/tarantino19/aig/pkg/prompts"
+)
+
+// repoPromptsDir is where a repository keeps its prompt templates
+const repoPromptsDir = ".aig/prompts"
+
+var (
+	promptsBuiltin bool
+	promptsRepo    bool
+	promptsForce   bool
+)
+
+// NewPromptsCmd creates the prompts command
+func NewPromptsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "prompts",
+		Short: "Inspect and customise the AI prompt templates",
+		Long: `The prompts sent to the AI provider are Go text/template files. A template
+in a repository's .aig/prompts directory replaces the one in
+~/.config/aig/prompts, which replaces the builtin template.
+
+Templates: commit, summary, review, pr.`,
+	}
+
+	cmd.AddCommand(newPromptsListCmd())
+	cmd.AddCommand(newPromptsShowCmd())
+	cmd.AddCommand(newPromptsEjectCmd())
+	cmd.AddCommand(newPromptsTestCmd())
+
+	return cmd
+}
+
+// configurePrompts points the prompt loader at the user and repository template
+// directories
+func configurePrompts() {
+	var dirs []string
+	if root, err := git.GetRepoRoot(); err == nil && root != "" {
+		dirs = append(dirs, filepath.Join(root, repoPromptsDir))
+	}
+	if dir, err := userPromptsDir(); err == nil {
+		dirs = append(dirs, dir)
+	}
+	prompts.SetOverrideDirs(dirs...)
+}
+
+// userPromptsDir returns the directory holding the user's prompt templates
+func userPromptsDir() (string, error) {
+	configPath, err := config.GlobalConfigPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(configPath), "prompts"), nil
+}
+
+func newPromptsListCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List the prompt templates in effect",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			configurePrompts()
+
+			for _, name := range prompts.Names() {
+				t, err := prompts.Load(name)
+				if err != nil {
+					fmt.Printf("  %-8s error: %v\n", name, err)
+					continue
+				}
+				fmt.Printf("  %-8s %-16s %s\n", name, t.Version, t.Source)
+			}
+			return nil
+		},
+	}
+}
+
+func newPromptsShowCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "show <name>",
+		Short: "Print a prompt template",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			configurePrompts()
+
+			t, err := loadPrompt(args[0], promptsBuiltin)
+			if err != nil {
+				return err
+			}
+
+			ui.ShowInfo(fmt.Sprintf("%s (%s, version %s)", t.Name, t.Source, t.Version))
+			fmt.Print(t.Text)
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&promptsBuiltin, "builtin", false, "Show the builtin template even if it is overridden")
+
+	return cmd
+}
+
+func newPromptsEjectCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "eject <name>",
+		Short: "Copy a builtin template for editing",
+		Long: `Copy a builtin template to ~/.config/aig/prompts, or with --repo to the
+repository's .aig/prompts, where it overrides the builtin template.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			t, err := prompts.LoadBuiltin(args[0])
+			if err != nil {
+				return err
+			}
+
+			dir, err := userPromptsDir()
+			if promptsRepo {
+				var root string
+				root, err = git.GetRepoRoot()
+				if err != nil {
+					return fmt.Errorf("--repo requires a git repository")
+				}
+				dir = filepath.Join(root, repoPromptsDir)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to get prompts directory: %w", err)
+			}
+
+			path := filepath.Join(dir, t.Name+".tmpl")
+			if _, err := os.Stat(path); err == nil && !promptsForce {
+				return fmt.Errorf("%s already exists, use --force to overwrite it", path)
+			}
+
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				return fmt.Errorf("failed to create prompts directory: %w", err)
+			}
+			if err := os.WriteFile(path, []byte(t.Text), 0644); err != nil {
+				return fmt.Errorf("failed to write template: %w", err)
+			}
+
+			ui.ShowSuccess(fmt.Sprintf("Wrote %s", path))
+			ui.ShowInfo(fmt.Sprintf("Check your changes with 'aig prompts test %s'", t.Name))
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&promptsRepo, "repo", false, "Eject into the repository's "+repoPromptsDir)
+	cmd.Flags().BoolVar(&promptsForce, "force", false, "Overwrite an existing template")
+
+	return cmd
+}
+
+func newPromptsTestCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "test <name>",
+		Short: "Render a template with sample data",
+		Long: `Render a template with sample data to check that it parses and produces the
+prompt you expect. Nothing is sent to the AI provider.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			configurePrompts()
+
+			t, err := loadPrompt(args[0], promptsBuiltin)
+			if err != nil {
+				return err
+			}
+
+			data, ok := samplePromptData[t.Name]
+			if !ok {
+				return fmt.Errorf("no sample data for template %q", t.Name)
+			}
+
+			prompt, err := t.Execute(data)
+			if err != nil {
+				return err
+			}
+
+			ui.ShowInfo(fmt.Sprintf("%s (%s, version %s)", t.Name, t.Source, t.Version))
+			fmt.Println(prompt)
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&promptsBuiltin, "builtin", false, "Test the builtin template even if it is overridden")
+
+	return cmd
+}
+
+func loadPrompt(name string, builtin bool) (*prompts.Template, error) {
+	if builtin {
+		return prompts.LoadBuiltin(name)
+	}
+	return prompts.Load(name)
+}
+
+// sampleDiff is a small diff used to render templates in 'aig prompts test'
+const sampleDiff = `diff --git a/greet.go b/greet.go
+--- a/greet.go
++++ b/greet.go
+@@ -1,5 +1,5 @@
+ package main
+
+ func greet(name string) string {
+-	return "Hello " + name
++	return "Hello, " + name + "!"
+ }`
+
+var sampleCommits = []prompts.Commit{
+	{Hash: "3f2a9c1d8e7b6a5f4e3d2c1b0a9f8e7d6c5b4a3f", Author: "Jane Doe", Date: "2025-01-02", Message: "feat(api): add pagination to list endpoints"},
+	{Hash: "9e8d7c6b5a4f3e2d1c0b9a8f7e6d5c4b3a2f1e0d", Author: "John Roe", Date: "2025-01-01", Message: "fix: handle empty config files"},
+}
+
+// samplePromptData holds the data each template is rendered with in 'aig prompts test'
+var samplePromptData = map[string]interface{}{
+	prompts.CommitTemplate: prompts.CommitData{
+		Diff:         sampleDiff,
+		Conventional: true,
+		Types:        []string{"feat", "fix", "docs", "refactor", "test", "chore"},
+		Scopes:       []string{"api", "cli"},
+		Examples: []string{
+			"feat(api): add pagination to list endpoints",
+			"fix(cli): handle empty config files",
+		},
+	},
+	prompts.SummaryTemplate: prompts.SummaryData{
+		Commits:     sampleCommits,
+		GroupByType: true,
+	},
+	prompts.ReviewTemplate: prompts.ReviewData{
+		Diff:       sampleDiff,
+		FocusAreas: []string{"security", "performance"},
+		Security:   true,
+	},
+	prompts.PRTemplate: prompts.PRData{
+		CurrentBranch: "feature/1234-friendlier-greeting",
+		TargetBranch:  "main",
+		Diff:          sampleDiff,
+		Commits:       sampleCommits,
+		Issues:        []string{"#1234"},
+		Platform:      "github",
+	},
+	prompts.SplitTemplate: prompts.SplitData{
+		Hunks:        []prompts.Hunk{{ID: "H1", Path: "greet.go", Diff: sampleDiff}},
+		Conventional: true,
+		Types:        []string{"feat", "fix", "docs", "refactor", "test", "chore"},
+	},
+}
@@ -0,0 +1,214 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/tarantino19/aig/internal/ai"
+	"github.com/tarantino19/aig/internal/cache"
+	"github.com/tarantino19/aig/internal/config"
+	"github.com/tarantino19/aig/internal/git"
+	"github.com/tarantino19/aig/internal/redact"
+	"github.com/tarantino19/aig/internal/ui"
+)
+
+// responseCacheNamespace is the cache store holding whole provider responses
+const responseCacheNamespace = "responses"
+
+// noCache bypasses the response cache for the current command
+var noCache bool
+
+var (
+	modelFlag    string
+	providerFlag string
+)
+
+// addCacheFlag registers --no-cache on a command that calls the AI provider
+func addCacheFlag(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&noCache, "no-cache", false, "Always send requests to the AI provider instead of reusing cached responses")
+}
+
+// addAIFlags registers --model and --provider on a command that calls the AI
+// provider and selects the command's ai.commands section of the config
+func addAIFlags(cmd *cobra.Command, command string) {
+	cmd.PersistentFlags().StringVar(&modelFlag, "model", "", "AI model to use (overrides ai.model)")
+	cmd.PersistentFlags().StringVar(&providerFlag, "provider", "", "AI provider to use: openai, gemini or replay (overrides ai.provider)")
+
+	next := cmd.PersistentPreRunE
+	cmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		config.SelectCommand(command)
+		if cmd.Flags().Changed("model") {
+			config.SetOverride("ai.model", modelFlag, "--model")
+		}
+		if cmd.Flags().Changed("provider") {
+			config.SetOverride("ai.provider", providerFlag, "--provider")
+		}
+		if next != nil {
+			return next(cmd, args)
+		}
+		return nil
+	}
+}
+
+// aiContext bounds the requests of a command run by ai.timeout
+func aiContext(parent context.Context, cfg *config.Config) (context.Context, context.CancelFunc) {
+	if parent == nil {
+		parent = context.Background()
+	}
+	if cfg.AI.Timeout > 0 {
+		return context.WithTimeout(parent, cfg.AI.Timeout)
+	}
+	return context.WithCancel(parent)
+}
+
+// newAIProvider creates the configured AI provider wrapped in the shared middleware.
+// command names the aig command making the requests.
+func newAIProvider(cfg *config.Config, command string) (ai.Provider, error) {
+	configurePrompts()
+
+	if ignored := config.IgnoredSettings(); len(ignored) > 0 {
+		ui.ShowWarning(fmt.Sprintf("Ignoring settings of an untrusted repository: %s (see trusted_repos)", strings.Join(ignored, ", ")))
+	}
+
+	ai.ResetCallHooks()
+	if cfg.Audit.Enabled {
+		hook, err := auditHook(cfg, command)
+		if err != nil {
+			return nil, err
+		}
+		ai.RegisterCallHook(hook)
+	}
+	if cfg.Usage.Enabled {
+		hook, err := usageHook(cfg, command)
+		if err != nil {
+			return nil, err
+		}
+		ai.RegisterCallHook(hook)
+	}
+
+	providerConfig := ai.ProviderConfig{
+		Provider:    cfg.AI.Provider,
+		APIKey:      cfg.AI.APIKey,
+		Model:       cfg.AI.Model,
+		Temperature: cfg.AI.Temperature,
+		MaxTokens:   cfg.AI.MaxTokens,
+		Replay: ai.ReplayConfig{
+			Mode:     cfg.AI.Replay.Mode,
+			Dir:      cfg.AI.Replay.Dir,
+			Provider: cfg.AI.Replay.Provider,
+		},
+		Retry: ai.RetryPolicy{
+			MaxAttempts:  cfg.AI.Retry.MaxAttempts,
+			InitialDelay: cfg.AI.Retry.InitialDelay,
+			MaxDelay:     cfg.AI.Retry.MaxDelay,
+		},
+		RateLimit: ai.RateLimit{
+			RequestsPerMinute: cfg.AI.RateLimit.RequestsPerMinute,
+			Burst:             cfg.AI.RateLimit.Burst,
+		},
+	}
+
+	provider, err := ai.NewProvider(providerConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AI provider: %w", err)
+	}
+
+	// Cached responses would hide requests from the recorder
+	if cfg.Cache.Enabled && !noCache && cfg.AI.Provider != "replay" {
+		store, err := cache.OpenWithOptions(responseCacheNamespace, cache.Options{
+			TTL:      cfg.Cache.TTL,
+			MaxBytes: int64(cfg.Cache.MaxSizeMB) * 1024 * 1024,
+		})
+		if err != nil {
+			// Caching is an optimisation; carry on without it
+			ui.ShowWarning(fmt.Sprintf("Response cache unavailable: %v", err))
+		} else {
+			provider = ai.NewCachingProvider(provider, store, providerConfig)
+		}
+	}
+
+	// Redaction comes last so that secrets reach neither the provider nor the cache
+	if cfg.Redact.Enabled {
+		redactor, err := redact.New(redact.Options{
+			Detectors: cfg.Redact.Detectors,
+			Patterns:  cfg.Redact.Patterns,
+			DenyPaths: cfg.Redact.DenyPaths,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("invalid redact config: %w", err)
+		}
+		provider = ai.NewRedactingProvider(provider, redactor, showRedactions)
+	}
+
+	return provider, nil
+}
+
+// providerRemedies are the API key variables and billing pages of each provider
+var providerRemedies = map[string]struct{ envVar, billing string }{
+	"openai": {"AIG_OPENAI_API_KEY", "https://platform.openai.com/usage"},
+	"gemini": {"AIG_GEMINI_API_KEY", "https://ai.google.dev/gemini-api/docs/rate-limits"},
+}
+
+// showProviderRemedy suggests what to do about a failed provider request
+func showProviderRemedy(err error, cfg *config.Config) {
+	remedy := providerRemedies[cfg.AI.Provider]
+
+	switch {
+	case errors.Is(err, ai.ErrAuth):
+		ui.ShowInfo(fmt.Sprintf("Check the %s API key: store it with 'aig auth login --provider %s' or set %s", cfg.AI.Provider, cfg.AI.Provider, remedy.envVar))
+	case errors.Is(err, ai.ErrQuotaExhausted):
+		if remedy.billing != "" {
+			ui.ShowInfo(fmt.Sprintf("The %s quota is used up, check your plan and billing at %s", cfg.AI.Provider, remedy.billing))
+		}
+	case errors.Is(err, ai.ErrRateLimited):
+		ui.ShowInfo("Wait a minute and try again, or keep aig under the limit with ai.rate_limit.requests_per_minute")
+	case errors.Is(err, ai.ErrTimeout):
+		ui.ShowInfo("Try again, or send less at once: stage fewer changes or list generated files in .aigignore")
+	case errors.Is(err, ai.ErrContextTooLong):
+		ui.ShowInfo(fmt.Sprintf("The changes do not fit in %s: stage fewer of them, list generated files in .aigignore or pick a model with a larger context", cfg.AI.Model))
+	case errors.Is(err, ai.ErrContentBlocked):
+		ui.ShowInfo("The provider's safety filters rejected the request; exclude the affected files in .aigignore")
+	}
+}
+
+// trimForContext halves a diff that was too long for the model
+func trimForContext(diff string) string {
+	trimmed, dropped := git.TrimDiff(diff, len(diff)/2)
+	ui.ShowWarning(fmt.Sprintf("The diff is too long for the model, retrying without the changes to %d file(s): %s", len(dropped), strings.Join(dropped, ", ")))
+	return trimmed
+}
+
+// showRedactions warns about what was removed from a request
+func showRedactions(report redact.Report) {
+	if len(report.Denied) > 0 {
+		ui.ShowWarning(fmt.Sprintf("Not sending %d file(s) on the redaction deny list: %s", len(report.Denied), strings.Join(report.Denied, ", ")))
+	}
+	if len(report.Findings) > 0 {
+		ui.ShowWarning(fmt.Sprintf("Redacted before sending: %s", report))
+	}
+}
+
+// dataFilePath returns a configured file path with ~ expanded, or name in the aig
+// config directory when path is empty
+func dataFilePath(path, name string) (string, error) {
+	if path == "" {
+		configPath, err := config.GlobalConfigPath()
+		if err != nil {
+			return "", fmt.Errorf("failed to get config directory: %w", err)
+		}
+		return filepath.Join(filepath.Dir(configPath), name), nil
+	}
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to get home directory: %w", err)
+		}
+		path = filepath.Join(home, path[1:])
+	}
+	return path, nil
+}
@@ -1,13 +1,19 @@
 package commands
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
+	"path/filepath"
 
 	"github.com/spf13/cobra"
 	"github.com/tarantino19/aig/internal/ai"
+	"github.com/tarantino19/aig/internal/cache"
 	"github.com/tarantino19/aig/internal/config"
 	"github.com/tarantino19/aig/internal/git"
+	"github.com/tarantino19/aig/internal/ignore"
+	"github.com/tarantino19/aig/internal/review"
 	"github.com/tarantino19/aig/internal/ui"
 )
 
@@ -20,6 +26,9 @@ var (
 	reviewVerbose     bool
 	reviewSecurity    bool
 	reviewPerformance bool
+	reviewBaseline    bool
+	reviewFull        bool
+	reviewReplace     bool
 )
 
 // NewReviewCmd creates the review command
@@ -29,7 +38,18 @@ func NewReviewCmd() *cobra.Command {
 		Aliases: []string{"r"},
 		Short:   "Get AI-powered code review for changes",
 		Long: `Analyzes code changes and provides intelligent feedback on
-potential issues, improvements, and best practices.`,
+potential issues, improvements, and best practices.
+
+Findings recorded with --baseline are added to .aig/review-baseline.json and
+hidden from later reviews; --baseline --replace discards the findings accepted
+before. Commit that file to share it with your team. A
+finding can also be silenced by an "aig:ignore" comment on its line or the
+line above it.
+
+Review results are cached per diff hunk, so re-running a review after a small
+fix only sends the hunks that changed. --full and --no-cache review the whole
+diff in a single request without reading or writing that cache, as does setting
+review.hunk_cache to false. Use 'aig cache clear' to drop cached results.`,
 		RunE: runReview,
 	}
 
@@ -41,11 +61,21 @@ potential issues, improvements, and best practices.`,
 	cmd.Flags().BoolVarP(&reviewVerbose, "verbose", "v", false, "Detailed review output")
 	cmd.Flags().BoolVar(&reviewSecurity, "security", false, "Focus on security issues")
 	cmd.Flags().BoolVar(&reviewPerformance, "performance", false, "Focus on performance issues")
+	cmd.Flags().BoolVar(&reviewBaseline, "baseline", false, "Record the current findings as accepted in the review baseline")
+	cmd.Flags().BoolVar(&reviewReplace, "replace", false, "With --baseline, replace the baseline instead of adding to it")
+	cmd.Flags().BoolVar(&reviewFull, "full", false, "Review the whole diff in one request without the per-hunk review cache")
+	addCacheFlag(cmd)
+	addProfileFlag(cmd)
+	addAIFlags(cmd, "review")
 
 	return cmd
 }
 
 func runReview(cmd *cobra.Command, args []string) error {
+	if reviewReplace && !reviewBaseline {
+		return fmt.Errorf("--replace only applies with --baseline")
+	}
+
 	// Load configuration
 	cfg, err := config.Load()
 	if err != nil {
@@ -81,21 +111,28 @@ func runReview(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("no changes found to review")
 	}
 
+	diff, err = filterIgnoredPaths(diff)
+	if err != nil {
+		return fmt.Errorf("failed to apply ignore file: %w", err)
+	}
+
+	if len(cfg.Review.ExcludePatterns) > 0 {
+		diff, _ = git.FilterDiff(diff, ignore.New(cfg.Review.ExcludePatterns).Match)
+	}
+
+	if diff == "" {
+		return fmt.Errorf("no changes left to review after applying .aigignore and review.exclude_patterns")
+	}
+
 	// Show diff preview if verbose
 	if reviewVerbose {
 		ui.ShowDiff(truncateString(diff, 500))
 	}
 
 	// Initialize AI provider
-	aiProvider, err := ai.NewProvider(ai.ProviderConfig{
-		Provider:    cfg.AI.Provider,
-		APIKey:      cfg.AI.APIKey,
-		Model:       cfg.AI.Model,
-		Temperature: cfg.AI.Temperature,
-		MaxTokens:   cfg.AI.MaxTokens,
-	})
+	aiProvider, err := newAIProvider(cfg, "review")
 	if err != nil {
-		return fmt.Errorf("failed to create AI provider: %w", err)
+		return err
 	}
 	defer func() {
 		if err := aiProvider.Close(); err != nil {
@@ -112,16 +149,100 @@ func runReview(cmd *cobra.Command, args []string) error {
 		Performance: reviewPerformance,
 	}
 
-	review, err := aiProvider.ReviewCode(cmd.Context(), diff, reviewOptions)
+	ctx, cancel := aiContext(cmd.Context(), cfg)
+	defer cancel()
+
+	var result *ai.Review
+	useCache := !noCache && !reviewFull && cfg.Review.HunkCache
+	if !useCache {
+		result, err = aiProvider.ReviewCode(ctx, diff, reviewOptions)
+		if errors.Is(err, ai.ErrContextTooLong) {
+			// Hunks are small enough to review one at a time
+			ui.ShowWarning("The diff is too long for the model, reviewing it hunk by hunk")
+			result, err = reviewIncrementally(ctx, cfg, aiProvider, diff, reviewOptions, true, false)
+		}
+	} else {
+		result, err = reviewIncrementally(ctx, cfg, aiProvider, diff, reviewOptions, false, true)
+		if errors.Is(err, ai.ErrContextTooLong) {
+			ui.ShowWarning("The changed hunks are too long for the model together, reviewing them one at a time")
+			result, err = reviewIncrementally(ctx, cfg, aiProvider, diff, reviewOptions, true, true)
+		}
+	}
 	if err != nil {
+		showProviderRemedy(err, cfg)
 		return fmt.Errorf("failed to get code review: %w", err)
 	}
 
-	ui.ShowReview(review)
+	baselinePath := review.BaselineFile
+	if repoRoot, err := git.GetRepoRoot(); err == nil {
+		baselinePath = filepath.Join(repoRoot, review.BaselineFile)
+	}
+
+	if reviewBaseline {
+		// Inline aig:ignore markers still apply; everything else is accepted
+		review.Suppress(result, diff, nil)
+		recorded, total, err := review.RecordBaseline(baselinePath, result, diff, reviewReplace)
+		if err != nil {
+			return err
+		}
+		ui.ShowSuccess(fmt.Sprintf("Recorded %d finding(s) in %s, %d in total", recorded, review.BaselineFile, total))
+		ui.ShowInfo("Commit this file to share the baseline with your team")
+		return nil
+	}
+
+	baseline, err := review.LoadBaseline(baselinePath)
+	if err != nil {
+		return err
+	}
+
+	hidden := review.Suppress(result, diff, baseline)
+
+	ui.ShowReview(result)
+
+	if hidden > 0 {
+		ui.ShowInfo(fmt.Sprintf("%d finding(s) hidden by the review baseline or aig:ignore comments", hidden))
+	}
 
 	return nil
 }
 
+// reviewIncrementally reviews only the hunks that are not in the review cache yet,
+// all in one request unless perHunk is set. Without useCache every hunk is
+// reviewed and nothing is cached. The cache is limited by the cache settings,
+// like the response cache.
+func reviewIncrementally(ctx context.Context, cfg *config.Config, provider ai.Provider, diff string, options ai.ReviewOptions, perHunk, useCache bool) (*ai.Review, error) {
+	var store *cache.Store
+	if useCache {
+		var err error
+		store, err = cache.OpenWithOptions(review.CacheNamespace, cache.Options{
+			TTL:      cfg.Cache.TTL,
+			MaxBytes: int64(cfg.Cache.MaxSizeMB) * 1024 * 1024,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	reviewer := &review.Incremental{
+		Provider:     provider,
+		Cache:        store,
+		ProviderName: cfg.AI.Provider,
+		Model:        cfg.AI.Model,
+		PerHunk:      perHunk,
+	}
+
+	result, stats, err := reviewer.Review(ctx, diff, options)
+	if err != nil {
+		return nil, err
+	}
+
+	if stats.Cached > 0 {
+		ui.ShowInfo(fmt.Sprintf("Reviewed %d of %d hunk(s), reused %d from cache", stats.Reviewed, stats.Hunks, stats.Cached))
+	}
+
+	return result, nil
+}
+
 func truncateString(s string, maxLen int) string {
 	if len(s) <= maxLen {
 		return s
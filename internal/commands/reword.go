@@ -0,0 +1,360 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/tarantino19/aig/internal/ai"
+	"github.com/tarantino19/aig/internal/config"
+	"github.com/tarantino19/aig/internal/git"
+	"github.com/tarantino19/aig/internal/ui"
+)
+
+var (
+	rewordAll    bool
+	rewordForce  bool
+	rewordDryRun bool
+	rewordYes    bool
+)
+
+// rewordColumn is the width of each side of the reword plan
+const rewordColumn = 44
+
+// NewRewordCmd creates the reword command
+func NewRewordCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "reword <range>",
+		Short: "Rewrite poor commit messages on the current branch",
+		Long: `Finds commits with poor messages such as "wip" or "fix stuff" in a range
+of the current branch and generates new messages from each commit's own diff.
+The range is a base commit (main, HEAD~5) or base..HEAD.
+
+History is rewritten without touching trees, authors or dates, and the old
+branch tip is kept under refs/aig/backup/. Commits that are already on a
+protected branch (git.protected_branches) or on the branch's upstream are
+never rewritten unless --force is given.`,
+		Args: cobra.ExactArgs(1),
+		RunE: runReword,
+	}
+
+	cmd.Flags().BoolVar(&rewordAll, "all", false, "Reword every commit in the range, not only poor ones")
+	cmd.Flags().BoolVar(&rewordForce, "force", false, "Rewrite commits that are already on protected or upstream branches")
+	cmd.Flags().BoolVar(&rewordDryRun, "dry-run", false, "Show the plan without rewriting history")
+	cmd.Flags().BoolVarP(&rewordYes, "yes", "y", false, "Rewrite without asking for confirmation")
+
+	addCacheFlag(cmd)
+	addProfileFlag(cmd)
+	addAIFlags(cmd, "commit")
+
+	return cmd
+}
+
+func runReword(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	branch, err := git.GetCurrentBranch()
+	if err != nil || branch == "" || branch == "HEAD" {
+		return fmt.Errorf("reword needs a checked out branch, HEAD is detached")
+	}
+	if git.IsProtectedBranch(branch, cfg.Git.ProtectedBranches) && !rewordForce {
+		return fmt.Errorf("%s is a protected branch (git.protected_branches), use --force to rewrite it anyway", branch)
+	}
+
+	base, err := rewordBase(args[0])
+	if err != nil {
+		return err
+	}
+	commits, err := git.GetRangeCommits(base)
+	if err != nil {
+		return err
+	}
+	if len(commits) == 0 {
+		ui.ShowInfo(fmt.Sprintf("No commits between %s and HEAD", base))
+		return nil
+	}
+
+	// Pick the commits to reword
+	var targets []git.RangeCommit
+	reasons := make(map[string]string)
+	for _, c := range commits {
+		if c.IsMerge() {
+			continue
+		}
+		reason := git.PoorMessageReason(c.Message)
+		if reason == "" && !rewordAll {
+			continue
+		}
+		targets = append(targets, c)
+		reasons[c.Hash] = reason
+	}
+	if len(targets) == 0 {
+		ui.ShowSuccess(fmt.Sprintf("All %d commit messages look fine, nothing to reword", len(commits)))
+		return nil
+	}
+
+	// Rewording a commit changes the hash of every commit after it
+	refs, err := git.PublishedRefs(cfg.Git.ProtectedBranches)
+	if err != nil {
+		return err
+	}
+	published, err := git.PublishedCommits(commits, refs)
+	if err != nil {
+		return err
+	}
+	if affected := affectedPublished(commits, targets[0].Hash, published); len(affected) > 0 && !rewordForce {
+		return fmt.Errorf("%d of the commits that would be rewritten are already on a protected or upstream branch (first: %s); use --force to rewrite them anyway",
+			len(affected), shortHash(affected[0]))
+	}
+
+	provider, err := newAIProvider(cfg, "reword")
+	if err != nil {
+		return err
+	}
+	defer provider.Close()
+
+	ctx, cancel := aiContext(cmd.Context(), cfg)
+	defer cancel()
+
+	matcher, err := ticketMatcher(cfg)
+	if err != nil {
+		return err
+	}
+	ticketKey := matcher.Find(branch)
+
+	options := ai.CommitOptions{
+		Conventional: true,
+		Types:        cfg.Commit.Types,
+		Scopes:       cfg.Commit.Scopes,
+	}
+
+	ui.ShowInfo(fmt.Sprintf("🤖 Generating %d new messages with %s...", len(targets), strings.Title(cfg.AI.Provider)))
+	messages := make(map[string]string)
+	for _, c := range targets {
+		diff, err := rewordDiff(c.Hash)
+		if err != nil {
+			return err
+		}
+		if diff == "" {
+			ui.ShowWarning(fmt.Sprintf("%s has no changes to describe (or only ignored files), keeping its message", shortHash(c.Hash)))
+			continue
+		}
+
+		msg, err := provider.GenerateCommitMessage(ctx, diff, options)
+		if errors.Is(err, ai.ErrContextTooLong) {
+			msg, err = provider.GenerateCommitMessage(ctx, trimForContext(diff), options)
+		}
+		if err != nil {
+			showProviderRemedy(err, cfg)
+			return fmt.Errorf("failed to generate a message for %s: %w", shortHash(c.Hash), err)
+		}
+
+		placeTicket(msg, ticketKey, cfg.Commit)
+		messages[c.Hash] = git.AppendTrailers(msg.FullMessage, git.ParseTrailers(c.Message))
+	}
+	if len(messages) == 0 {
+		return nil
+	}
+
+	showRewordPlan(targets, reasons, messages)
+	if rewordDryRun {
+		return nil
+	}
+
+	if !rewordYes {
+		fmt.Printf("\nRewrite %d commit messages on %s? [y/N/e(dit)]: ", len(messages), branch)
+		var response string
+		fmt.Scanln(&response)
+		switch strings.ToLower(strings.TrimSpace(response)) {
+		case "y", "yes":
+		case "e", "edit":
+			if messages, err = editRewordPlan(targets, messages); err != nil {
+				return err
+			}
+			if len(messages) == 0 {
+				ui.ShowInfo("Nothing left to reword")
+				return nil
+			}
+		default:
+			ui.ShowInfo("Reword cancelled")
+			return nil
+		}
+	}
+
+	oldTip := commits[len(commits)-1].Hash
+	newTip, err := git.RewriteMessages(commits, messages)
+	if err != nil {
+		return fmt.Errorf("failed to rewrite history: %w", err)
+	}
+
+	backup, err := git.CreateBackupRef(branch)
+	if err != nil {
+		return err
+	}
+	if err := git.UpdateBranch(branch, newTip, oldTip, "aig reword"); err != nil {
+		return err
+	}
+
+	ui.ShowSuccess(fmt.Sprintf("Reworded %d commits on %s", len(messages), branch))
+	ui.ShowInfo(fmt.Sprintf("The old branch is kept as %s; restore it with: git reset --keep %s", backup, backup))
+	return nil
+}
+
+// rewordBase returns the base commit of a range given as base, base.. or base..HEAD
+func rewordBase(spec string) (string, error) {
+	base, tip, isRange := strings.Cut(spec, "..")
+	if strings.HasPrefix(tip, ".") {
+		return "", fmt.Errorf("symmetric ranges such as %s are not supported, use base..HEAD", spec)
+	}
+	if isRange && tip != "" {
+		head, err := git.ResolveCommit("HEAD")
+		if err != nil {
+			return "", err
+		}
+		end, err := git.ResolveCommit(tip)
+		if err != nil {
+			return "", err
+		}
+		if end != head {
+			return "", fmt.Errorf("the range must end at HEAD; check out %s to reword its commits", tip)
+		}
+	}
+	if base == "" {
+		return "", fmt.Errorf("the range %s has no base", spec)
+	}
+	if _, err := git.ResolveCommit(base); err != nil {
+		return "", err
+	}
+	return base, nil
+}
+
+// rewordDiff returns the changes of a commit without the header git show prints,
+// leaving out files listed in .aigignore
+func rewordDiff(hash string) (string, error) {
+	diff, err := git.GetCommitDiff(hash)
+	if err != nil {
+		return "", err
+	}
+	if i := strings.Index(diff, "diff --git "); i >= 0 {
+		diff = diff[i:]
+	} else {
+		diff = ""
+	}
+	return filterIgnoredPaths(diff)
+}
+
+// affectedPublished returns the published commits from first onwards, all of
+// which get new hashes when first is rewritten
+func affectedPublished(commits []git.RangeCommit, first string, published map[string]bool) []string {
+	var affected []string
+	seen := false
+	for _, c := range commits {
+		seen = seen || c.Hash == first
+		if seen && published[c.Hash] {
+			affected = append(affected, c.Hash)
+		}
+	}
+	return affected
+}
+
+// showRewordPlan prints the old and new subject of each commit side by side
+func showRewordPlan(targets []git.RangeCommit, reasons, messages map[string]string) {
+	fmt.Println()
+	fmt.Printf("%-8s %-*s   %s\n", "", rewordColumn, "Current", "New")
+	for _, c := range targets {
+		message, ok := messages[c.Hash]
+		if !ok {
+			continue
+		}
+		old := c.Subject()
+		if reason := reasons[c.Hash]; reason != "" {
+			old += " (" + reason + ")"
+		}
+		newSubject := strings.SplitN(message, "\n", 2)[0]
+		fmt.Printf("%-8s %-*s → %s\n", c.Hash[:7], rewordColumn, truncateSubject(old, rewordColumn), newSubject)
+	}
+}
+
+// truncateSubject cuts s to n runes, marking the cut with an ellipsis
+func truncateSubject(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-1]) + "…"
+}
+
+// rewordPlanLineRegex matches the line that starts a commit in an edited plan
+var rewordPlanLineRegex = regexp.MustCompile(`^reword ([0-9a-f]{7,40})\s*$`)
+
+// editRewordPlan opens the new messages in the user's editor. Each starts with
+// a "reword <hash>" line; removing a commit's block keeps its old message.
+func editRewordPlan(targets []git.RangeCommit, messages map[string]string) (map[string]string, error) {
+	var b strings.Builder
+	for _, c := range targets {
+		if message, ok := messages[c.Hash]; ok {
+			fmt.Fprintf(&b, "reword %s\n# was: %s\n%s\n\n", c.Hash[:12], c.Subject(), message)
+		}
+	}
+	b.WriteString("# Each block starts with a \"reword <hash>\" line followed by the new message.\n")
+	b.WriteString("# Remove a block to keep that commit's message. Lines starting with '#' are ignored.\n")
+
+	f, err := os.CreateTemp("", "aig-reword-*.txt")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.WriteString(b.String()); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to write temp file: %w", err)
+	}
+	f.Close()
+
+	if err := runEditor(f.Name()); err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(f.Name())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read edited plan: %w", err)
+	}
+
+	edited := make(map[string]string)
+	var current string
+	var lines []string
+	flush := func() {
+		if message := strings.TrimSpace(strings.Join(lines, "\n")); current != "" && message != "" {
+			edited[current] = message
+		}
+		lines = nil
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		if m := rewordPlanLineRegex.FindStringSubmatch(strings.TrimSpace(line)); m != nil {
+			flush()
+			current = ""
+			for _, c := range targets {
+				if strings.HasPrefix(c.Hash, m[1]) {
+					current = c.Hash
+				}
+			}
+			if current == "" {
+				return nil, fmt.Errorf("%s is not one of the commits being reworded", m[1])
+			}
+			continue
+		}
+		lines = append(lines, line)
+	}
+	flush()
+
+	return edited, nil
+}
@@ -0,0 +1,249 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/tarantino19/aig/internal/ai"
+	"github.com/tarantino19/aig/internal/config"
+	"github.com/tarantino19/aig/internal/git"
+	"github.com/tarantino19/aig/internal/ui"
+)
+
+// runCommitSplit asks the provider to group the staged hunks into commits,
+// lets the user review the plan and then creates the commits in order.
+// aiDiff is the staged diff with ignored files removed; hunks of files missing
+// from it are never sent and end up in the last commit.
+func runCommitSplit(ctx context.Context, cfg *config.Config, provider ai.Provider, aiDiff string, options ai.CommitOptions, ticketKey string) error {
+	changes, err := git.GetStagedChanges()
+	if err != nil {
+		return fmt.Errorf("failed to get staged changes: %w", err)
+	}
+	if len(changes.Hunks) < 2 {
+		ui.ShowInfo("Only one hunk is staged, there is nothing to split. Run 'aig commit' without --split.")
+		return nil
+	}
+
+	allowed := make(map[string]bool)
+	for _, f := range git.ParseDiff(aiDiff) {
+		allowed[f.Path()] = true
+	}
+	var hunks []ai.SplitHunk
+	for _, h := range changes.Hunks {
+		if path := changes.Path(h); allowed[path] {
+			hunks = append(hunks, ai.SplitHunk{ID: h.ID, Path: path, Diff: changes.Diff(h)})
+		}
+	}
+	if len(hunks) == 0 {
+		return fmt.Errorf("every staged file is listed in .aigignore, nothing can be sent to plan a split")
+	}
+
+	ui.ShowInfo(fmt.Sprintf("🤖 Planning commits for %d hunks with %s...", len(changes.Hunks), strings.Title(cfg.AI.Provider)))
+	plan, err := provider.PlanCommitSplit(ctx, hunks, options)
+	if err != nil {
+		showProviderRemedy(err, cfg)
+		if errors.Is(err, ai.ErrContextTooLong) {
+			ui.ShowInfo("Stage fewer changes at a time, or commit without --split.")
+		}
+		return fmt.Errorf("failed to plan commit split: %w", err)
+	}
+
+	groups := make([]git.CommitGroup, len(plan.Commits))
+	for i, c := range plan.Commits {
+		msg := ai.ParseCommitMessage(c.Message, options.Conventional)
+		placeTicket(msg, ticketKey, cfg.Commit)
+		groups[i] = git.CommitGroup{Hunks: c.Hunks, Message: msg.FullMessage}
+	}
+
+	for {
+		groups, err = completeSplitPlan(changes, groups)
+		if err != nil && !interactive {
+			return fmt.Errorf("invalid split plan: %w", err)
+		}
+
+		if err == nil {
+			showSplitPlan(changes, groups)
+			if !interactive {
+				break
+			}
+			fmt.Printf("\nCreate these %d commits? [Y/n/e(dit)]: ", len(groups))
+		} else {
+			ui.ShowError(fmt.Errorf("invalid split plan: %w", err))
+			fmt.Print("\nEdit the plan? [Y/n]: ")
+		}
+
+		var response string
+		fmt.Scanln(&response)
+		response = strings.ToLower(strings.TrimSpace(response))
+
+		switch {
+		case err == nil && (response == "" || response == "y" || response == "yes"):
+		case response == "n" || response == "no":
+			ui.ShowInfo("Commit cancelled")
+			return nil
+		case err != nil || response == "e" || response == "edit":
+			edited, editErr := editSplitPlan(changes, groups)
+			if editErr != nil {
+				return editErr
+			}
+			if len(edited) == 0 {
+				ui.ShowInfo("Commit cancelled")
+				return nil
+			}
+			groups = edited
+			continue
+		default:
+			continue
+		}
+		break
+	}
+
+	if err := changes.CommitGroups(groups); err != nil {
+		return fmt.Errorf("failed to commit split: %w", err)
+	}
+	ui.ShowSuccess(fmt.Sprintf("Created %d commits!", len(groups)))
+
+	if push {
+		ui.ShowInfo("Pushing to remote...")
+		if err := git.Push(); err != nil {
+			ui.ShowWarning(fmt.Sprintf("Failed to push: %v", err))
+		} else {
+			ui.ShowSuccess("Pushed to remote successfully!")
+		}
+	}
+
+	return nil
+}
+
+// completeSplitPlan validates the plan and adds hunks no commit takes to the
+// last one, so that everything staged is committed
+func completeSplitPlan(changes *git.StagedChanges, groups []git.CommitGroup) ([]git.CommitGroup, error) {
+	if len(groups) == 0 {
+		return nil, fmt.Errorf("the plan has no commits")
+	}
+	ids := make([][]string, len(groups))
+	for i, g := range groups {
+		if strings.TrimSpace(g.Message) == "" {
+			return groups, fmt.Errorf("commit %d has no message", i+1)
+		}
+		ids[i] = g.Hunks
+	}
+
+	missing, err := changes.Unassigned(ids)
+	if err != nil {
+		return groups, err
+	}
+	if len(missing) > 0 {
+		ui.ShowWarning(fmt.Sprintf("%s not assigned to any commit, adding to the last one", strings.Join(missing, ", ")))
+		last := &groups[len(groups)-1]
+		last.Hunks = append(last.Hunks, missing...)
+	}
+	return groups, nil
+}
+
+// showSplitPlan prints each planned commit with the hunks it takes
+func showSplitPlan(changes *git.StagedChanges, groups []git.CommitGroup) {
+	fmt.Println()
+	fmt.Printf("Proposed %d commits:\n", len(groups))
+	for i, g := range groups {
+		msg := ai.ParseCommitMessage(g.Message, conventional)
+		header := msg.Header()
+		if msg.Type != "" && strings.HasPrefix(header, msg.Type) {
+			header = ui.GetCommitTypeStyle(msg.Type).Render(msg.Type) + header[len(msg.Type):]
+		}
+		fmt.Printf("\n%d. %s\n", i+1, header)
+		for _, id := range g.Hunks {
+			fmt.Printf("     %s\n", describeHunk(changes, id))
+		}
+	}
+}
+
+// describeHunk names a hunk by its file and location
+func describeHunk(changes *git.StagedChanges, id string) string {
+	for _, h := range changes.Hunks {
+		if h.ID != id {
+			continue
+		}
+		if h.Hunk < 0 {
+			return fmt.Sprintf("%-4s %s (whole file)", id, changes.Path(h))
+		}
+		return fmt.Sprintf("%-4s %s %s", id, changes.Path(h), changes.Files[h.File].Hunks[h.Hunk].Header)
+	}
+	return id
+}
+
+// editSplitPlan opens the plan in the user's editor. Each commit starts with a
+// "commit" line naming its hunks, followed by its message.
+func editSplitPlan(changes *git.StagedChanges, groups []git.CommitGroup) ([]git.CommitGroup, error) {
+	var b strings.Builder
+	for _, g := range groups {
+		fmt.Fprintf(&b, "commit %s\n%s\n\n", strings.Join(g.Hunks, " "), g.Message)
+	}
+	b.WriteString("# Each commit starts with a line \"commit <hunk> <hunk>...\" followed by its\n")
+	b.WriteString("# message. Commits are made from top to bottom. Lines starting with '#' are\n")
+	b.WriteString("# ignored, and removing every commit cancels the split.\n#\n# Hunks:\n")
+	for _, h := range changes.Hunks {
+		fmt.Fprintf(&b, "#   %s\n", describeHunk(changes, h.ID))
+	}
+
+	f, err := os.CreateTemp("", "aig-split-*.txt")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.WriteString(b.String()); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to write temp file: %w", err)
+	}
+	f.Close()
+
+	if err := runEditor(f.Name()); err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(f.Name())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read edited plan: %w", err)
+	}
+	return parseSplitPlanText(string(data)), nil
+}
+
+// splitPlanLineRegex matches the line that starts a commit in an edited plan
+var splitPlanLineRegex = regexp.MustCompile(`(?i)^commit((?:[\s,]+H\d+)+)\s*$`)
+
+// parseSplitPlanText reads a plan in the format written by editSplitPlan
+func parseSplitPlanText(text string) []git.CommitGroup {
+	var groups []git.CommitGroup
+	var message []string
+
+	flush := func() {
+		if len(groups) > 0 {
+			groups[len(groups)-1].Message = strings.TrimSpace(strings.Join(message, "\n"))
+		}
+		message = nil
+	}
+
+	for _, line := range strings.Split(text, "\n") {
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		if m := splitPlanLineRegex.FindStringSubmatch(strings.TrimSpace(line)); m != nil {
+			flush()
+			var ids []string
+			for _, id := range strings.FieldsFunc(m[1], func(r rune) bool { return r == ',' || r == ' ' || r == '\t' }) {
+				ids = append(ids, strings.ToUpper(id))
+			}
+			groups = append(groups, git.CommitGroup{Hunks: ids})
+			continue
+		}
+		message = append(message, line)
+	}
+	flush()
+
+	return groups
+}
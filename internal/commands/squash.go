@@ -0,0 +1,224 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/tarantino19/aig/internal/ai"
+	"github.com/tarantino19/aig/internal/config"
+	"github.com/tarantino19/aig/internal/git"
+	"github.com/tarantino19/aig/internal/ui"
+)
+
+var (
+	squashOnto  string
+	squashApply bool
+	squashForce bool
+	squashYes   bool
+)
+
+// NewSquashCmd creates the squash command
+func NewSquashCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "squash",
+		Short: "Generate one commit message for squashing the current branch",
+		Long: `Writes a single conventional commit message for the commits of the current
+branch since it forked from the target branch, from their subjects and the
+combined diff. BREAKING CHANGE notes, issue references and co-authors of all the
+commits are carried over as footers, and other authors become co-authors.
+
+The message is printed for use in a squash merge. With --apply the branch is
+squashed locally into one commit on top of the merge base; the working tree is
+not touched and the old tip is kept under refs/aig/backup/.`,
+		Args: cobra.NoArgs,
+		RunE: runSquash,
+	}
+
+	cmd.Flags().StringVar(&squashOnto, "onto", "", "Branch the squashed commit will land on, defaults to git.default_branch")
+	cmd.Flags().BoolVar(&squashApply, "apply", false, "Squash the branch into one commit locally")
+	cmd.Flags().BoolVar(&squashForce, "force", false, "Squash even commits that are already on protected or upstream branches")
+	cmd.Flags().BoolVarP(&squashYes, "yes", "y", false, "Squash without asking for confirmation")
+
+	addCacheFlag(cmd)
+	addProfileFlag(cmd)
+	addAIFlags(cmd, "commit")
+
+	return cmd
+}
+
+func runSquash(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	onto := squashOnto
+	if onto == "" {
+		onto = cfg.Git.DefaultBranch
+	}
+
+	branch, err := git.GetCurrentBranch()
+	if err != nil {
+		return fmt.Errorf("failed to get current branch: %w", err)
+	}
+	if branch == onto {
+		return fmt.Errorf("current branch (%s) is the branch to squash onto; check out the branch to squash or pass --onto", branch)
+	}
+
+	base, err := git.GetMergeBase(onto)
+	if err != nil {
+		return err
+	}
+
+	// Subjects for the prompt, newest first, and full messages for the footers
+	commits, err := git.GetCommits(git.CommitOptions{Branch: base + "..HEAD", NoMerges: true})
+	if err != nil {
+		return fmt.Errorf("failed to get branch commits: %w", err)
+	}
+	if len(commits) == 0 {
+		ui.ShowInfo(fmt.Sprintf("No commits on %s since it forked from %s", branch, onto))
+		return nil
+	}
+	rangeCommits, err := git.GetRangeCommits(base)
+	if err != nil {
+		return err
+	}
+
+	if squashApply {
+		if err := checkSquashable(cfg, branch, rangeCommits); err != nil {
+			return err
+		}
+	}
+
+	diff, err := git.GetCommitRangeDiff(base + "..HEAD")
+	if err != nil {
+		return fmt.Errorf("failed to get branch diff: %w", err)
+	}
+	diff, err = filterIgnoredPaths(diff)
+	if err != nil {
+		return fmt.Errorf("failed to apply ignore file: %w", err)
+	}
+	if diff == "" {
+		ui.ShowWarning(fmt.Sprintf("The commits on %s add up to no changes (or only ignored files), nothing to describe", branch))
+		return nil
+	}
+
+	branchRules, err := git.ParseBranchRules(cfg.Commit.BranchTypes)
+	if err != nil {
+		return fmt.Errorf("invalid commit.branch_types: %w", err)
+	}
+	matcher, err := ticketMatcher(cfg)
+	if err != nil {
+		return err
+	}
+	ticketKey := matcher.Find(branch)
+
+	subjects := make([]string, 0, len(commits))
+	for i := len(commits) - 1; i >= 0; i-- {
+		subjects = append(subjects, commits[i].Message)
+	}
+	options := ai.CommitOptions{
+		TypeHint:     git.MatchBranchType(branch, branchRules),
+		Conventional: true,
+		Types:        cfg.Commit.Types,
+		Scopes:       cfg.Commit.Scopes,
+		Squashed:     subjects,
+	}
+
+	provider, err := newAIProvider(cfg, "squash")
+	if err != nil {
+		return err
+	}
+	defer provider.Close()
+
+	ctx, cancel := aiContext(cmd.Context(), cfg)
+	defer cancel()
+
+	ui.ShowInfo(fmt.Sprintf("🤖 Writing one message for %d commits with %s...", len(commits), strings.Title(cfg.AI.Provider)))
+	msg, err := provider.GenerateCommitMessage(ctx, diff, options)
+	if errors.Is(err, ai.ErrContextTooLong) {
+		msg, err = provider.GenerateCommitMessage(ctx, trimForContext(diff), options)
+	}
+	if err != nil {
+		showProviderRemedy(err, cfg)
+		return fmt.Errorf("failed to generate squash message: %w", err)
+	}
+
+	placeTicket(msg, ticketKey, cfg.Commit)
+	message := git.AppendTrailers(msg.FullMessage, git.SquashFooters(rangeCommits, git.GetUserEmail()))
+
+	fmt.Printf("\nSquash message for %d commits of %s onto %s:\n\n%s\n", len(commits), branch, onto, message)
+	if !squashApply {
+		ui.ShowInfo(fmt.Sprintf("💡 Tip: Use 'aig squash --apply --onto %s' to squash the branch locally", onto))
+		return nil
+	}
+
+	if !squashYes {
+		fmt.Printf("\nSquash %d commits into one? [y/N/e(dit)]: ", len(rangeCommits))
+		var response string
+		fmt.Scanln(&response)
+		switch strings.ToLower(strings.TrimSpace(response)) {
+		case "y", "yes":
+		case "e", "edit":
+			edited, err := editCommitMessage(&ai.CommitMessage{FullMessage: message}, true)
+			if err != nil {
+				return err
+			}
+			if edited == nil {
+				ui.ShowInfo("Squash cancelled")
+				return nil
+			}
+			message = edited.FullMessage
+		default:
+			ui.ShowInfo("Squash cancelled")
+			return nil
+		}
+	}
+
+	oldTip := rangeCommits[len(rangeCommits)-1].Hash
+	newTip, err := git.CreateSquashCommit(base, message)
+	if err != nil {
+		return fmt.Errorf("failed to create squashed commit: %w", err)
+	}
+
+	backup, err := git.CreateBackupRef(branch)
+	if err != nil {
+		return err
+	}
+	if err := git.UpdateBranch(branch, newTip, oldTip, "aig squash"); err != nil {
+		return err
+	}
+
+	ui.ShowSuccess(fmt.Sprintf("Squashed %d commits on %s into %s", len(rangeCommits), branch, shortHash(newTip)))
+	ui.ShowInfo(fmt.Sprintf("The old branch is kept as %s; restore it with: git reset --keep %s", backup, backup))
+	return nil
+}
+
+// checkSquashable refuses to squash a protected branch or commits that are
+// already published, unless --force is given
+func checkSquashable(cfg *config.Config, branch string, commits []git.RangeCommit) error {
+	if branch == "" || branch == "HEAD" {
+		return fmt.Errorf("--apply needs a checked out branch, HEAD is detached")
+	}
+	if squashForce {
+		return nil
+	}
+	if git.IsProtectedBranch(branch, cfg.Git.ProtectedBranches) {
+		return fmt.Errorf("%s is a protected branch (git.protected_branches), use --force to squash it anyway", branch)
+	}
+
+	refs, err := git.PublishedRefs(cfg.Git.ProtectedBranches)
+	if err != nil {
+		return err
+	}
+	published, err := git.PublishedCommits(commits, refs)
+	if err != nil {
+		return err
+	}
+	if len(published) > 0 {
+		return fmt.Errorf("%d of the commits to squash are already on a protected or upstream branch; use --force to squash them anyway", len(published))
+	}
+	return nil
+}
@@ -38,6 +38,9 @@ release notes, or changelog entries.`,
 	cmd.Flags().BoolVarP(&summaryGroup, "group", "g", false, "Group by commit type")
 	cmd.Flags().BoolVar(&summaryChangelog, "changelog", false, "Generate changelog format")
 
+	addProfileFlag(cmd)
+	addAIFlags(cmd, "summary")
+
 	return cmd
 }
 
@@ -66,7 +69,7 @@ func runSummary(cmd *cobra.Command, args []string) error {
 	ui.ShowInfo(fmt.Sprintf("Found %d commits to summarize", len(commits)))
 	
 	// This will be implemented when we complete the AI integration
-	fmt.Printf("Configuration loaded: %+v\n", cfg)
+	fmt.Printf("Configuration loaded: provider=%s model=%s\n", cfg.AI.Provider, cfg.AI.Model)
 	fmt.Printf("Output format: %s\n", summaryOutput)
 	
 	return fmt.Errorf("AI integration not yet implemented")
@@ -0,0 +1,64 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tarantino19/aig/internal/ai"
+	"github.com/tarantino19/aig/internal/config"
+	"github.com/tarantino19/aig/internal/tickets"
+)
+
+// ticketMatcher returns the matcher for the configured ticket patterns. The
+// older commit.ticket_pattern is tried before them.
+func ticketMatcher(cfg *config.Config) (*tickets.Matcher, error) {
+	patterns := cfg.Tickets.Patterns
+	if len(patterns) == 0 {
+		patterns = tickets.DefaultPatterns
+	}
+	if cfg.Commit.TicketPattern != "" {
+		patterns = append([]string{cfg.Commit.TicketPattern}, patterns...)
+	}
+
+	m, err := tickets.New(patterns...)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ticket pattern in config: %w", err)
+	}
+	return m, nil
+}
+
+// placeTicket adds the ticket key to the commit message where commit.ticket_placement
+// says, unless the message already mentions it
+func placeTicket(msg *ai.CommitMessage, key string, commit config.CommitConfig) {
+	if key == "" || strings.Contains(msg.FullMessage, key) {
+		return
+	}
+
+	placement := commit.TicketPlacement
+	if placement == "scope" && msg.Type == "" {
+		// A message without a type has no scope to put the key in
+		placement = "prefix"
+	}
+
+	switch placement {
+	case "none":
+		return
+	case "scope":
+		msg.Scope = key
+	case "trailer":
+		trailer := fmt.Sprintf("%s: %s", commit.TicketTrailer, tickets.Reference(key))
+		if msg.Footer != "" {
+			msg.Footer += "\n" + trailer
+		} else {
+			msg.Footer = trailer
+		}
+	default:
+		format := commit.TicketFormat
+		if format == "" {
+			format = "{ticket}"
+		}
+		msg.Subject = tickets.Format(format, key) + " " + msg.Subject
+	}
+
+	msg.FullMessage = msg.Format()
+}
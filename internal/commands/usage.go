@@ -0,0 +1,221 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/tarantino19/aig/internal/ai"
+	"github.com/tarantino19/aig/internal/config"
+	"github.com/tarantino19/aig/internal/git"
+	"github.com/tarantino19/aig/internal/ui"
+	"github.com/tarantino19/aig/internal/usage"
+)
+
+var (
+	usageSince string
+	usageBy    string
+	usageJSON  bool
+)
+
+// NewUsageCmd creates the usage command
+func NewUsageCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "usage",
+		Short: "Report token usage and cost of AI requests",
+		Long: `Every request sent to an AI provider records its prompt and completion tokens
+and its cost, priced with the built-in table and usage.prices. The report
+covers the current month unless --since is given.
+
+Set usage.monthly_budget to be warned, or with usage.budget_action block, once
+the month's spend reaches the budget.`,
+		Example: `  aig usage
+  aig usage --since 7d --by model
+  aig usage --since 2025-01-01 --by repo --json`,
+		Args: cobra.NoArgs,
+		RunE: runUsage,
+	}
+
+	cmd.Flags().StringVar(&usageSince, "since", "", "Only requests newer than this, e.g. 24h, 7d or 2025-01-31 (default: this month)")
+	cmd.Flags().StringVar(&usageBy, "by", "command", "Group by command, model, provider, repo, user, day or month")
+	cmd.Flags().BoolVar(&usageJSON, "json", false, "Print the report as JSON")
+
+	return cmd
+}
+
+func runUsage(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	since := usage.MonthStart(time.Now())
+	if usageSince != "" {
+		if since, err = parseSince(usageSince); err != nil {
+			return err
+		}
+	}
+
+	store, err := usageStore(cfg)
+	if err != nil {
+		return err
+	}
+	records, err := store.Since(since)
+	if err != nil {
+		return err
+	}
+
+	rows, err := usage.Group(records, usageBy)
+	if err != nil {
+		return err
+	}
+	total := usage.Total(records)
+
+	if usageJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(struct {
+			Since time.Time   `json:"since"`
+			By    string      `json:"by"`
+			Rows  []usage.Row `json:"rows"`
+			Total usage.Row   `json:"total"`
+		}{since, usageBy, rows, total})
+	}
+
+	if !cfg.Usage.Enabled {
+		ui.ShowInfo("Usage tracking is disabled, enable it with 'aig config set usage.enabled true'")
+	}
+	if len(records) == 0 {
+		ui.ShowInfo(fmt.Sprintf("No AI requests since %s", since.Format("2006-01-02 15:04")))
+		return nil
+	}
+
+	fmt.Printf("AI usage since %s\n\n", since.Format("2006-01-02 15:04"))
+	fmt.Printf("%-32s %9s %12s %12s %10s\n", usageBy, "requests", "prompt", "completion", "cost")
+	for _, row := range rows {
+		printUsageRow(row.Key, row)
+	}
+	printUsageRow("total", total)
+
+	if total.Estimated {
+		fmt.Println("\n~ some token counts are estimates because the provider reported none")
+	}
+	if total.Unpriced {
+		fmt.Println("* some models have no price, add them to usage.prices")
+	}
+
+	if budget := cfg.Usage.MonthlyBudget; budget > 0 {
+		spent, err := monthToDateCost(store)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("\nMonthly budget: $%.2f of $%.2f spent (%.0f%%)\n", spent, budget, spent/budget*100)
+	}
+
+	return nil
+}
+
+func printUsageRow(key string, row usage.Row) {
+	marks := ""
+	if row.Estimated {
+		marks += "~"
+	}
+	if row.Unpriced {
+		marks += "*"
+	}
+	if len(key) > 32 {
+		key = "..." + key[len(key)-29:]
+	}
+	fmt.Printf("%-32s %9d %12d %12d %10s%s\n", key, row.Requests, row.PromptTokens, row.CompletionTokens,
+		fmt.Sprintf("$%.4f", row.Cost), marks)
+}
+
+// usageStore opens the configured usage log
+func usageStore(cfg *config.Config) (*usage.Store, error) {
+	path, err := dataFilePath(cfg.Usage.Path, usage.FileName)
+	if err != nil {
+		return nil, err
+	}
+	return usage.Open(path), nil
+}
+
+// monthToDateCost returns the dollars spent since the start of the month
+func monthToDateCost(store *usage.Store) (float64, error) {
+	records, err := store.Since(usage.MonthStart(time.Now()))
+	if err != nil {
+		return 0, err
+	}
+	return usage.Total(records).Cost, nil
+}
+
+// checkBudget warns or fails once the month's spend reaches the budget
+func checkBudget(cfg *config.Config, store *usage.Store) error {
+	budget := cfg.Usage.MonthlyBudget
+	if budget <= 0 {
+		return nil
+	}
+
+	spent, err := monthToDateCost(store)
+	if err != nil {
+		// A broken usage log should not stop work
+		ui.ShowWarning(fmt.Sprintf("Could not check the monthly budget: %v", err))
+		return nil
+	}
+
+	switch {
+	case spent >= budget && cfg.Usage.BudgetAction == "block":
+		return fmt.Errorf("monthly AI budget of $%.2f is spent ($%.2f this month); raise usage.monthly_budget or set usage.budget_action to warn", budget, spent)
+	case spent >= budget:
+		ui.ShowWarning(fmt.Sprintf("Monthly AI budget of $%.2f is spent ($%.2f this month)", budget, spent))
+	case spent >= budget*0.8:
+		ui.ShowWarning(fmt.Sprintf("$%.2f of the $%.2f monthly AI budget is spent", spent, budget))
+	}
+	return nil
+}
+
+// usageHook checks the budget and returns a call hook recording the usage of
+// every request
+func usageHook(cfg *config.Config, command string) (ai.CallHook, error) {
+	prices, err := usage.ParsePrices(cfg.Usage.Prices)
+	if err != nil {
+		return nil, fmt.Errorf("invalid usage config: %w", err)
+	}
+	store, err := usageStore(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkBudget(cfg, store); err != nil {
+		return nil, err
+	}
+
+	repo, _ := git.GetRepoRoot()
+	user := git.GetUserEmail()
+
+	return func(ctx context.Context, call *ai.Call) {
+		if call.Err != nil {
+			// Failed requests are not billed
+			return
+		}
+		cost, priced := prices.Cost(call.Model, call.Usage.PromptTokens, call.Usage.CompletionTokens)
+		record := usage.Record{
+			Time:             call.Started.UTC(),
+			User:             user,
+			Command:          command,
+			Kind:             call.Kind,
+			Repo:             repo,
+			Provider:         call.Provider,
+			Model:            call.Model,
+			PromptTokens:     call.Usage.PromptTokens,
+			CompletionTokens: call.Usage.CompletionTokens,
+			Estimated:        call.Usage.Estimated,
+			Cost:             cost,
+			Unpriced:         !priced,
+		}
+		if err := store.Add(record); err != nil {
+			ui.ShowWarning(fmt.Sprintf("Could not record token usage: %v", err))
+		}
+	}, nil
+}
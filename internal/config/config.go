@@ -1,12 +1,20 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/spf13/viper"
+	"github.com/tarantino19/aig/internal/credentials"
+	"github.com/tarantino19/aig/internal/git"
+	"github.com/tarantino19/aig/internal/redact"
+	"github.com/tarantino19/aig/internal/secrets"
+	"github.com/tarantino19/aig/internal/tickets"
 )
 
 // Config holds the application configuration
@@ -15,15 +23,89 @@ type Config struct {
 	Git     GitConfig     `mapstructure:"git"`
 	UI      UIConfig      `mapstructure:"ui"`
 	Review  ReviewConfig  `mapstructure:"review"`
+	Cache   CacheConfig   `mapstructure:"cache"`
+	Commit  CommitConfig  `mapstructure:"commit"`
+	PR      PRConfig      `mapstructure:"pr"`
+	Tickets TicketsConfig `mapstructure:"tickets"`
+	Redact  RedactConfig  `mapstructure:"redact"`
+	Secrets SecretsConfig `mapstructure:"secrets"`
+	Audit   AuditConfig   `mapstructure:"audit"`
+	Usage   UsageConfig   `mapstructure:"usage"`
+
+	Profile  string                   `mapstructure:"profile"`
+	Profiles map[string]ProfileConfig `mapstructure:"profiles"`
+
+	// TrustedRepos are repository roots whose .aig.yaml may use ${cmd:...} and
+	// ${file:...} references. Only the global config file can set it.
+	TrustedRepos []string `mapstructure:"trusted_repos"`
+}
+
+// ProfileConfig is a named set of overrides. Only the keys it sets replace the
+// values from the config files.
+type ProfileConfig struct {
+	AI     AIConfig     `mapstructure:"ai"`
+	Review ReviewConfig `mapstructure:"review"`
+	UI     UIConfig     `mapstructure:"ui"`
 }
 
 // AIConfig holds AI provider settings
 type AIConfig struct {
-	Provider    string  `mapstructure:"provider"`
-	APIKey      string  `mapstructure:"api_key"`
-	Model       string  `mapstructure:"model"`
-	Temperature float64 `mapstructure:"temperature"`
-	MaxTokens   int     `mapstructure:"max_tokens"`
+	Provider    string        `mapstructure:"provider"`
+	APIKey      string        `mapstructure:"api_key"`
+	Model       string        `mapstructure:"model"`
+	Temperature float64       `mapstructure:"temperature"`
+	MaxTokens   int           `mapstructure:"max_tokens"`
+	Timeout     time.Duration `mapstructure:"timeout"` // per command run, 0 for no limit
+
+	// Commands holds per-command overrides, merged into the fields above by Load
+	Commands AICommandsConfig `mapstructure:"commands"`
+
+	Retry     RetryConfig     `mapstructure:"retry"`
+	RateLimit RateLimitConfig `mapstructure:"rate_limit"`
+	Replay    ReplayConfig    `mapstructure:"replay"`
+}
+
+// AICommandsConfig holds the ai overrides of each command that calls the provider
+type AICommandsConfig struct {
+	Commit  CommandAIConfig `mapstructure:"commit"`
+	Review  CommandAIConfig `mapstructure:"review"`
+	PR      CommandAIConfig `mapstructure:"pr"`
+	Summary CommandAIConfig `mapstructure:"summary"`
+}
+
+// CommandAIConfig overrides the ai settings for one command. Only the keys that
+// are set replace the values of the ai section.
+type CommandAIConfig struct {
+	Provider    string        `mapstructure:"provider"`
+	Model       string        `mapstructure:"model"`
+	Temperature float64       `mapstructure:"temperature"`
+	MaxTokens   int           `mapstructure:"max_tokens"`
+	Timeout     time.Duration `mapstructure:"timeout"`
+}
+
+// NeedsAPIKey reports whether requests reach a real provider
+func (c AIConfig) NeedsAPIKey() bool {
+	return c.Provider != "replay" || c.Replay.Mode == "record"
+}
+
+// ReplayConfig holds the settings of the replay provider, selected with provider: replay
+type ReplayConfig struct {
+	Mode     string `mapstructure:"mode"`     // replay, record or static
+	Dir      string `mapstructure:"dir"`      // fixture directory
+	Provider string `mapstructure:"provider"` // the real provider used in record mode
+}
+
+// RetryConfig holds how failed requests to the AI provider are retried
+type RetryConfig struct {
+	MaxAttempts  int           `mapstructure:"max_attempts"` // including the first, 1 disables retries
+	InitialDelay time.Duration `mapstructure:"initial_delay"`
+	MaxDelay     time.Duration `mapstructure:"max_delay"`
+}
+
+// RateLimitConfig holds the client-side limit on requests to the AI provider
+type RateLimitConfig struct {
+	RequestsPerMinute float64 `mapstructure:"requests_per_minute"` // 0 for no limit
+	Burst             int     `mapstructure:"burst"`
 }
 
 // GitConfig holds git-related settings
@@ -31,6 +113,9 @@ type GitConfig struct {
 	AutoStage      bool   `mapstructure:"auto_stage"`
 	DefaultBranch  string `mapstructure:"default_branch"`
 	CommitTemplate string `mapstructure:"commit_template"`
+
+	// ProtectedBranches are branch name patterns whose history aig never rewrites
+	ProtectedBranches []string `mapstructure:"protected_branches"`
 }
 
 // UIConfig holds UI settings
@@ -46,36 +131,106 @@ type ReviewConfig struct {
 	IncludePatterns []string `mapstructure:"include_patterns"`
 	ExcludePatterns []string `mapstructure:"exclude_patterns"`
 	FocusAreas      []string `mapstructure:"focus_areas"`
+	HunkCache       bool     `mapstructure:"hunk_cache"` // reuse findings per diff hunk
 }
 
-// Load loads the configuration from file and environment
+// CommitConfig holds commit message settings
+type CommitConfig struct {
+	Types         []string `mapstructure:"types"`
+	Scopes        []string `mapstructure:"scopes"`
+	BranchTypes   []string `mapstructure:"branch_types"` // pattern=type rules, first match wins
+	TicketPattern string   `mapstructure:"ticket_pattern"`
+
+	// Where the ticket key of the branch goes: prefix, scope, trailer or none
+	TicketPlacement string `mapstructure:"ticket_placement"`
+	TicketFormat    string `mapstructure:"ticket_format"`
+	TicketTrailer   string `mapstructure:"ticket_trailer"`
+
+	// LearnStyle shows the model recent commits as examples of the house style
+	LearnStyle    bool `mapstructure:"learn_style"`
+	StyleExamples int  `mapstructure:"style_examples"`
+	StyleSample   int  `mapstructure:"style_sample"`
+}
+
+// PRConfig holds pull request settings
+type PRConfig struct {
+	Platform string `mapstructure:"platform"`
+}
+
+// TicketsConfig holds the ticket key patterns shared by commit and pr
+type TicketsConfig struct {
+	Patterns []string `mapstructure:"patterns"`
+}
+
+// RedactConfig holds the settings for removing secrets from requests
+type RedactConfig struct {
+	Enabled   bool     `mapstructure:"enabled"`
+	Detectors []string `mapstructure:"detectors"`
+	Patterns  []string `mapstructure:"patterns"`   // extra regexps; a capture group limits what is replaced
+	DenyPaths []string `mapstructure:"deny_paths"` // files that are never sent, in .gitignore syntax
+}
+
+// SecretsConfig holds the settings of the secret scan that blocks commits
+type SecretsConfig struct {
+	Enabled   bool     `mapstructure:"enabled"`
+	Detectors []string `mapstructure:"detectors"`
+	Patterns  []string `mapstructure:"patterns"`
+}
+
+// AuditConfig holds the settings of the log of requests sent to AI providers
+type AuditConfig struct {
+	Enabled      bool   `mapstructure:"enabled"`
+	Path         string `mapstructure:"path"`          // ~/.config/aig/audit.jsonl when empty
+	StorePrompts bool   `mapstructure:"store_prompts"` // also record the full prompt and response
+}
+
+// UsageConfig holds the settings of token usage and cost tracking
+type UsageConfig struct {
+	Enabled       bool     `mapstructure:"enabled"`
+	Path          string   `mapstructure:"path"`           // ~/.config/aig/usage.jsonl when empty
+	Prices        []string `mapstructure:"prices"`         // model=input/output in dollars per million tokens
+	MonthlyBudget float64  `mapstructure:"monthly_budget"` // US dollars, 0 for no budget
+	BudgetAction  string   `mapstructure:"budget_action"`  // warn or block once the budget is spent
+}
+
+// CacheConfig holds settings for the AI response cache
+type CacheConfig struct {
+	Enabled   bool          `mapstructure:"enabled"`
+	TTL       time.Duration `mapstructure:"ttl"`
+	MaxSizeMB int           `mapstructure:"max_size_mb"`
+}
+
+// Load loads the configuration from every source and merges them with the precedence
+// flags > env > profile > repo-private > repo > global > defaults. The API key is
+// also looked up in the credential stores, see resolveAPIKey.
 func Load() (*Config, error) {
 	// Load .env file if it exists
 	_ = godotenv.Load()
 
 	// Set default config path
-	configDir, err := getConfigDir()
+	configPath, err := GlobalConfigPath()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get config directory: %w", err)
 	}
-
-	configPath := filepath.Join(configDir, "config.yaml")
 	
 	// Initialize viper
 	viper.SetConfigFile(configPath)
 	viper.SetConfigType("yaml")
+	origins = make(map[string]Origin)
 	
 	// Set defaults
 	setDefaults()
+	recordDefaultOrigins()
 	
-	// Enable environment variable support
-	viper.AutomaticEnv()
+	// Enable environment variable support, AIG_AI_MODEL maps to ai.model
 	viper.SetEnvPrefix("AIG")
+	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	viper.AutomaticEnv()
 	
 	// Try to read config file
 	if err := viper.ReadInConfig(); err != nil {
 		// Create default config if it doesn't exist
-		if _, ok := err.(viper.ConfigFileNotFoundError); ok {
+		if _, ok := err.(viper.ConfigFileNotFoundError); ok || os.IsNotExist(err) {
 			if err := createDefaultConfig(configPath); err != nil {
 				return nil, fmt.Errorf("failed to create default config: %w", err)
 			}
@@ -87,53 +242,182 @@ func Load() (*Config, error) {
 			return nil, fmt.Errorf("failed to read config: %w", err)
 		}
 	}
+	global, err := readConfigFile(configPath)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range flattenKeys(global, "") {
+		origins[key] = Origin{Kind: OriginGlobal, Source: configPath}
+	}
+
+	// Merge the repository layers over the global file. Unless the repository is
+	// trusted they cannot change the compliance settings.
+	trusted := isTrustedRepo(global)
+	ignored = nil
+	for _, layer := range []struct {
+		kind string
+		path string
+	}{
+		{OriginRepo, RepoConfigPath()},
+		{OriginPrivate, PrivateConfigPath()},
+	} {
+		values, err := readConfigFile(layer.path)
+		if err != nil {
+			return nil, err
+		}
+		if !trusted {
+			dropGuardedKeys(values, Origin{Kind: layer.kind, Source: layer.path})
+		}
+		if err := mergeLayer(values, Origin{Kind: layer.kind, Source: layer.path}); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := applyProfile(); err != nil {
+		return nil, err
+	}
+
+	recordEnvOrigins()
+	applyOverrides()
 	
 	// Unmarshal config
 	var cfg Config
 	if err := viper.Unmarshal(&cfg); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
 	}
-	
-	// Override provider from environment if set
-	if provider := os.Getenv("AIG_AI_PROVIDER"); provider != "" {
-		cfg.AI.Provider = provider
+
+	// Expand ${...} references in string values
+	if err := expandConfig(&cfg, trusted); err != nil {
+		return nil, fmt.Errorf("failed to expand config: %w", err)
 	}
 	
-	// Override model from environment if set
-	if model := os.Getenv("AIG_AI_MODEL"); model != "" {
-		cfg.AI.Model = model
+	applyCommandSection(&cfg)
+
+	// Resolve the API key in the order env > keyring > encrypted file > config files
+	keyProvider := cfg.AI.Provider
+	if keyProvider == "replay" {
+		keyProvider = cfg.AI.Replay.Provider
 	}
-	
-	// Override API key from environment based on provider
-	switch cfg.AI.Provider {
+	switch keyProvider {
 	case "openai":
-		if apiKey := os.Getenv("AIG_OPENAI_API_KEY"); apiKey != "" {
-			cfg.AI.APIKey = apiKey
-		} else if apiKey := os.Getenv("OPENAI_API_KEY"); apiKey != "" {
-			cfg.AI.APIKey = apiKey
-		}
+		cfg.AI.APIKey, err = resolveAPIKey(keyProvider, cfg.AI.APIKey, trusted, "AIG_OPENAI_API_KEY", "OPENAI_API_KEY")
 	case "gemini":
-		if apiKey := os.Getenv("AIG_GEMINI_API_KEY"); apiKey != "" {
-			cfg.AI.APIKey = apiKey
-		} else if apiKey := os.Getenv("GEMINI_API_KEY"); apiKey != "" {
-			cfg.AI.APIKey = apiKey
-		}
+		cfg.AI.APIKey, err = resolveAPIKey(keyProvider, cfg.AI.APIKey, trusted, "AIG_GEMINI_API_KEY", "GEMINI_API_KEY")
+	default:
+		cfg.AI.APIKey, err = resolveAPIKey(keyProvider, cfg.AI.APIKey, trusted)
+	}
+	if err != nil {
+		return nil, err
 	}
 	
 	return &cfg, nil
 }
 
+// resolveAPIKey returns the API key for provider from the first source that has one:
+// AIG_AI_API_KEY, the provider-specific environment variables, the credential stores
+// and finally the configured value. References in the configured value are only
+// expanded when it is used, so ${cmd:...} does not run needlessly.
+func resolveAPIKey(provider, configured string, trusted bool, envNames ...string) (string, error) {
+	if _, ok := os.LookupEnv(envVarName("ai.api_key")); ok {
+		return configured, nil
+	}
+	for _, name := range envNames {
+		if apiKey := os.Getenv(name); apiKey != "" {
+			origins["ai.api_key"] = Origin{Kind: OriginEnv, Source: name}
+			return apiKey, nil
+		}
+	}
+
+	apiKey, store, err := credentials.Lookup(provider)
+	if err == nil {
+		origins["ai.api_key"] = Origin{Kind: OriginStore, Source: store}
+		return apiKey, nil
+	}
+	if !errors.Is(err, credentials.ErrNotFound) {
+		return "", err
+	}
+
+	return expandAPIKey(configured, allowsLocalReferences(referenceOrigin("ai.api_key"), trusted))
+}
+
+// defaultModels are used when a command or flag switches the provider without
+// naming a model
+var defaultModels = map[string]string{
+	"openai": "gpt-4o-mini",
+	"gemini": "gemini-1.5-flash",
+}
+
+// applyCommandSection merges the ai.commands section of the selected command
+// over the ai settings. A section value only wins over the ai value when it
+// comes from the same or a higher precedence source, so that for example
+// AIG_AI_TEMPERATURE beats a built-in ai.commands.review.temperature.
+func applyCommandSection(cfg *Config) {
+	var section CommandAIConfig
+	switch selectedCommand {
+	case "commit":
+		section = cfg.AI.Commands.Commit
+	case "review":
+		section = cfg.AI.Commands.Review
+	case "pr":
+		section = cfg.AI.Commands.PR
+	case "summary":
+		section = cfg.AI.Commands.Summary
+	default:
+		return
+	}
+
+	prefix := "ai.commands." + selectedCommand + "."
+	apply := func(key string, set bool, assign func()) bool {
+		if !set || !viper.IsSet(prefix+key) || originRank(OriginOf(prefix+key)) < originRank(OriginOf("ai."+key)) {
+			return false
+		}
+		assign()
+		origins["ai."+key] = OriginOf(prefix + key)
+		return true
+	}
+
+	providerChanged := apply("provider", section.Provider != "", func() { cfg.AI.Provider = section.Provider })
+	apply("model", section.Model != "", func() { cfg.AI.Model = section.Model })
+	apply("temperature", true, func() { cfg.AI.Temperature = section.Temperature })
+	apply("max_tokens", section.MaxTokens > 0, func() { cfg.AI.MaxTokens = section.MaxTokens })
+	apply("timeout", true, func() { cfg.AI.Timeout = section.Timeout })
+
+	if origins["ai.provider"].Kind == OriginFlag {
+		providerChanged = true
+	}
+	// A model of the previous provider would only fail at the API
+	if providerChanged && ValidateModel(cfg.AI.Provider, cfg.AI.Model) != nil && origins["ai.model"].Kind != OriginFlag {
+		if model, ok := defaultModels[cfg.AI.Provider]; ok {
+			cfg.AI.Model = model
+			origins["ai.model"] = Origin{Kind: OriginDefault}
+		}
+	}
+}
+
 func setDefaults() {
 	// AI defaults - now defaulting to OpenAI
 	viper.SetDefault("ai.provider", "openai")
 	viper.SetDefault("ai.model", "gpt-4o-mini")
 	viper.SetDefault("ai.temperature", 0.7)
 	viper.SetDefault("ai.max_tokens", 2000)
+	viper.SetDefault("ai.timeout", "0s")
+	viper.SetDefault("ai.commands.commit.timeout", "30s")
+	viper.SetDefault("ai.commands.review.temperature", 0.2)
+	viper.SetDefault("ai.commands.pr.timeout", "60s")
+	viper.SetDefault("ai.retry.max_attempts", 4)
+	viper.SetDefault("ai.retry.initial_delay", "1s")
+	viper.SetDefault("ai.retry.max_delay", "30s")
+	viper.SetDefault("ai.rate_limit.requests_per_minute", 0)
+	viper.SetDefault("ai.rate_limit.burst", 1)
+	viper.SetDefault("ai.replay.mode", "replay")
+	viper.SetDefault("ai.replay.dir", ".aig/replay")
+	viper.SetDefault("ai.replay.provider", "openai")
 	
 	// Git defaults
 	viper.SetDefault("git.auto_stage", false)
 	viper.SetDefault("git.default_branch", "main")
 	viper.SetDefault("git.commit_template", "conventional")
+	viper.SetDefault("git.protected_branches", []string{"main", "master", "develop", "release/*"})
 	
 	// UI defaults
 	viper.SetDefault("ui.theme", "dark")
@@ -145,6 +429,55 @@ func setDefaults() {
 	viper.SetDefault("review.include_patterns", []string{"*.go", "*.js", "*.py"})
 	viper.SetDefault("review.exclude_patterns", []string{"*_test.go", "vendor/*"})
 	viper.SetDefault("review.focus_areas", []string{"security", "performance", "best_practices"})
+	viper.SetDefault("review.hunk_cache", true)
+	
+	// Cache defaults
+	viper.SetDefault("cache.enabled", true)
+	viper.SetDefault("cache.ttl", "168h")
+	viper.SetDefault("cache.max_size_mb", 100)
+	
+	// Commit defaults
+	viper.SetDefault("commit.types", []string{"feat", "fix", "docs", "style", "refactor", "test", "chore", "perf", "ci", "build"})
+	viper.SetDefault("commit.scopes", []string{})
+	viper.SetDefault("commit.branch_types", append([]string(nil), git.DefaultBranchRules...))
+	viper.SetDefault("commit.ticket_pattern", "")
+	viper.SetDefault("commit.ticket_placement", "prefix")
+	viper.SetDefault("commit.ticket_format", "{ticket}")
+	viper.SetDefault("commit.ticket_trailer", "Refs")
+	viper.SetDefault("commit.learn_style", false)
+	viper.SetDefault("commit.style_examples", 5)
+	viper.SetDefault("commit.style_sample", 100)
+	
+	// Ticket defaults
+	viper.SetDefault("tickets.patterns", append([]string(nil), tickets.DefaultPatterns...))
+	
+	// Redaction defaults
+	viper.SetDefault("redact.enabled", true)
+	viper.SetDefault("redact.detectors", append([]string(nil), redact.DefaultDetectors...))
+	viper.SetDefault("redact.patterns", []string{})
+	viper.SetDefault("redact.deny_paths", append([]string(nil), redact.DefaultDenyPaths...))
+	
+	// Secret scan defaults
+	viper.SetDefault("secrets.enabled", true)
+	viper.SetDefault("secrets.detectors", append([]string(nil), secrets.DefaultDetectors...))
+	viper.SetDefault("secrets.patterns", []string{})
+	
+	// Audit defaults
+	viper.SetDefault("audit.enabled", false)
+	viper.SetDefault("audit.path", "")
+	viper.SetDefault("audit.store_prompts", false)
+	
+	// Usage defaults
+	viper.SetDefault("usage.enabled", true)
+	viper.SetDefault("usage.path", "")
+	viper.SetDefault("usage.prices", []string{})
+	viper.SetDefault("usage.monthly_budget", 0.0)
+	viper.SetDefault("usage.budget_action", "warn")
+	
+	// PR defaults
+	viper.SetDefault("pr.platform", "github")
+
+	viper.SetDefault("trusted_repos", []string{})
 }
 
 func getConfigDir() (string, error) {
@@ -170,16 +503,42 @@ func createDefaultConfig(configPath string) error {
 # AI Provider Settings
 ai:
   provider: openai # openai or gemini
-  api_key: ${AIG_OPENAI_API_KEY} # Environment variable
+  api_key: ${AIG_OPENAI_API_KEY} # Environment variable, or store it with 'aig auth login'
   model: gpt-4o-mini # OpenAI: gpt-4o-mini, gpt-4o, gpt-3.5-turbo | Gemini: gemini-1.5-pro, gemini-1.5-flash
   temperature: 0.7
   max_tokens: 2000
+  timeout: 0s # per command run, 0 for no limit
+  # Per-command overrides of provider, model, temperature, max_tokens and timeout
+  commands:
+    commit:
+      timeout: 30s
+      # model: gpt-4o-mini
+    review:
+      temperature: 0.2
+    pr:
+      timeout: 60s
+    summary: {}
+  # Rate limits, server errors, timeouts and dropped connections are retried
+  # with exponential backoff, honouring Retry-After
+  retry:
+    max_attempts: 4 # including the first, 1 disables retries
+    initial_delay: 1s
+    max_delay: 30s
+  rate_limit:
+    requests_per_minute: 0 # 0 for no limit
+    burst: 1
+  # With provider: replay, responses come from files instead of an API
+  replay:
+    mode: replay # replay recorded fixtures, record them, or static canned responses
+    dir: .aig/replay
+    provider: openai # the real provider used in record mode
 
 # Git Settings
 git:
   auto_stage: false
   default_branch: main
   commit_template: conventional # or custom
+  protected_branches: [main, master, develop, "release/*"] # history aig never rewrites
 
 # UI Settings
 ui:
@@ -201,6 +560,105 @@ review:
     - security
     - performance
     - best_practices
+  hunk_cache: true # only send the hunks that changed since the last review
+
+# Response Cache Settings
+cache:
+  enabled: true
+  ttl: 168h # how long identical prompts are answered from the cache
+  max_size_mb: 100
+
+# Commit Settings
+commit:
+  types: [feat, fix, docs, style, refactor, test, chore, perf, ci, build]
+  scopes: [] # allowed scopes, any scope when empty
+  # Branch name patterns suggesting a commit type, first match wins; * matches anything
+  branch_types:
+    - feature/*=feat
+    - feat/*=feat
+    - fix/*=fix
+    - bugfix/*=fix
+    - hotfix/*=fix
+    - docs/*=docs
+    - chore/*=chore
+    - refactor/*=refactor
+    - perf/*=perf
+    - test/*=test
+    - ci/*=ci
+    - build/*=build
+    - style/*=style
+    - release/*=chore
+  ticket_pattern: "" # extra regexp for ticket keys in branch names, tried before tickets.patterns
+  ticket_placement: prefix # prefix, scope, trailer or none
+  ticket_format: "{ticket}" # how a prefix is written, e.g. "[{ticket}]"
+  ticket_trailer: Refs # trailer token for the trailer placement
+  learn_style: false # imitate recent commit messages and offer the scopes they use
+  style_examples: 5 # how many recent commits to show the model
+  style_sample: 100 # how many recent commits to learn scopes from
+
+# Ticket keys found in branch names and commit messages, used by commit and pr.
+# Builtin patterns are jira (PROJ-123), linear (eng-123), github (#123) and
+# numeric (a bare 4-7 digit number); anything else is a regexp whose first
+# capture group is the key.
+tickets:
+  patterns: [jira, linear, github, numeric]
+
+# Secrets and personal data are replaced with placeholders such as
+# [REDACTED:email:1] before anything is sent to the AI provider
+redact:
+  enabled: true
+  detectors: [aws_key, private_key, jwt, token, high_entropy, email]
+  patterns: [] # extra regexps, e.g. 'internal-host-\d+'
+  # Files whose contents are never sent, in .gitignore syntax
+  deny_paths: [.env, .env.*, "*.pem", "*.key", "*.p12", "*.pfx", "*.keystore", id_rsa*, id_ed25519*, .npmrc, .pypirc, .netrc, credentials.json]
+
+# Staged changes containing secrets are not committed. Allow reviewed findings
+# in the repository's .aigsecrets file or with --allow-secrets.
+secrets:
+  enabled: true
+  detectors: [aws_key, private_key, jwt, token]
+  patterns: []
+
+# Append-only log of every request sent to the AI provider, see 'aig audit'
+audit:
+  enabled: false
+  path: "" # defaults to ~/.config/aig/audit.jsonl
+  store_prompts: false # also record the full prompt and response
+
+# Token usage and cost of every request, see 'aig usage'
+usage:
+  enabled: true
+  path: "" # defaults to ~/.config/aig/usage.jsonl
+  # Prices in dollars per million input/output tokens, on top of the built-in
+  # table. A trailing * matches every model with that prefix.
+  prices: [] # e.g. ["gpt-4o=2.50/10.00", "my-finetune-*=3/12"]
+  monthly_budget: 0 # dollars per calendar month, 0 for no budget
+  budget_action: warn # warn or block once the budget is spent
+
+# Pull Request Settings
+pr:
+  platform: github # github, gitlab or bitbucket
+
+# Profiles override any part of the ai, review and ui sections. Select one
+# with --profile, AIG_PROFILE or the profile key (which a repository can set).
+# profile: work
+# profiles:
+#   work:
+#     ai:
+#       provider: openai
+#       model: gpt-4o
+#   personal:
+#     ai:
+#       provider: gemini
+#       model: gemini-1.5-flash
+
+# Per-repository settings can be placed in .aig.yaml at the repository root
+# (shared with the team) or in .git/aig.yaml (private). They are merged over
+# this file; environment variables and flags take precedence over all files.
+# A repository's .aig.yaml may only refer to environment variables, and neither
+# repository file may change the redact, secrets.enabled, audit or usage
+# settings, unless the repository root is listed here.
+trusted_repos: []
 `
 	
 	if err := os.WriteFile(configPath, []byte(defaultConfig), 0644); err != nil {
@@ -0,0 +1,315 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/tarantino19/aig/internal/git"
+)
+
+// commandTimeout bounds ${cmd:...} substitutions, which may prompt for a password
+const commandTimeout = 30 * time.Second
+
+// errLocalReference is returned for a ${cmd:...} or ${file:...} reference in a
+// file committed to a repository that is not in trusted_repos
+var errLocalReference = errors.New("cmd and file references are not allowed in a repository's .aig.yaml unless the repository is listed in trusted_repos of the global config")
+
+// UnsetVariableError is returned when a value refers to an environment variable
+// that is not set
+type UnsetVariableError struct {
+	Name string
+}
+
+func (e *UnsetVariableError) Error() string {
+	return fmt.Sprintf("environment variable %s is not set", e.Name)
+}
+
+// Expand replaces the references in a config value:
+//
+//	${VAR} or ${env:VAR}   the value of an environment variable
+//	${cmd:command}        the output of a shell command, without trailing newlines
+//	${file:path}          the contents of a file, without trailing newlines; ~ is the home directory
+//
+// $${ produces a literal ${. A reference ends at the } that matches its ${, so
+// commands may contain balanced braces.
+func Expand(value string) (string, error) {
+	return expand(value, true)
+}
+
+// expand is Expand with ${cmd:...} and ${file:...} refused unless local is set,
+// for values that come from a file committed to the repository
+func expand(value string, local bool) (string, error) {
+	if !strings.Contains(value, "${") {
+		return value, nil
+	}
+
+	var out strings.Builder
+	rest := value
+	for {
+		start := strings.Index(rest, "${")
+		if start < 0 {
+			out.WriteString(rest)
+			return out.String(), nil
+		}
+
+		// $${ escapes a literal ${
+		if start > 0 && rest[start-1] == '$' {
+			out.WriteString(rest[:start-1])
+			out.WriteString("${")
+			rest = rest[start+2:]
+			continue
+		}
+
+		end := closingBrace(rest, start+2)
+		if end < 0 {
+			return "", fmt.Errorf("unterminated reference %q", rest[start:])
+		}
+
+		out.WriteString(rest[:start])
+		resolved, err := resolveReference(rest[start+2:end], local)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", rest[start:end+1], err)
+		}
+		out.WriteString(resolved)
+		rest = rest[end+1:]
+	}
+}
+
+// closingBrace returns the index of the } that closes a reference whose body
+// starts at from, skipping balanced pairs of braces, or -1
+func closingBrace(s string, from int) int {
+	depth := 0
+	for i := from; i < len(s); i++ {
+		switch s[i] {
+		case '{':
+			depth++
+		case '}':
+			if depth == 0 {
+				return i
+			}
+			depth--
+		}
+	}
+	return -1
+}
+
+// resolveReference resolves the body of a single ${...} reference
+func resolveReference(ref string, local bool) (string, error) {
+	kind, arg, found := strings.Cut(ref, ":")
+	if !found {
+		kind, arg = "env", ref
+	}
+	arg = strings.TrimSpace(arg)
+	if arg == "" {
+		return "", fmt.Errorf("empty reference")
+	}
+
+	if (kind == "cmd" || kind == "file") && !local {
+		return "", errLocalReference
+	}
+
+	switch kind {
+	case "env":
+		value, ok := os.LookupEnv(arg)
+		if !ok {
+			return "", &UnsetVariableError{Name: arg}
+		}
+		return value, nil
+	case "cmd":
+		return runReferenceCommand(arg)
+	case "file":
+		path, err := expandHome(arg)
+		if err != nil {
+			return "", err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to read file: %w", err)
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	default:
+		return "", fmt.Errorf("unknown reference type %q, expected env, cmd or file", kind)
+	}
+}
+
+// runReferenceCommand runs a ${cmd:...} command through the shell
+func runReferenceCommand(command string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	var out bytes.Buffer
+	var stderr bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+	// Let password managers prompt on the terminal
+	cmd.Stdin = os.Stdin
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return "", fmt.Errorf("command timed out after %s", commandTimeout)
+		}
+		return "", fmt.Errorf("command failed: %w, stderr: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	return strings.TrimRight(out.String(), "\r\n"), nil
+}
+
+// expandHome replaces a leading ~ with the user's home directory
+func expandHome(path string) (string, error) {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path, nil
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(homeDir, path[1:]), nil
+}
+
+// expandConfig expands the references in every string value of cfg. The API key
+// is skipped because it is only expanded when no other source provides one, and
+// profiles are skipped because the active one has already been merged. Values
+// from the repository's .aig.yaml may only refer to the environment unless the
+// repository is trusted.
+func expandConfig(cfg *Config, trusted bool) error {
+	return expandStruct(reflect.ValueOf(cfg).Elem(), "", trusted)
+}
+
+func expandStruct(v reflect.Value, prefix string, trusted bool) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name := t.Field(i).Tag.Get("mapstructure")
+		if name == "" {
+			continue
+		}
+		key := name
+		if prefix != "" {
+			key = prefix + "." + name
+		}
+		if key == "ai.api_key" || key == "profiles" {
+			continue
+		}
+
+		field := v.Field(i)
+		local := allowsLocalReferences(referenceOrigin(key), trusted)
+		switch field.Kind() {
+		case reflect.Struct:
+			if err := expandStruct(field, key, trusted); err != nil {
+				return err
+			}
+		case reflect.String:
+			expanded, err := expand(field.String(), local)
+			if err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+			field.SetString(expanded)
+		case reflect.Slice:
+			if field.Type().Elem().Kind() != reflect.String {
+				continue
+			}
+			for j := 0; j < field.Len(); j++ {
+				expanded, err := expand(field.Index(j).String(), local)
+				if err != nil {
+					return fmt.Errorf("%s[%d]: %w", key, j, err)
+				}
+				field.Index(j).SetString(expanded)
+			}
+		}
+	}
+	return nil
+}
+
+// referenceOrigin returns the layer a key's value was read from. Values of the
+// active profile belong to the file that defines the profile.
+func referenceOrigin(key string) Origin {
+	origin := OriginOf(key)
+	if origin.Kind == OriginProfile {
+		return OriginOf("profiles." + strings.ToLower(origin.Source) + "." + key)
+	}
+	return origin
+}
+
+// allowsLocalReferences reports whether a value from origin may run commands
+// and read files: everything but the repository's .aig.yaml, which anyone who
+// clones the repository would otherwise run, unless the repository is trusted
+func allowsLocalReferences(origin Origin, trusted bool) bool {
+	return origin.Kind != OriginRepo || trusted
+}
+
+// isTrustedRepo reports whether the current repository is listed in the
+// trusted_repos key of the global config file. The merged config is not used,
+// as a repository could otherwise trust itself.
+func isTrustedRepo(global map[string]interface{}) bool {
+	repos, ok := global["trusted_repos"].([]interface{})
+	if !ok || len(repos) == 0 {
+		return false
+	}
+	root, err := git.GetRepoRoot()
+	if err != nil || root == "" {
+		return false
+	}
+	root = canonicalPath(root)
+
+	for _, repo := range repos {
+		path, ok := repo.(string)
+		if !ok {
+			continue
+		}
+		path, err := expandHome(path)
+		if err != nil {
+			continue
+		}
+		if canonicalPath(path) == root {
+			return true
+		}
+	}
+	return false
+}
+
+// canonicalPath cleans a path and resolves its symlinks where possible
+func canonicalPath(path string) string {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	return filepath.Clean(path)
+}
+
+// providerKeyVars are the environment variables the API key is looked up in
+// before the configured value
+var providerKeyVars = map[string]bool{
+	"AIG_OPENAI_API_KEY": true,
+	"OPENAI_API_KEY":     true,
+	"AIG_GEMINI_API_KEY": true,
+	"GEMINI_API_KEY":     true,
+}
+
+// expandAPIKey expands the configured API key. A reference to one of the
+// providers' unset key variables, such as the ${AIG_OPENAI_API_KEY} placeholder
+// of the default config, leaves the key unset so that commands can explain how
+// to configure one; any other unresolved reference is an error.
+func expandAPIKey(configured string, local bool) (string, error) {
+	apiKey, err := expand(configured, local)
+	if err != nil {
+		var unset *UnsetVariableError
+		if errors.As(err, &unset) && providerKeyVars[unset.Name] {
+			return "", nil
+		}
+		return "", fmt.Errorf("ai.api_key: %w", err)
+	}
+	return apiKey, nil
+}
@@ -0,0 +1,97 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestExpand(t *testing.T) {
+	t.Setenv("AIG_TEST_KEY", "secret")
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "key")
+	if err := os.WriteFile(keyFile, []byte("from-file\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"plain", "plain"},
+		{"${AIG_TEST_KEY}", "secret"},
+		{"${env:AIG_TEST_KEY}", "secret"},
+		{"pre-${AIG_TEST_KEY}-post", "pre-secret-post"},
+		{"${file:" + keyFile + "}", "from-file"},
+		{"$${AIG_TEST_KEY}", "${AIG_TEST_KEY}"},
+		{`[A-Z]+-\d+$`, `[A-Z]+-\d+$`},
+	}
+	if runtime.GOOS != "windows" {
+		tests = append(tests, struct {
+			value string
+			want  string
+		}{"${cmd:echo from-cmd}", "from-cmd"}, struct {
+			value string
+			want  string
+		}{"${cmd:echo a b | awk '{print $2}'}", "b"})
+	}
+
+	for _, tt := range tests {
+		got, err := Expand(tt.value)
+		if err != nil {
+			t.Errorf("Expand(%q) failed: %v", tt.value, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Expand(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestExpandErrors(t *testing.T) {
+	os.Unsetenv("AIG_TEST_UNSET")
+
+	_, err := Expand("${AIG_TEST_UNSET}")
+	var unset *UnsetVariableError
+	if !errors.As(err, &unset) || unset.Name != "AIG_TEST_UNSET" {
+		t.Errorf("expected UnsetVariableError, got %v", err)
+	}
+
+	for _, value := range []string{"${AIG_TEST_KEY", "${vault:x}", "${file:/does/not/exist}", "${}"} {
+		if _, err := Expand(value); err == nil {
+			t.Errorf("Expand(%q) should fail", value)
+		}
+	}
+}
+
+func TestExpandRefusesLocalReferences(t *testing.T) {
+	t.Setenv("AIG_TEST_KEY", "secret")
+
+	for _, value := range []string{"${cmd:echo x}", "${file:~/.ssh/id_rsa}"} {
+		if _, err := expand(value, false); !errors.Is(err, errLocalReference) {
+			t.Errorf("expand(%q) outside the local config should be refused, got %v", value, err)
+		}
+	}
+	if got, err := expand("${AIG_TEST_KEY}", false); err != nil || got != "secret" {
+		t.Errorf("environment references should still expand, got %q, %v", got, err)
+	}
+}
+
+func TestExpandAPIKeyPlaceholder(t *testing.T) {
+	os.Unsetenv("AIG_OPENAI_API_KEY")
+	os.Unsetenv("AIG_TEST_UNSET")
+
+	key, err := expandAPIKey("${AIG_OPENAI_API_KEY}", true)
+	if err != nil || key != "" {
+		t.Errorf("an unset placeholder should leave the key empty, got %q, %v", key, err)
+	}
+
+	// Any other unset variable is reported by name
+	_, err = expandAPIKey("${AIG_TEST_UNSET}", true)
+	var unset *UnsetVariableError
+	if !errors.As(err, &unset) || unset.Name != "AIG_TEST_UNSET" {
+		t.Errorf("expected UnsetVariableError for AIG_TEST_UNSET, got %v", err)
+	}
+}
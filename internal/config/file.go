@@ -0,0 +1,145 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SetFileValue sets a dotted key in a single YAML config file, creating the file
+// if needed. Comments and the order of existing keys are preserved.
+func SetFileValue(path, key string, value interface{}) error {
+	doc, err := readDocument(path)
+	if err != nil {
+		return err
+	}
+
+	var encoded yaml.Node
+	if err := encoded.Encode(value); err != nil {
+		return fmt.Errorf("failed to encode %s: %w", key, err)
+	}
+	if encoded.Kind == yaml.SequenceNode {
+		encoded.Style = yaml.FlowStyle
+	}
+
+	node := doc.Content[0]
+	parts := strings.Split(strings.ToLower(key), ".")
+	for i, part := range parts {
+		if node.Kind != yaml.MappingNode {
+			return fmt.Errorf("cannot set %s: %s is not a section", key, strings.Join(parts[:i], "."))
+		}
+
+		child := mappingValue(node, part)
+		if i == len(parts)-1 {
+			if child == nil {
+				node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: part}, &encoded)
+			} else {
+				encoded.LineComment = child.LineComment
+				*child = encoded
+			}
+			break
+		}
+
+		if child == nil {
+			child = &yaml.Node{Kind: yaml.MappingNode}
+			node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: part}, child)
+		}
+		node = child
+	}
+
+	return writeDocument(path, doc)
+}
+
+// DeleteFileKey removes a dotted key, and everything below it, from a single YAML
+// config file. It reports whether the key was present.
+func DeleteFileKey(path, key string) (bool, error) {
+	doc, err := readDocument(path)
+	if err != nil {
+		return false, err
+	}
+
+	node := doc.Content[0]
+	parts := strings.Split(strings.ToLower(key), ".")
+	for i, part := range parts {
+		if node.Kind != yaml.MappingNode {
+			return false, nil
+		}
+
+		if i == len(parts)-1 {
+			for j := 0; j+1 < len(node.Content); j += 2 {
+				if strings.EqualFold(node.Content[j].Value, part) {
+					node.Content = append(node.Content[:j], node.Content[j+2:]...)
+					return true, writeDocument(path, doc)
+				}
+			}
+			return false, nil
+		}
+
+		node = mappingValue(node, part)
+		if node == nil {
+			return false, nil
+		}
+	}
+
+	return false, nil
+}
+
+// readDocument parses a YAML file into a document node. A missing or empty file
+// yields an empty mapping.
+func readDocument(path string) (*yaml.Node, error) {
+	doc := &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return doc, nil
+		}
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var parsed yaml.Node
+	if err := yaml.Unmarshal(data, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if parsed.Kind != yaml.DocumentNode || len(parsed.Content) == 0 {
+		return doc, nil
+	}
+
+	return &parsed, nil
+}
+
+// writeDocument encodes a document node back to its file
+func writeDocument(path string, doc *yaml.Node) error {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(doc); err != nil {
+		return fmt.Errorf("failed to encode %s: %w", path, err)
+	}
+	if err := encoder.Close(); err != nil {
+		return fmt.Errorf("failed to encode %s: %w", path, err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// mappingValue returns the value node for key in a mapping node
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if strings.EqualFold(node.Content[i].Value, key) {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
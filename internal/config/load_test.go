@@ -0,0 +1,267 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// setupLoad creates a home directory and a repository with the given global,
+// repository and private config files and makes the repository the working
+// directory. Empty contents leave a file out.
+func setupLoad(t *testing.T, global, repo, private string) string {
+	t.Helper()
+	dir := t.TempDir()
+	home := filepath.Join(dir, "home")
+	root := filepath.Join(dir, "repo")
+
+	if out, err := exec.Command("git", "init", "-q", root).CombinedOutput(); err != nil {
+		t.Fatalf("git init failed: %v\n%s", err, out)
+	}
+	files := map[string]string{
+		filepath.Join(home, ".config", "aig", "config.yaml"): global,
+		filepath.Join(root, RepoConfigFile):                  repo,
+		filepath.Join(root, ".git", PrivateConfigFile):       private,
+	}
+	for path, content := range files {
+		if content == "" {
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Setenv("HOME", home)
+	t.Setenv("AIG_OPENAI_API_KEY", "test-key")
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(root); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		os.Chdir(wd)
+		viper.Reset()
+		SelectCommand("")
+	})
+	viper.Reset()
+	return root
+}
+
+func TestLoadRefusesRepoCommandReferences(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh commands")
+	}
+	marker := filepath.Join(t.TempDir(), "ran")
+	repoConfig := "ui:\n  theme: ${cmd:touch " + marker + " && echo dark}\n"
+
+	setupLoad(t, "ai:\n  provider: openai\n", repoConfig, "")
+	if _, err := Load(); !errors.Is(err, errLocalReference) {
+		t.Errorf("expected the repository's command to be refused, got %v", err)
+	}
+	if _, err := os.Stat(marker); err == nil {
+		t.Fatal("the repository's command ran")
+	}
+
+	// Neither can a profile defined in the repository file
+	setupLoad(t, "ai:\n  provider: openai\n", "profile: evil\nprofiles:\n  evil:\n    ai:\n      model: ${file:~/.ssh/id_rsa}\n", "")
+	if _, err := Load(); !errors.Is(err, errLocalReference) {
+		t.Errorf("expected the repository profile's file reference to be refused, got %v", err)
+	}
+
+	// The private file is the user's own
+	setupLoad(t, "", "", "ui:\n  theme: ${cmd:echo light}\n")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("private config references should expand: %v", err)
+	}
+	if cfg.UI.Theme != "light" {
+		t.Errorf("theme = %q, want light", cfg.UI.Theme)
+	}
+}
+
+func TestLoadTrustedRepo(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh commands")
+	}
+
+	root := setupLoad(t, "", "ui:\n  theme: ${cmd:echo dark}\n", "")
+	global := "trusted_repos: [" + root + "]\n"
+	if err := os.MkdirAll(filepath.Join(os.Getenv("HOME"), ".config", "aig"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(os.Getenv("HOME"), ".config", "aig", "config.yaml"), []byte(global), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("a trusted repository may use command references: %v", err)
+	}
+	if cfg.UI.Theme != "dark" {
+		t.Errorf("theme = %q, want dark", cfg.UI.Theme)
+	}
+
+	// Trusting itself from the repository file does not count
+	setupLoad(t, "", "trusted_repos: [.]\nui:\n  theme: ${cmd:echo dark}\n", "")
+	if _, err := Load(); !errors.Is(err, errLocalReference) {
+		t.Errorf("a repository must not be able to trust itself, got %v", err)
+	}
+}
+
+func TestLoadGuardsComplianceSettings(t *testing.T) {
+	const global = "ai:\n  provider: openai\naudit:\n  enabled: true\nusage:\n  budget_action: block\n"
+	const repo = `redact:
+  enabled: false
+secrets:
+  enabled: false
+audit:
+  enabled: false
+usage:
+  budget_action: warn
+ui:
+  theme: light
+`
+	root := setupLoad(t, global, repo, "REDACT:\n  Enabled: false\n")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cfg.Redact.Enabled || !cfg.Secrets.Enabled || !cfg.Audit.Enabled || cfg.Usage.BudgetAction != "block" {
+		t.Errorf("an untrusted repository changed compliance settings: redact %v, secrets %v, audit %v, budget action %q",
+			cfg.Redact.Enabled, cfg.Secrets.Enabled, cfg.Audit.Enabled, cfg.Usage.BudgetAction)
+	}
+	if cfg.UI.Theme != "light" {
+		t.Errorf("theme = %q, other repository settings must still apply", cfg.UI.Theme)
+	}
+	if got := len(IgnoredSettings()); got != 5 {
+		t.Errorf("IgnoredSettings() = %v, want 5 entries", IgnoredSettings())
+	}
+
+	// A trusted repository may change them
+	globalPath := filepath.Join(os.Getenv("HOME"), ".config", "aig", "config.yaml")
+	if err := os.WriteFile(globalPath, []byte(global+"trusted_repos: ["+root+"]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	viper.Reset()
+	if cfg, err = Load(); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Redact.Enabled || cfg.Secrets.Enabled || cfg.Audit.Enabled || cfg.Usage.BudgetAction != "warn" {
+		t.Errorf("a trusted repository's settings were ignored: %+v %+v %+v %+v", cfg.Redact, cfg.Secrets, cfg.Audit, cfg.Usage)
+	}
+	if len(IgnoredSettings()) != 0 {
+		t.Errorf("IgnoredSettings() = %v, want none for a trusted repository", IgnoredSettings())
+	}
+}
+
+func TestLoadCommandSectionPrecedence(t *testing.T) {
+	tests := []struct {
+		name        string
+		command     string
+		global      string
+		repo        string
+		env         map[string]string
+		timeout     time.Duration
+		temperature float64
+	}{
+		{
+			name:        "built-in section defaults",
+			command:     "review",
+			temperature: 0.2,
+		},
+		{
+			name:    "built-in commit timeout",
+			command: "commit",
+			timeout: 30 * time.Second,
+		},
+		{
+			name:    "global ai.timeout beats the built-in section default",
+			command: "commit",
+			global:  "ai:\n  timeout: 120s\n",
+			timeout: 120 * time.Second,
+		},
+		{
+			name:        "environment beats the built-in section default",
+			command:     "review",
+			env:         map[string]string{"AIG_AI_TEMPERATURE": "0.9"},
+			temperature: 0.9,
+		},
+		{
+			name:    "section in the same file wins",
+			command: "commit",
+			global:  "ai:\n  timeout: 120s\n  commands:\n    commit:\n      timeout: 45s\n",
+			timeout: 45 * time.Second,
+		},
+		{
+			name:        "repository section beats global ai value",
+			command:     "review",
+			global:      "ai:\n  temperature: 0.7\n",
+			repo:        "ai:\n  commands:\n    review:\n      temperature: 0.5\n",
+			temperature: 0.5,
+		},
+		{
+			name:        "repository ai value beats global section",
+			command:     "review",
+			global:      "ai:\n  commands:\n    review:\n      temperature: 0.5\n",
+			repo:        "ai:\n  temperature: 0.8\n",
+			temperature: 0.8,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			global := tt.global
+			if global == "" {
+				// Without a global file a default one is generated
+				global = "ai:\n  provider: openai\n"
+			}
+			setupLoad(t, global, tt.repo, "")
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+			SelectCommand(tt.command)
+
+			cfg, err := Load()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.timeout != 0 && cfg.AI.Timeout != tt.timeout {
+				t.Errorf("timeout = %s, want %s", cfg.AI.Timeout, tt.timeout)
+			}
+			if tt.temperature != 0 && cfg.AI.Temperature != tt.temperature {
+				t.Errorf("temperature = %v, want %v", cfg.AI.Temperature, tt.temperature)
+			}
+		})
+	}
+}
+
+func TestLoadHunkCacheIsSeparate(t *testing.T) {
+	setupLoad(t, "ai:\n  provider: openai\ncache:\n  enabled: false\n", "", "")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Cache.Enabled || !cfg.Review.HunkCache {
+		t.Errorf("cache.enabled = %v, review.hunk_cache = %v, want the response cache off and the hunk cache on", cfg.Cache.Enabled, cfg.Review.HunkCache)
+	}
+
+	setupLoad(t, "ai:\n  provider: openai\n", "review:\n  hunk_cache: false\n", "")
+	if cfg, err = Load(); err != nil {
+		t.Fatal(err)
+	}
+	if !cfg.Cache.Enabled || cfg.Review.HunkCache {
+		t.Errorf("cache.enabled = %v, review.hunk_cache = %v, want only the hunk cache off", cfg.Cache.Enabled, cfg.Review.HunkCache)
+	}
+}
@@ -0,0 +1,515 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tarantino19/aig/internal/git"
+	"github.com/tarantino19/aig/internal/redact"
+	"github.com/tarantino19/aig/internal/tickets"
+	"github.com/tarantino19/aig/internal/usage"
+)
+
+// Value types of config fields
+const (
+	TypeString   = "string"
+	TypeBool     = "bool"
+	TypeInt      = "int"
+	TypeFloat    = "float"
+	TypeDuration = "duration"
+	TypeList     = "list"
+)
+
+// Field describes a single config key
+type Field struct {
+	Key  string
+	Type string
+
+	Enum []string // allowed values, any value when empty
+	Min  float64  // lower bound for numbers
+	Max  float64  // upper bound for numbers
+
+	// Check validates the value beyond its type, enum and range
+	Check func(value interface{}) error
+}
+
+// providerModelPrefixes are the model name prefixes each provider accepts
+var providerModelPrefixes = map[string][]string{
+	"openai": {"gpt-", "o1", "o3", "o4", "chatgpt-"},
+	"gemini": {"gemini-"},
+	"replay": nil, // any model, the name is only recorded
+}
+
+// constraints refines the fields derived from the Config structs
+var constraints = map[string]func(*Field){
+	"ai.provider": func(f *Field) { f.Enum = sortedKeys(providerModelPrefixes) },
+	"ai.temperature": func(f *Field) {
+		f.Min, f.Max = 0, 2
+	},
+	"ai.max_tokens": func(f *Field) {
+		f.Min, f.Max = 1, 1000000
+	},
+	"ai.retry.max_attempts": func(f *Field) {
+		f.Min, f.Max = 1, 20
+	},
+	"ai.timeout":             func(f *Field) { f.Check = maxDuration(time.Hour) },
+	"ai.retry.initial_delay": func(f *Field) { f.Check = maxDuration(time.Minute) },
+	"ai.retry.max_delay":     func(f *Field) { f.Check = maxDuration(time.Hour) },
+	"ai.rate_limit.requests_per_minute": func(f *Field) {
+		f.Min, f.Max = 0, 1000000
+	},
+	"ai.rate_limit.burst": func(f *Field) {
+		f.Min, f.Max = 1, 10000
+	},
+	"ai.replay.mode":      func(f *Field) { f.Enum = []string{"replay", "record", "static"} },
+	"ai.replay.provider":  func(f *Field) { f.Enum = []string{"openai", "gemini"} },
+	"git.commit_template": func(f *Field) { f.Enum = []string{"conventional", "custom"} },
+	"ui.theme":            func(f *Field) { f.Enum = []string{"dark", "light", "auto"} },
+	"pr.platform":         func(f *Field) { f.Enum = []string{"github", "gitlab", "bitbucket"} },
+	"cache.max_size_mb": func(f *Field) {
+		f.Min, f.Max = 0, math.MaxInt32
+	},
+	"cache.ttl": func(f *Field) {
+		f.Min, f.Max = 0, math.Inf(1)
+	},
+	"commit.style_examples": func(f *Field) {
+		f.Min, f.Max = 1, 20
+	},
+	"commit.style_sample": func(f *Field) {
+		f.Min, f.Max = 1, 1000
+	},
+	"commit.ticket_placement": func(f *Field) { f.Enum = []string{"prefix", "scope", "trailer", "none"} },
+	"commit.ticket_format": func(f *Field) {
+		f.Check = func(value interface{}) error {
+			if !strings.Contains(value.(string), "{ticket}") {
+				return fmt.Errorf("must contain {ticket}")
+			}
+			return nil
+		}
+	},
+	"commit.branch_types": func(f *Field) {
+		f.Check = func(value interface{}) error {
+			_, err := git.ParseBranchRules(value.([]string))
+			return err
+		}
+	},
+	"redact.detectors": func(f *Field) {
+		f.Check = func(value interface{}) error {
+			_, err := redact.New(redact.Options{Detectors: value.([]string)})
+			return err
+		}
+	},
+	"redact.patterns": func(f *Field) {
+		f.Check = func(value interface{}) error {
+			_, err := redact.New(redact.Options{Detectors: []string{}, Patterns: value.([]string)})
+			return err
+		}
+	},
+	"secrets.detectors": func(f *Field) {
+		f.Check = func(value interface{}) error {
+			_, err := redact.New(redact.Options{Detectors: value.([]string)})
+			return err
+		}
+	},
+	"secrets.patterns": func(f *Field) {
+		f.Check = func(value interface{}) error {
+			_, err := redact.New(redact.Options{Detectors: []string{}, Patterns: value.([]string)})
+			return err
+		}
+	},
+	"usage.prices": func(f *Field) {
+		f.Check = func(value interface{}) error {
+			_, err := usage.ParsePrices(value.([]string))
+			return err
+		}
+	},
+	"usage.monthly_budget": func(f *Field) {
+		f.Min, f.Max = 0, math.Inf(1)
+	},
+	"usage.budget_action": func(f *Field) { f.Enum = []string{"warn", "block"} },
+	"tickets.patterns": func(f *Field) {
+		f.Check = func(value interface{}) error { return tickets.Validate(value.([]string)) }
+	},
+	"commit.ticket_pattern": func(f *Field) {
+		f.Check = func(value interface{}) error {
+			_, err := regexp.Compile(value.(string))
+			return err
+		}
+	},
+}
+
+var schema = buildSchema()
+
+// Schema returns every config field in key order
+func Schema() []Field {
+	fields := make([]Field, 0, len(schema))
+	for _, field := range schema {
+		fields = append(fields, field)
+	}
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Key < fields[j].Key })
+	return fields
+}
+
+// LookupField returns the field for key. Keys inside a profile, such as
+// profiles.work.ai.model, resolve to the field they override.
+func LookupField(key string) (Field, bool) {
+	key = strings.ToLower(key)
+	if field, ok := schema[key]; ok {
+		return field, true
+	}
+
+	parts := strings.SplitN(key, ".", 3)
+	if len(parts) == 3 && parts[0] == "profiles" && parts[1] != "" {
+		section, _, _ := strings.Cut(parts[2], ".")
+		if !profileSections[section] {
+			return Field{}, false
+		}
+		field, ok := schema[parts[2]]
+		if !ok {
+			return Field{}, false
+		}
+		field.Key = key
+		return field, true
+	}
+
+	return Field{}, false
+}
+
+// CheckKey returns an UnknownKeyError with suggestions if key is not part of the schema
+func CheckKey(key string) error {
+	if _, ok := LookupField(key); ok {
+		return nil
+	}
+	return unknownKey(key)
+}
+
+// UnknownKeyError is returned for keys that are not part of the schema
+type UnknownKeyError struct {
+	Key         string
+	Suggestions []string
+}
+
+func (e *UnknownKeyError) Error() string {
+	if len(e.Suggestions) == 0 {
+		return fmt.Sprintf("unknown config key %q", e.Key)
+	}
+	return fmt.Sprintf("unknown config key %q, did you mean %s?", e.Key, strings.Join(e.Suggestions, " or "))
+}
+
+// unknownKey builds the error for an unknown key with the closest known keys
+func unknownKey(key string) error {
+	key = strings.ToLower(key)
+	type candidate struct {
+		key      string
+		distance int
+	}
+
+	var candidates []candidate
+	for known := range schema {
+		if d := levenshtein(key, known); d <= 3 {
+			candidates = append(candidates, candidate{known, d})
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].distance != candidates[j].distance {
+			return candidates[i].distance < candidates[j].distance
+		}
+		return candidates[i].key < candidates[j].key
+	})
+
+	err := &UnknownKeyError{Key: key}
+	for i := 0; i < len(candidates) && i < 3; i++ {
+		err.Suggestions = append(err.Suggestions, candidates[i].key)
+	}
+	return err
+}
+
+// ParseValue converts a command-line value to the type of key and validates it.
+// Lists are written comma-separated or as a YAML flow sequence, [a, b].
+func ParseValue(key, raw string) (interface{}, error) {
+	field, ok := LookupField(key)
+	if !ok {
+		return nil, unknownKey(key)
+	}
+
+	var value interface{}
+	switch field.Type {
+	case TypeBool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be true or false, got %q", key, raw)
+		}
+		value = b
+	case TypeInt:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be a whole number, got %q", key, raw)
+		}
+		value = n
+	case TypeFloat:
+		n, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be a number, got %q", key, raw)
+		}
+		value = n
+	case TypeDuration:
+		if _, err := time.ParseDuration(raw); err != nil {
+			return nil, fmt.Errorf("%s must be a duration such as 30s or 168h, got %q", key, raw)
+		}
+		value = raw
+	case TypeList:
+		value = parseList(raw)
+	default:
+		value = raw
+	}
+
+	if err := field.validate(value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// ValidateValue checks a value read from a config file against the schema
+func ValidateValue(key string, value interface{}) error {
+	field, ok := LookupField(key)
+	if !ok {
+		return unknownKey(key)
+	}
+
+	var typed interface{}
+	switch field.Type {
+	case TypeBool:
+		b, ok := value.(bool)
+		if !ok {
+			return fmt.Errorf("%s must be true or false, got %v", key, value)
+		}
+		typed = b
+	case TypeInt:
+		n, ok := value.(int)
+		if !ok {
+			return fmt.Errorf("%s must be a whole number, got %v", key, value)
+		}
+		typed = n
+	case TypeFloat:
+		switch n := value.(type) {
+		case int:
+			typed = float64(n)
+		case float64:
+			typed = n
+		default:
+			return fmt.Errorf("%s must be a number, got %v", key, value)
+		}
+	case TypeDuration:
+		s, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("%s must be a duration such as 30s or 168h, got %v", key, value)
+		}
+		if _, err := time.ParseDuration(s); err != nil {
+			return fmt.Errorf("%s must be a duration such as 30s or 168h, got %q", key, s)
+		}
+		typed = s
+	case TypeList:
+		items, ok := value.([]interface{})
+		if !ok {
+			return fmt.Errorf("%s must be a list, got %v", key, value)
+		}
+		var list []string
+		for _, item := range items {
+			s, ok := item.(string)
+			if !ok {
+				return fmt.Errorf("%s must be a list of strings, got %v", key, item)
+			}
+			list = append(list, s)
+		}
+		typed = list
+	default:
+		s, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("%s must be a string, got %v", key, value)
+		}
+		typed = s
+	}
+
+	return field.validate(typed)
+}
+
+// ValidateModel checks that a model name belongs to the provider
+func ValidateModel(provider, model string) error {
+	prefixes, ok := providerModelPrefixes[provider]
+	if !ok || model == "" || len(prefixes) == 0 {
+		return nil
+	}
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(model, prefix) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%q is not a %s model, expected a name starting with %s", model, provider, strings.Join(prefixes, ", "))
+}
+
+// validate checks a typed value against the field's enum, range and check
+func (f Field) validate(value interface{}) error {
+	if len(f.Enum) > 0 {
+		s, _ := value.(string)
+		if !containsString(f.Enum, s) {
+			return fmt.Errorf("%s must be one of %s, got %q", f.Key, strings.Join(f.Enum, ", "), s)
+		}
+	}
+
+	if f.Min != 0 || f.Max != 0 {
+		var n float64
+		switch v := value.(type) {
+		case int:
+			n = float64(v)
+		case float64:
+			n = v
+		case string:
+			d, _ := time.ParseDuration(v)
+			n = float64(d)
+		}
+		if n < f.Min || n > f.Max {
+			if math.IsInf(f.Max, 1) {
+				return fmt.Errorf("%s must not be negative", f.Key)
+			}
+			return fmt.Errorf("%s must be between %v and %v, got %v", f.Key, f.Min, f.Max, value)
+		}
+	}
+
+	if f.Check != nil {
+		if err := f.Check(value); err != nil {
+			return fmt.Errorf("%s: %w", f.Key, err)
+		}
+	}
+
+	return nil
+}
+
+// buildSchema derives the fields from the mapstructure tags of Config
+func buildSchema() map[string]Field {
+	fields := make(map[string]Field)
+	collectFields(reflect.TypeOf(Config{}), "", fields)
+	for key, refine := range constraints {
+		field := fields[key]
+		refine(&field)
+		fields[key] = field
+	}
+
+	// Command sections take the constraints of the ai keys they override
+	for key, field := range fields {
+		rest, ok := strings.CutPrefix(key, "ai.commands.")
+		if !ok {
+			continue
+		}
+		_, name, _ := strings.Cut(rest, ".")
+		if refine, ok := constraints["ai."+name]; ok {
+			refine(&field)
+			fields[key] = field
+		}
+	}
+	return fields
+}
+
+func collectFields(t reflect.Type, prefix string, fields map[string]Field) {
+	durationType := reflect.TypeOf(time.Duration(0))
+
+	for i := 0; i < t.NumField(); i++ {
+		name := t.Field(i).Tag.Get("mapstructure")
+		if name == "" {
+			continue
+		}
+		key := name
+		if prefix != "" {
+			key = prefix + "." + name
+		}
+
+		fieldType := t.Field(i).Type
+		switch {
+		case fieldType == durationType:
+			fields[key] = Field{Key: key, Type: TypeDuration}
+		case fieldType.Kind() == reflect.Struct:
+			collectFields(fieldType, key, fields)
+		case fieldType.Kind() == reflect.Bool:
+			fields[key] = Field{Key: key, Type: TypeBool}
+		case fieldType.Kind() == reflect.Int:
+			fields[key] = Field{Key: key, Type: TypeInt}
+		case fieldType.Kind() == reflect.Float64:
+			fields[key] = Field{Key: key, Type: TypeFloat}
+		case fieldType.Kind() == reflect.Slice && fieldType.Elem().Kind() == reflect.String:
+			fields[key] = Field{Key: key, Type: TypeList}
+		case fieldType.Kind() == reflect.String:
+			fields[key] = Field{Key: key, Type: TypeString}
+		}
+		// Maps such as profiles are validated through LookupField
+	}
+}
+
+// parseList splits a comma-separated list or a YAML flow sequence
+func parseList(raw string) []string {
+	raw = strings.TrimSpace(raw)
+	raw = strings.TrimSuffix(strings.TrimPrefix(raw, "["), "]")
+
+	list := []string{}
+	for _, item := range strings.Split(raw, ",") {
+		item = strings.Trim(strings.TrimSpace(item), `'"`)
+		if item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// levenshtein returns the edit distance between two strings
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+// maxDuration returns a check that a duration is not negative and at most limit
+func maxDuration(limit time.Duration) func(value interface{}) error {
+	return func(value interface{}) error {
+		d, _ := time.ParseDuration(value.(string))
+		if d < 0 || d > limit {
+			return fmt.Errorf("must be between 0s and %s", limit)
+		}
+		return nil
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
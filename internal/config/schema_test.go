@@ -0,0 +1,80 @@
+package config
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseValue(t *testing.T) {
+	tests := []struct {
+		key  string
+		raw  string
+		want interface{}
+	}{
+		{"ui.emoji", "false", false},
+		{"ai.temperature", "0.2", 0.2},
+		{"ai.max_tokens", "4096", 4096},
+		{"cache.ttl", "24h", "24h"},
+		{"review.focus_areas", "security, performance", []string{"security", "performance"}},
+		{"commit.scopes", "[api, ui]", []string{"api", "ui"}},
+		{"profiles.work.ai.model", "gpt-4o", "gpt-4o"},
+		{"ai.commands.review.temperature", "0.1", 0.1},
+		{"ai.commands.commit.timeout", "45s", "45s"},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseValue(tt.key, tt.raw)
+		if err != nil {
+			t.Errorf("ParseValue(%q, %q) failed: %v", tt.key, tt.raw, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseValue(%q, %q) = %#v, want %#v", tt.key, tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestParseValueRejectsInvalid(t *testing.T) {
+	for _, kv := range [][2]string{
+		{"ui.emoji", "maybe"},
+		{"ai.temperature", "5"},
+		{"ui.theme", "pink"},
+		{"cache.ttl", "a week"},
+		{"commit.ticket_pattern", "[a-"},
+		{"commit.ticket_placement", "footer"},
+		{"commit.branch_types", "docs/*"},
+		{"redact.detectors", "email, ssn"},
+		{"commit.ticket_format", "[TICKET]"},
+		{"tickets.patterns", "jira, (unclosed"},
+		{"profiles.work.git.auto_stage", "true"},
+		{"ai.commands.review.temperature", "3"},
+		{"ai.commands.pr.provider", "claude"},
+		{"ai.commands.commit.timeout", "2h"},
+	} {
+		if _, err := ParseValue(kv[0], kv[1]); err == nil {
+			t.Errorf("ParseValue(%q, %q) should fail", kv[0], kv[1])
+		}
+	}
+}
+
+func TestUnknownKeySuggestions(t *testing.T) {
+	_, err := ParseValue("ai.modle", "gpt-4o")
+
+	var unknown *UnknownKeyError
+	if !errors.As(err, &unknown) {
+		t.Fatalf("expected UnknownKeyError, got %v", err)
+	}
+	if len(unknown.Suggestions) == 0 || unknown.Suggestions[0] != "ai.model" {
+		t.Errorf("expected ai.model to be suggested first, got %v", unknown.Suggestions)
+	}
+}
+
+func TestValidateModel(t *testing.T) {
+	if err := ValidateModel("openai", "gpt-4o-mini"); err != nil {
+		t.Error(err)
+	}
+	if err := ValidateModel("gemini", "gpt-4o-mini"); err == nil {
+		t.Error("a gpt model should be rejected for gemini")
+	}
+}
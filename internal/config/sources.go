@@ -0,0 +1,312 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+	"github.com/tarantino19/aig/internal/git"
+	"gopkg.in/yaml.v3"
+)
+
+// RepoConfigFile is the name of the shared per-repository config file at the repo root
+const RepoConfigFile = ".aig.yaml"
+
+// PrivateConfigFile is the name of the per-repository config file inside $GIT_DIR,
+// for overrides that should never be committed
+const PrivateConfigFile = "aig.yaml"
+
+// profileEnvVar selects a profile from the environment
+const profileEnvVar = "AIG_PROFILE"
+
+// guardedKeys are the compliance settings that only the global config, the
+// environment and flags may change, unless the repository is trusted
+var guardedKeys = []string{"redact", "secrets.enabled", "audit", "usage"}
+
+// profileSections are the config sections a profile may override
+var profileSections = map[string]bool{"ai": true, "review": true, "ui": true}
+
+// Origin kinds, listed from lowest to highest precedence
+const (
+	OriginDefault = "default"
+	OriginGlobal  = "global"
+	OriginRepo    = "repo"
+	OriginPrivate = "repo-private"
+	OriginProfile = "profile"
+	OriginStore   = "credentials"
+	OriginEnv     = "env"
+	OriginFlag    = "flag"
+)
+
+// originRanks orders the origin kinds by precedence
+var originRanks = map[string]int{
+	OriginDefault: 0,
+	OriginGlobal:  1,
+	OriginRepo:    2,
+	OriginPrivate: 3,
+	OriginProfile: 4,
+	OriginStore:   5,
+	OriginEnv:     6,
+	OriginFlag:    7,
+}
+
+// originRank returns the precedence of an origin, higher wins
+func originRank(o Origin) int {
+	return originRanks[o.Kind]
+}
+
+// Origin records where a configuration value came from
+type Origin struct {
+	Kind   string // one of the Origin* constants
+	Source string // file path, environment variable, credential store or flag name
+}
+
+// String renders the origin for display
+func (o Origin) String() string {
+	if o.Source == "" {
+		return o.Kind
+	}
+	return fmt.Sprintf("%s (%s)", o.Kind, o.Source)
+}
+
+// override is a value set from a command-line flag
+type override struct {
+	value interface{}
+	flag  string
+}
+
+var (
+	origins         = make(map[string]Origin)
+	overrides       = make(map[string]override)
+	ignored         []string
+	selectedProfile string
+	selectedCommand string
+)
+
+// SetOverride sets a value from a command-line flag. Overrides take precedence over
+// every other source and must be set before Load is called.
+func SetOverride(key string, value interface{}, flag string) {
+	overrides[strings.ToLower(key)] = override{value: value, flag: flag}
+}
+
+// SelectCommand selects the command whose ai.commands section Load merges over
+// the ai settings
+func SelectCommand(name string) {
+	selectedCommand = name
+}
+
+// SelectProfile selects the profile named by the --profile flag. It takes precedence
+// over AIG_PROFILE and the profile key of the config files.
+func SelectProfile(name string) {
+	selectedProfile = name
+}
+
+// ActiveProfile returns the name of the profile applied by the last Load and where
+// it was selected. The name is empty when no profile is active.
+func ActiveProfile() (string, Origin) {
+	if selectedProfile != "" {
+		return selectedProfile, Origin{Kind: OriginFlag, Source: "--profile"}
+	}
+	if name, ok := os.LookupEnv(profileEnvVar); ok && name != "" {
+		return name, Origin{Kind: OriginEnv, Source: profileEnvVar}
+	}
+	return viper.GetString("profile"), OriginOf("profile")
+}
+
+// ProfileNames returns the names of every configured profile in sorted order
+func ProfileNames() []string {
+	var names []string
+	for name := range viper.GetStringMap("profiles") {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// OriginOf returns where the value of key came from
+func OriginOf(key string) Origin {
+	if origin, ok := origins[strings.ToLower(key)]; ok {
+		return origin
+	}
+	return Origin{Kind: OriginDefault}
+}
+
+// Keys returns every known configuration key in sorted order
+func Keys() []string {
+	keys := viper.AllKeys()
+	sort.Strings(keys)
+	return keys
+}
+
+// RepoConfigPath returns the path of the shared repository config file, or an
+// empty string outside a repository
+func RepoConfigPath() string {
+	root, err := git.GetRepoRoot()
+	if err != nil || root == "" {
+		return ""
+	}
+	return filepath.Join(root, RepoConfigFile)
+}
+
+// PrivateConfigPath returns the path of the private repository config file inside
+// $GIT_DIR, or an empty string outside a repository
+func PrivateConfigPath() string {
+	gitDir, err := git.GetGitDir()
+	if err != nil || gitDir == "" {
+		return ""
+	}
+	return filepath.Join(gitDir, PrivateConfigFile)
+}
+
+// GlobalConfigPath returns the path of the user's global config file
+func GlobalConfigPath() (string, error) {
+	configDir, err := getConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "config.yaml"), nil
+}
+
+// readConfigFile parses a YAML config file into a map. A missing file yields nil.
+func readConfigFile(path string) (map[string]interface{}, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	values := make(map[string]interface{})
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	return values, nil
+}
+
+// mergeLayer merges a parsed config file over the values loaded so far and
+// records it as the origin of every key it sets
+func mergeLayer(values map[string]interface{}, origin Origin) error {
+	if len(values) == 0 {
+		return nil
+	}
+
+	if err := viper.MergeConfigMap(values); err != nil {
+		return fmt.Errorf("failed to merge %s config: %w", origin, err)
+	}
+
+	for _, key := range flattenKeys(values, "") {
+		origins[key] = origin
+	}
+
+	return nil
+}
+
+// dropGuardedKeys removes the guardedKeys from the values of an untrusted
+// repository file and records them as ignored
+func dropGuardedKeys(values map[string]interface{}, origin Origin) {
+	for _, key := range guardedKeys {
+		if dropKey(values, strings.Split(key, ".")) {
+			ignored = append(ignored, fmt.Sprintf("%s from %s", key, origin.Source))
+		}
+	}
+}
+
+// dropKey deletes the value at path from a nested map, ignoring case
+func dropKey(values map[string]interface{}, path []string) bool {
+	for key, value := range values {
+		if !strings.EqualFold(key, path[0]) {
+			continue
+		}
+		if len(path) == 1 {
+			delete(values, key)
+			return true
+		}
+		if section, ok := value.(map[string]interface{}); ok && dropKey(section, path[1:]) {
+			return true
+		}
+	}
+	return false
+}
+
+// IgnoredSettings returns the settings of an untrusted repository that the last
+// Load left out, each as "key from path"
+func IgnoredSettings() []string {
+	return ignored
+}
+
+// applyProfile merges the active profile over the values loaded from files
+func applyProfile() error {
+	name, _ := ActiveProfile()
+	if name == "" {
+		return nil
+	}
+
+	section, ok := viper.Get("profiles." + strings.ToLower(name)).(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("profile %q not found, available profiles: %s", name, strings.Join(ProfileNames(), ", "))
+	}
+
+	for key := range section {
+		if !profileSections[strings.ToLower(key)] {
+			return fmt.Errorf("profile %q: section %q cannot be set in a profile", name, key)
+		}
+	}
+
+	return mergeLayer(section, Origin{Kind: OriginProfile, Source: name})
+}
+
+// recordDefaultOrigins marks every key that has a default value
+func recordDefaultOrigins() {
+	for _, key := range viper.AllKeys() {
+		origins[key] = Origin{Kind: OriginDefault}
+	}
+}
+
+// recordEnvOrigins marks keys whose value comes from an AIG_* environment variable
+func recordEnvOrigins() {
+	for _, key := range viper.AllKeys() {
+		name := envVarName(key)
+		if _, ok := os.LookupEnv(name); ok {
+			origins[key] = Origin{Kind: OriginEnv, Source: name}
+		}
+	}
+}
+
+// applyOverrides sets flag values with the highest precedence
+func applyOverrides() {
+	for key, o := range overrides {
+		viper.Set(key, o.value)
+		origins[key] = Origin{Kind: OriginFlag, Source: o.flag}
+	}
+}
+
+// envVarName returns the environment variable that overrides a config key
+func envVarName(key string) string {
+	return "AIG_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// flattenKeys returns the dotted paths of every leaf value in a nested map
+func flattenKeys(values map[string]interface{}, prefix string) []string {
+	var keys []string
+	for key, value := range values {
+		fullKey := strings.ToLower(key)
+		if prefix != "" {
+			fullKey = prefix + "." + fullKey
+		}
+		if nested, ok := value.(map[string]interface{}); ok && len(nested) > 0 {
+			keys = append(keys, flattenKeys(nested, fullKey)...)
+			continue
+		}
+		keys = append(keys, fullKey)
+	}
+	return keys
+}
@@ -0,0 +1,104 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// ValidateFile checks every value of a single config file against the schema. A
+// missing file is valid.
+func ValidateFile(path string) ([]error, error) {
+	values, err := readConfigFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var problems []error
+	validateValues(values, "", &problems)
+	return problems, nil
+}
+
+// ValidateConfig checks constraints that span several keys of the merged config
+func ValidateConfig(cfg *Config) []error {
+	var problems []error
+
+	if err := ValidateModel(cfg.AI.Provider, cfg.AI.Model); err != nil {
+		problems = append(problems, fmt.Errorf("ai.model: %w", err))
+	}
+
+	for _, command := range []string{"commit", "review", "pr", "summary"} {
+		prefix := "ai.commands." + command + "."
+		provider := viper.GetString(prefix + "provider")
+		if provider == "" {
+			provider = viper.GetString("ai.provider")
+		}
+		if err := ValidateModel(provider, viper.GetString(prefix+"model")); err != nil {
+			problems = append(problems, fmt.Errorf("%smodel: %w", prefix, err))
+		}
+	}
+
+	for _, name := range sortedKeys(cfg.Profiles) {
+		// Only the keys a profile sets are known here, so check it against the
+		// raw values rather than the zero values of the struct
+		provider := viper.GetString("profiles." + name + ".ai.provider")
+		if provider == "" {
+			provider = cfg.AI.Provider
+		}
+		model := viper.GetString("profiles." + name + ".ai.model")
+		if err := ValidateModel(provider, model); err != nil {
+			problems = append(problems, fmt.Errorf("profiles.%s.ai.model: %w", name, err))
+		}
+	}
+
+	return problems
+}
+
+func validateValues(values map[string]interface{}, prefix string, problems *[]error) {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := values[key]
+		fullKey := strings.ToLower(key)
+		if prefix != "" {
+			fullKey = prefix + "." + fullKey
+		}
+
+		if _, ok := LookupField(fullKey); ok {
+			if err := ValidateValue(fullKey, value); err != nil {
+				*problems = append(*problems, err)
+			}
+			continue
+		}
+
+		if nested, ok := value.(map[string]interface{}); ok && isSection(fullKey) {
+			validateValues(nested, fullKey, problems)
+			continue
+		}
+
+		*problems = append(*problems, unknownKey(fullKey))
+	}
+}
+
+// isSection reports whether key names a group of fields rather than a value
+func isSection(key string) bool {
+	parts := strings.Split(key, ".")
+	if parts[0] == "profiles" {
+		// profiles, profiles.<name> and profiles.<name>.<section>
+		return len(parts) <= 2 || (len(parts) == 3 && profileSections[parts[2]])
+	}
+
+	prefix := key + "."
+	for known := range schema {
+		if strings.HasPrefix(known, prefix) {
+			return true
+		}
+	}
+	return false
+}
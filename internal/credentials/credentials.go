@@ -0,0 +1,84 @@
+package credentials
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ErrNotFound is returned when no credential is stored for a provider
+var ErrNotFound = errors.New("credential not found")
+
+// Store keeps API keys per provider
+type Store interface {
+	// Name describes the store for status output
+	Name() string
+
+	// Get returns the key stored for provider or ErrNotFound
+	Get(provider string) (string, error)
+
+	// Set stores the key for provider
+	Set(provider, key string) error
+
+	// Delete removes the key for provider. Deleting a missing key is not an error.
+	Delete(provider string) error
+}
+
+// Stores returns the available credential stores in lookup order: the system
+// keyring when one is available, then the encrypted file
+func Stores() []Store {
+	var stores []Store
+	if keyring := systemKeyring(); keyring != nil {
+		stores = append(stores, keyring)
+	}
+	if file, err := DefaultFile(); err == nil {
+		stores = append(stores, file)
+	}
+	return stores
+}
+
+// Default returns the store new credentials are saved to, preferring the
+// system keyring over the encrypted file
+func Default() (Store, error) {
+	stores := Stores()
+	if len(stores) == 0 {
+		return nil, fmt.Errorf("no credential store available")
+	}
+	return stores[0], nil
+}
+
+// Lookup returns the key for provider from the first store that has one, together
+// with the name of that store
+func Lookup(provider string) (string, string, error) {
+	for _, store := range Stores() {
+		key, err := store.Get(provider)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return "", "", fmt.Errorf("failed to read credential from %s: %w", store.Name(), err)
+		}
+		return key, store.Name(), nil
+	}
+	return "", "", ErrNotFound
+}
+
+// Mask hides all but the edges of a secret so that it can be displayed
+func Mask(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	if len(secret) < 12 {
+		return "********"
+	}
+	return secret[:4] + "..." + secret[len(secret)-4:]
+}
+
+func configDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".config", "aig"), nil
+}
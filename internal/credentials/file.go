@@ -0,0 +1,211 @@
+package credentials
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+)
+
+// FileName is the name of the encrypted credentials file in the config directory
+const FileName = "credentials.enc"
+
+// PassphraseEnv holds the passphrase protecting the credentials file
+const PassphraseEnv = "AIG_CREDENTIALS_PASSPHRASE"
+
+const (
+	fileVersion      = 1
+	kdfIterations    = 600000
+	saltSize         = 16
+	encryptionKeyLen = 32
+)
+
+// File stores credentials in a file encrypted with AES-256-GCM. It is the fallback
+// for machines without a system keyring, such as headless servers.
+type File struct {
+	path string
+}
+
+// encryptedFile is the on-disk format of the credentials file
+type encryptedFile struct {
+	Version int    `json:"version"`
+	Salt    []byte `json:"salt"`
+	Nonce   []byte `json:"nonce"`
+	Data    []byte `json:"data"`
+}
+
+// DefaultFile returns the credentials file in the aig config directory
+func DefaultFile() (*File, error) {
+	dir, err := configDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get config directory: %w", err)
+	}
+	return NewFile(filepath.Join(dir, FileName)), nil
+}
+
+// NewFile returns a credentials file store at path
+func NewFile(path string) *File {
+	return &File{path: path}
+}
+
+// Name describes the store for status output
+func (f *File) Name() string {
+	return fmt.Sprintf("encrypted file (%s)", f.path)
+}
+
+// Get returns the key stored for provider
+func (f *File) Get(provider string) (string, error) {
+	keys, err := f.read()
+	if err != nil {
+		return "", err
+	}
+	key, ok := keys[provider]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return key, nil
+}
+
+// Set stores the key for provider
+func (f *File) Set(provider, key string) error {
+	keys, err := f.read()
+	if err != nil {
+		return err
+	}
+	keys[provider] = key
+	return f.write(keys)
+}
+
+// Delete removes the key for provider
+func (f *File) Delete(provider string) error {
+	keys, err := f.read()
+	if err != nil {
+		return err
+	}
+	if _, ok := keys[provider]; !ok {
+		return nil
+	}
+	delete(keys, provider)
+	return f.write(keys)
+}
+
+func (f *File) read() (map[string]string, error) {
+	keys := make(map[string]string)
+
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return keys, nil
+		}
+		return nil, fmt.Errorf("failed to read credentials file: %w", err)
+	}
+
+	var file encryptedFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse credentials file: %w", err)
+	}
+	if file.Version != fileVersion {
+		return nil, fmt.Errorf("unsupported credentials file version %d", file.Version)
+	}
+
+	gcm, err := newGCM(file.Salt)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := gcm.Open(nil, file.Nonce, file.Data, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt credentials file, check %s", PassphraseEnv)
+	}
+
+	if err := json.Unmarshal(plaintext, &keys); err != nil {
+		return nil, fmt.Errorf("failed to parse credentials file: %w", err)
+	}
+	return keys, nil
+}
+
+func (f *File) write(keys map[string]string) error {
+	if len(keys) == 0 {
+		if err := os.Remove(f.path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove credentials file: %w", err)
+		}
+		return nil
+	}
+
+	plaintext, err := json.Marshal(keys)
+	if err != nil {
+		return fmt.Errorf("failed to encode credentials: %w", err)
+	}
+
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return fmt.Errorf("failed to generate salt: %w", err)
+	}
+	gcm, err := newGCM(salt)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	data, err := json.Marshal(encryptedFile{
+		Version: fileVersion,
+		Salt:    salt,
+		Nonce:   nonce,
+		Data:    gcm.Seal(nil, nonce, plaintext, nil),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode credentials file: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(f.path), 0700); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	if err := os.WriteFile(f.path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write credentials file: %w", err)
+	}
+	return nil
+}
+
+// newGCM derives the file key from the passphrase and salt
+func newGCM(salt []byte) (cipher.AEAD, error) {
+	key, err := pbkdf2.Key(sha256.New, passphrase(), salt, kdfIterations, encryptionKeyLen)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive encryption key: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// passphrase returns the passphrase protecting the credentials file. Without
+// AIG_CREDENTIALS_PASSPHRASE it is derived from the machine and user, which keeps
+// keys out of plaintext config files but does not protect them from anyone who
+// can log in as the same user.
+func passphrase() string {
+	if value := os.Getenv(PassphraseEnv); value != "" {
+		return value
+	}
+
+	parts := []string{"aig"}
+	for _, path := range []string{"/etc/machine-id", "/var/lib/dbus/machine-id"} {
+		if id, err := os.ReadFile(path); err == nil {
+			parts = append(parts, strings.TrimSpace(string(id)))
+			break
+		}
+	}
+	if u, err := user.Current(); err == nil {
+		parts = append(parts, u.Uid, u.Username)
+	}
+	return strings.Join(parts, "\x00")
+}
@@ -0,0 +1,64 @@
+package credentials
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileRoundTrip(t *testing.T) {
+	t.Setenv(PassphraseEnv, "correct horse")
+	path := filepath.Join(t.TempDir(), FileName)
+	store := NewFile(path)
+
+	if _, err := store.Get("openai"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound from a missing file, got %v", err)
+	}
+
+	if err := store.Set("openai", "sk-test-0123456789"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Set("gemini", "gem-key"); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "sk-test") {
+		t.Fatal("credentials file contains the plaintext key")
+	}
+
+	key, err := store.Get("openai")
+	if err != nil || key != "sk-test-0123456789" {
+		t.Fatalf("expected stored key, got %q, %v", key, err)
+	}
+
+	t.Setenv(PassphraseEnv, "wrong")
+	if _, err := store.Get("openai"); err == nil || errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected a decryption error with the wrong passphrase, got %v", err)
+	}
+
+	t.Setenv(PassphraseEnv, "correct horse")
+	if err := store.Delete("openai"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Get("openai"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound after delete, got %v", err)
+	}
+	if key, _ := store.Get("gemini"); key != "gem-key" {
+		t.Fatalf("expected the other provider's key to survive, got %q", key)
+	}
+}
+
+func TestMask(t *testing.T) {
+	if got := Mask("sk-abcdefghijklmnop"); got != "sk-a...mnop" {
+		t.Errorf("unexpected mask %q", got)
+	}
+	if got := Mask("short"); got != "********" {
+		t.Errorf("short secrets must be fully masked, got %q", got)
+	}
+}
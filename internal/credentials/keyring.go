@@ -0,0 +1,153 @@
+package credentials
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// service is the name credentials are filed under in the system keyring
+const service = "aig"
+
+// keyringTimeout bounds every call to the keyring helper, which can block when
+// no secret service is running
+const keyringTimeout = 5 * time.Second
+
+// secretTool stores credentials in the Secret Service (GNOME Keyring, KWallet)
+// through the secret-tool command
+type secretTool struct{}
+
+// macKeychain stores credentials in the macOS keychain through the security command
+type macKeychain struct{}
+
+// systemKeyring returns the keyring of the current platform, or nil when its
+// helper command is not installed
+func systemKeyring() Store {
+	switch runtime.GOOS {
+	case "linux", "freebsd", "openbsd":
+		if _, err := exec.LookPath("secret-tool"); err == nil {
+			return secretTool{}
+		}
+	case "darwin":
+		if _, err := exec.LookPath("security"); err == nil {
+			return macKeychain{}
+		}
+	}
+	return nil
+}
+
+func (secretTool) Name() string {
+	return "keyring (Secret Service)"
+}
+
+func (secretTool) Get(provider string) (string, error) {
+	out, err := runKeyring("", "secret-tool", "lookup", "service", service, "provider", provider)
+	if err != nil {
+		// secret-tool exits with status 1 and no output when nothing is stored
+		if isExitError(err) && out == "" {
+			return "", ErrNotFound
+		}
+		return "", err
+	}
+	if out == "" {
+		return "", ErrNotFound
+	}
+	return out, nil
+}
+
+func (secretTool) Set(provider, key string) error {
+	label := fmt.Sprintf("aig %s API key", provider)
+	_, err := runKeyring(key, "secret-tool", "store", "--label="+label, "service", service, "provider", provider)
+	return err
+}
+
+func (secretTool) Delete(provider string) error {
+	_, err := runKeyring("", "secret-tool", "clear", "service", service, "provider", provider)
+	if isExitError(err) {
+		return nil
+	}
+	return err
+}
+
+func (macKeychain) Name() string {
+	return "keyring (macOS Keychain)"
+}
+
+func (macKeychain) Get(provider string) (string, error) {
+	out, err := runKeyring("", "security", "find-generic-password", "-s", service, "-a", provider, "-w")
+	if err != nil {
+		if isExitError(err) {
+			return "", ErrNotFound
+		}
+		return "", err
+	}
+	return out, nil
+}
+
+func (macKeychain) Set(provider, key string) error {
+	// -U updates an existing item instead of failing. security only accepts the
+	// password as an argument, so it is briefly visible in the process list.
+	_, err := runKeyring("", "security", "add-generic-password", "-U", "-s", service, "-a", provider, "-w", key)
+	return err
+}
+
+func (macKeychain) Delete(provider string) error {
+	_, err := runKeyring("", "security", "delete-generic-password", "-s", service, "-a", provider)
+	if isExitError(err) {
+		return nil
+	}
+	return err
+}
+
+// runKeyring runs a keyring helper with the given stdin and returns its trimmed output
+func runKeyring(stdin string, name string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), keyringTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, name, args...)
+	var out bytes.Buffer
+	var stderr bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+	if stdin != "" {
+		cmd.Stdin = strings.NewReader(stdin)
+	}
+
+	err := cmd.Run()
+	if ctx.Err() != nil {
+		return "", fmt.Errorf("%s timed out, is a keyring service running?", name)
+	}
+	if err != nil {
+		if stderr.Len() > 0 {
+			return strings.TrimSpace(out.String()), &keyringError{err: err, stderr: strings.TrimSpace(stderr.String())}
+		}
+		return strings.TrimSpace(out.String()), err
+	}
+
+	return strings.TrimSpace(out.String()), nil
+}
+
+// keyringError keeps the helper's stderr while still unwrapping to *exec.ExitError
+type keyringError struct {
+	err    error
+	stderr string
+}
+
+func (e *keyringError) Error() string {
+	return fmt.Sprintf("%v: %s", e.err, e.stderr)
+}
+
+func (e *keyringError) Unwrap() error {
+	return e.err
+}
+
+// isExitError reports whether the helper ran but exited with a non-zero status
+func isExitError(err error) bool {
+	var exitErr *exec.ExitError
+	return errors.As(err, &exitErr)
+}
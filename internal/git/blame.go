@@ -0,0 +1,124 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// FixupCandidate is a commit that last touched lines a staged change modifies
+type FixupCandidate struct {
+	Hash    string
+	Subject string
+	Score   int // removed lines count twice, context lines next to a change once
+}
+
+var blameHeaderRegex = regexp.MustCompile(`^([0-9a-f]{40}) \d+ (\d+)`)
+
+// BlameLines returns the commit that last touched each line from start to end
+// of path at rev, keyed by line number
+func BlameLines(rev, path string, start, end int) (map[int]string, error) {
+	cmd := exec.Command("git", "blame", "--line-porcelain", "-L", fmt.Sprintf("%d,%d", start, end), rev, "--", path)
+	var out bytes.Buffer
+	var stderr bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if err != nil {
+		return nil, fmt.Errorf("git blame failed: %w, stderr: %s", err, stderr.String())
+	}
+
+	lines := make(map[int]string)
+	scanner := bufio.NewScanner(&out)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if m := blameHeaderRegex.FindStringSubmatch(scanner.Text()); m != nil {
+			lines[atoiDefault(m[2], 0)] = m[1]
+		}
+	}
+	return lines, scanner.Err()
+}
+
+// FindFixupTargets blames the lines of HEAD that the staged diff removes or
+// touches and ranks the commits that last changed them. New files have no
+// history and are skipped.
+func FindFixupTargets(diff string) ([]FixupCandidate, error) {
+	scores := make(map[string]int)
+	for _, f := range ParseDiff(diff) {
+		if f.OldPath == "/dev/null" {
+			continue
+		}
+		for _, h := range f.Hunks {
+			weights := oldLineWeights(h)
+			if len(weights) == 0 {
+				continue
+			}
+			blame, err := BlameLines("HEAD", f.OldPath, h.OldStart, h.OldStart+h.OldLines-1)
+			if err != nil {
+				return nil, err
+			}
+			for line, weight := range weights {
+				if hash, ok := blame[line]; ok {
+					scores[hash] += weight
+				}
+			}
+		}
+	}
+
+	candidates := make([]FixupCandidate, 0, len(scores))
+	for hash, score := range scores {
+		candidates = append(candidates, FixupCandidate{Hash: hash, Score: score})
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].Score != candidates[j].Score {
+			return candidates[i].Score > candidates[j].Score
+		}
+		return candidates[i].Hash < candidates[j].Hash
+	})
+
+	for i := range candidates {
+		message, err := GetCommitMessage(candidates[i].Hash)
+		if err != nil {
+			return nil, err
+		}
+		candidates[i].Subject = strings.SplitN(message, "\n", 2)[0]
+	}
+	return candidates, nil
+}
+
+// oldLineWeights scores the old-side lines of a hunk by line number. Removed
+// lines weigh 2; context lines directly before or after a change weigh 1, so
+// that pure additions still point at the code they extend.
+func oldLineWeights(h Hunk) map[int]int {
+	weights := make(map[int]int)
+	line := h.OldStart
+	prevChanged := false
+	lastContext := 0
+	for _, l := range h.Lines {
+		switch {
+		case strings.HasPrefix(l, "-"):
+			weights[line] += 2
+			line++
+			prevChanged = true
+		case strings.HasPrefix(l, "+"):
+			if lastContext > 0 && weights[lastContext] == 0 {
+				weights[lastContext] = 1
+			}
+			prevChanged = true
+		case strings.HasPrefix(l, `\`):
+		default:
+			if prevChanged && weights[line] == 0 {
+				weights[line] = 1
+			}
+			lastContext = line
+			line++
+			prevChanged = false
+		}
+	}
+	return weights
+}
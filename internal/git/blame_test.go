@@ -0,0 +1,30 @@
+package git
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestOldLineWeights(t *testing.T) {
+	h := Hunk{
+		OldStart: 10,
+		OldLines: 6,
+		NewStart: 10,
+		NewLines: 6,
+		Lines: []string{
+			" a", // 10
+			" b", // 11, just before an addition
+			"+new",
+			" c", // 12, just after it
+			"-d", // 13
+			"+D",
+			" e", // 14, just after the change
+			" f", // 15
+		},
+	}
+
+	want := map[int]int{11: 1, 12: 1, 13: 2, 14: 1}
+	if got := oldLineWeights(h); !reflect.DeepEqual(got, want) {
+		t.Errorf("oldLineWeights = %v, want %v", got, want)
+	}
+}
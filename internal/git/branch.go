@@ -6,6 +6,8 @@ import (
 	"os/exec"
 	"regexp"
 	"strings"
+
+	"github.com/tarantino19/aig/internal/tickets"
 )
 
 // GetCurrentBranch returns the current git branch name
@@ -24,28 +26,89 @@ func GetCurrentBranch() (string, error) {
 	return strings.TrimSpace(out.String()), nil
 }
 
-// ExtractCommitDetails extracts the commit type and ticket number from the branch name.
-func ExtractCommitDetails(branchName string) (string, string) {
-	branchName = strings.ToLower(branchName)
-	var commitType, ticketNumber string
-
-	// Remove date patterns (YYYYMMDD) from branch name
-	dateRegex := regexp.MustCompile(`\d{8}`)
-	branchName = dateRegex.ReplaceAllString(branchName, "")
-
-	// Extract ticket number
-	re := regexp.MustCompile(`(\d{4,5})`)
-	matches := re.FindStringSubmatch(branchName)
-	if len(matches) > 1 {
-		ticketNumber = matches[1]
+// DefaultBranchRules map branch name patterns to commit types, see ParseBranchRules
+var DefaultBranchRules = []string{
+	"feature/*=feat",
+	"feat/*=feat",
+	"fix/*=fix",
+	"bugfix/*=fix",
+	"hotfix/*=fix",
+	"docs/*=docs",
+	"chore/*=chore",
+	"refactor/*=refactor",
+	"perf/*=perf",
+	"test/*=test",
+	"ci/*=ci",
+	"build/*=build",
+	"style/*=style",
+	"release/*=chore",
+}
+
+// BranchRule maps branch names matching Pattern to a commit type
+type BranchRule struct {
+	Pattern string
+	Type    string
+
+	re *regexp.Regexp
+}
+
+// ParseBranchRules parses rules written as pattern=type. Patterns are matched
+// against the whole branch name, ignoring case; * matches any run of characters,
+// including /, and ? matches a single character.
+func ParseBranchRules(rules []string) ([]BranchRule, error) {
+	parsed := make([]BranchRule, 0, len(rules))
+	for _, rule := range rules {
+		pattern, commitType, found := strings.Cut(rule, "=")
+		pattern, commitType = strings.TrimSpace(pattern), strings.TrimSpace(commitType)
+		if !found || pattern == "" || commitType == "" {
+			return nil, fmt.Errorf("invalid branch rule %q, expected pattern=type such as docs/*=docs", rule)
+		}
+		parsed = append(parsed, BranchRule{Pattern: pattern, Type: commitType, re: globRegexp(pattern)})
+	}
+	return parsed, nil
+}
+
+// MatchBranchType returns the type of the first rule matching the branch name, or ""
+func MatchBranchType(branchName string, rules []BranchRule) string {
+	for _, rule := range rules {
+		if rule.re.MatchString(branchName) {
+			return rule.Type
+		}
 	}
+	return ""
+}
 
-	// Determine commit type
-	if strings.Contains(branchName, "fix") || strings.Contains(branchName, "bugfix") {
-		commitType = "fix"
-	} else if strings.Contains(branchName, "feature") || strings.Contains(branchName, "feat") {
-		commitType = "feat"
+// globRegexp compiles a branch pattern to an anchored, case-insensitive regexp
+func globRegexp(pattern string) *regexp.Regexp {
+	var expr strings.Builder
+	expr.WriteString("(?i)^")
+	for _, r := range pattern {
+		switch r {
+		case '*':
+			expr.WriteString(".*")
+		case '?':
+			expr.WriteString(".")
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(r)))
+		}
 	}
+	expr.WriteString("$")
+	return regexp.MustCompile(expr.String())
+}
 
-	return commitType, ticketNumber
+// ExtractCommitDetails extracts the commit type and ticket key from the branch
+// name using DefaultBranchRules and the default ticket patterns
+func ExtractCommitDetails(branchName string) (string, string) {
+	rules, _ := ParseBranchRules(DefaultBranchRules)
+	return MatchBranchType(branchName, rules), tickets.Default().Find(branchName)
+}
+
+// IsProtectedBranch reports whether a branch name matches one of the patterns
+func IsProtectedBranch(name string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if globRegexp(pattern).MatchString(name) {
+			return true
+		}
+	}
+	return false
 }
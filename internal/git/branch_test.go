@@ -15,9 +15,12 @@ func TestExtractCommitDetails(t *testing.T) {
 		{"bugfix/8765-another-bug", "fix", "8765"},
 		{"feat/4321-add-feature", "feat", "4321"},
 		{"hotfix/9999-critical-issue", "fix", "9999"},
-		{"release/v1.0", "", ""},
-		{"no-ticket-feat", "feat", ""},
-		{"12345-fix-something", "fix", "12345"},
+		{"release/v1.0", "chore", ""},
+		{"docs/1234-readme", "docs", "1234"},
+		{"Refactor/store", "refactor", ""},
+		{"no-ticket-feat", "", ""},
+		{"prefix-cleanup", "", ""},
+		{"12345-fix-something", "", "12345"},
 		{"feature/1234-20250620-new-login", "feat", "1234"},
 	}
 
@@ -33,3 +36,42 @@ func TestExtractCommitDetails(t *testing.T) {
 		})
 	}
 }
+
+func TestParseBranchRules(t *testing.T) {
+	rules, err := ParseBranchRules([]string{"*/docs/*=docs", "deps-*=build", "*=chore"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]string{
+		"jane/docs/readme": "docs",
+		"deps-bump-cobra":  "build",
+		"anything":         "chore",
+	}
+	for branch, want := range tests {
+		if got := MatchBranchType(branch, rules); got != want {
+			t.Errorf("MatchBranchType(%q) = %q, want %q", branch, got, want)
+		}
+	}
+
+	if _, err := ParseBranchRules([]string{"docs/*"}); err == nil {
+		t.Error("expected an error for a rule without a type")
+	}
+}
+
+func TestIsProtectedBranch(t *testing.T) {
+	patterns := []string{"main", "release/*"}
+
+	tests := map[string]bool{
+		"main":         true,
+		"release/1.2":  true,
+		"feature/main": false,
+		"mainline":     false,
+		"releases":     false,
+	}
+	for branch, want := range tests {
+		if got := IsProtectedBranch(branch, patterns); got != want {
+			t.Errorf("IsProtectedBranch(%q) = %v, want %v", branch, got, want)
+		}
+	}
+}
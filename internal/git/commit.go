@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"os/exec"
+	"strings"
 )
 
 // CreateCommit creates a git commit with the given message
@@ -64,4 +65,83 @@ func HasStagedChanges() (bool, error) {
 	}
 	
 	return false, nil // No staged changes
-} 
\ No newline at end of file
+}
+
+// AmendCommit replaces the message of HEAD and adds the staged changes to it,
+// keeping its author and date
+func AmendCommit(message string) error {
+	cmd := exec.Command("git", "commit", "--amend", "--allow-empty", "-m", message)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if err != nil {
+		return fmt.Errorf("git commit --amend failed: %w, stderr: %s", err, stderr.String())
+	}
+
+	return nil
+}
+
+// CreateFixupCommit commits the staged changes as a fixup! commit for target,
+// to be folded into it by git rebase --autosquash
+func CreateFixupCommit(target string, signoff bool) error {
+	args := []string{"commit", "--fixup=" + target}
+	if signoff {
+		args = append(args, "--signoff")
+	}
+	cmd := exec.Command("git", args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if err != nil {
+		return fmt.Errorf("git commit --fixup failed: %w, stderr: %s", err, stderr.String())
+	}
+
+	return nil
+}
+
+// GetCommitMessage returns the full message of a commit
+func GetCommitMessage(rev string) (string, error) {
+	cmd := exec.Command("git", "log", "-1", "--format=%B", rev, "--")
+	var out bytes.Buffer
+	var stderr bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if err != nil {
+		return "", fmt.Errorf("git log failed: %w, stderr: %s", err, stderr.String())
+	}
+
+	return strings.TrimSpace(out.String()), nil
+}
+
+// ResolveCommit returns the full hash of the commit rev names
+func ResolveCommit(rev string) (string, error) {
+	cmd := exec.Command("git", "rev-parse", "--verify", "--quiet", rev+"^{commit}")
+	var out bytes.Buffer
+	cmd.Stdout = &out
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("%s is not a commit", rev)
+	}
+
+	return strings.TrimSpace(out.String()), nil
+}
+
+// IsPushed reports whether a remote-tracking branch already contains the commit
+func IsPushed(rev string) (bool, error) {
+	cmd := exec.Command("git", "branch", "-r", "--contains", rev)
+	var out bytes.Buffer
+	var stderr bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if err != nil {
+		return false, fmt.Errorf("git branch failed: %w, stderr: %s", err, stderr.String())
+	}
+
+	return strings.TrimSpace(out.String()) != "", nil
+}
@@ -23,6 +23,32 @@ func GetStagedDiff() (string, error) {
 	return strings.TrimSpace(out.String()), nil
 }
 
+// emptyTree is the hash of the tree with no files, which every repository knows
+const emptyTree = "4b825dc642cb6eb9a060e54bf8d69288fbee4904"
+
+// GetAmendDiff returns the changes HEAD would hold after amending it with the
+// staged changes: the diff from HEAD's parent to the index
+func GetAmendDiff() (string, error) {
+	base := "HEAD^"
+	if err := exec.Command("git", "rev-parse", "--verify", "--quiet", "HEAD^").Run(); err != nil {
+		// HEAD is the root commit
+		base = emptyTree
+	}
+
+	cmd := exec.Command("git", "diff", "--cached", base)
+	var out bytes.Buffer
+	var stderr bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if err != nil {
+		return "", fmt.Errorf("git diff failed: %w, stderr: %s", err, stderr.String())
+	}
+
+	return strings.TrimSpace(out.String()), nil
+}
+
 // GetDiff returns the diff of unstaged changes
 func GetDiff() (string, error) {
 	cmd := exec.Command("git", "diff")
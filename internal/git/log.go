@@ -13,6 +13,8 @@ type CommitOptions struct {
 	Branch string
 	From   string
 	To     string
+
+	NoMerges bool // leave out merge commits
 }
 
 // Commit represents a git commit
@@ -31,6 +33,10 @@ func GetCommits(opts CommitOptions) ([]Commit, error) {
 		args = append(args, fmt.Sprintf("-n%d", opts.Number))
 	}
 	
+	if opts.NoMerges {
+		args = append(args, "--no-merges")
+	}
+	
 	if opts.Branch != "" {
 		args = append(args, opts.Branch)
 	}
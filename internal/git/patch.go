@@ -0,0 +1,289 @@
+package git
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// FileDiff is the part of a unified diff that touches a single file
+type FileDiff struct {
+	OldPath string
+	NewPath string
+	Header  []string // lines from "diff --git" up to the first hunk
+	Hunks   []Hunk
+}
+
+// Hunk is a single "@@" section of a file diff
+type Hunk struct {
+	Header   string
+	OldStart int
+	OldLines int
+	NewStart int
+	NewLines int
+	Lines    []string
+}
+
+var hunkHeaderRegex = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+// ParseDiff splits unified diff output into per-file sections. Anything before the
+// first "diff --git" line, such as the commit header printed by git show, is dropped.
+func ParseDiff(diff string) []FileDiff {
+	var files []FileDiff
+	var current *FileDiff
+	var hunk *Hunk
+	oldRemaining, newRemaining := 0, 0
+
+	flushHunk := func() {
+		if current != nil && hunk != nil {
+			current.Hunks = append(current.Hunks, *hunk)
+		}
+		hunk = nil
+	}
+
+	for _, line := range strings.Split(diff, "\n") {
+		// Hunk bodies are consumed by line count so that content lines starting
+		// with "---" or "diff" are never mistaken for headers
+		if hunk != nil && (oldRemaining > 0 || newRemaining > 0 || strings.HasPrefix(line, `\`)) {
+			hunk.Lines = append(hunk.Lines, line)
+			switch {
+			case strings.HasPrefix(line, "+"):
+				newRemaining--
+			case strings.HasPrefix(line, "-"):
+				oldRemaining--
+			case strings.HasPrefix(line, `\`):
+			default:
+				oldRemaining--
+				newRemaining--
+			}
+			continue
+		}
+
+		if strings.HasPrefix(line, "diff --git ") {
+			flushHunk()
+			if current != nil {
+				files = append(files, *current)
+			}
+			oldPath, newPath := parseDiffGitLine(line)
+			current = &FileDiff{OldPath: oldPath, NewPath: newPath, Header: []string{line}}
+			continue
+		}
+
+		if current == nil {
+			continue
+		}
+
+		if m := hunkHeaderRegex.FindStringSubmatch(line); m != nil {
+			flushHunk()
+			hunk = &Hunk{
+				Header:   line,
+				OldStart: atoiDefault(m[1], 0),
+				OldLines: atoiDefault(m[2], 1),
+				NewStart: atoiDefault(m[3], 0),
+				NewLines: atoiDefault(m[4], 1),
+			}
+			oldRemaining, newRemaining = hunk.OldLines, hunk.NewLines
+			continue
+		}
+
+		if hunk != nil {
+			// Trailing text after a complete hunk (e.g. a blank line) is kept with it
+			hunk.Lines = append(hunk.Lines, line)
+			continue
+		}
+
+		current.Header = append(current.Header, line)
+		switch {
+		case strings.HasPrefix(line, "--- "):
+			current.OldPath = stripDiffPrefix(strings.TrimPrefix(line, "--- "), "a/")
+		case strings.HasPrefix(line, "+++ "):
+			current.NewPath = stripDiffPrefix(strings.TrimPrefix(line, "+++ "), "b/")
+		}
+	}
+
+	flushHunk()
+	if current != nil {
+		files = append(files, *current)
+	}
+
+	for i := range files {
+		files[i].trimTrailingBlankLines()
+	}
+
+	return files
+}
+
+// Path returns the path the diff applies to, preferring the new name
+func (f FileDiff) Path() string {
+	if f.NewPath != "" && f.NewPath != "/dev/null" {
+		return f.NewPath
+	}
+	return f.OldPath
+}
+
+// IsDeleted reports whether the diff removes the file
+func (f FileDiff) IsDeleted() bool {
+	return f.NewPath == "/dev/null"
+}
+
+// String renders the file diff back into unified diff form
+func (f FileDiff) String() string {
+	lines := append([]string{}, f.Header...)
+	for _, h := range f.Hunks {
+		lines = append(lines, h.Header)
+		lines = append(lines, h.Lines...)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// String renders the hunk including its "@@" header
+func (h Hunk) String() string {
+	return strings.Join(append([]string{h.Header}, h.Lines...), "\n")
+}
+
+// NewLineNumbers returns the line number in the new file for every hunk line.
+// Removed lines map to 0 since they do not exist in the new file.
+func (h Hunk) NewLineNumbers() []int {
+	numbers := make([]int, len(h.Lines))
+	next := h.NewStart
+	for i, line := range h.Lines {
+		switch {
+		case strings.HasPrefix(line, "-"), strings.HasPrefix(line, `\`):
+			numbers[i] = 0
+		default:
+			numbers[i] = next
+			next++
+		}
+	}
+	return numbers
+}
+
+// JoinDiffs renders file diffs back into a single unified diff
+func JoinDiffs(files []FileDiff) string {
+	parts := make([]string, 0, len(files))
+	for _, f := range files {
+		parts = append(parts, f.String())
+	}
+	return strings.Join(parts, "\n")
+}
+
+// TrimDiff shortens a diff to about maxBytes. Hunks are kept in order while they
+// fit; files whose hunks are dropped keep their header so that the diff still
+// shows every changed file. It returns the trimmed diff and the paths whose
+// hunks were dropped.
+func TrimDiff(diff string, maxBytes int) (string, []string) {
+	if len(diff) <= maxBytes {
+		return diff, nil
+	}
+	files := ParseDiff(diff)
+	if len(files) == 0 {
+		return diff[:maxBytes], nil
+	}
+
+	budget := maxBytes
+	for _, f := range files {
+		budget -= len(strings.Join(f.Header, "\n")) + 1
+	}
+
+	var trimmed []string
+	for i := range files {
+		var kept []Hunk
+		for _, h := range files[i].Hunks {
+			size := len(h.String()) + 1
+			if size > budget {
+				break
+			}
+			budget -= size
+			kept = append(kept, h)
+		}
+		if len(kept) < len(files[i].Hunks) {
+			trimmed = append(trimmed, files[i].Path())
+		}
+		files[i].Hunks = kept
+	}
+
+	return JoinDiffs(files), trimmed
+}
+
+// FilterDiff drops every file section for which exclude returns true.
+// It returns the remaining diff and the paths that were removed.
+func FilterDiff(diff string, exclude func(path string) bool) (string, []string) {
+	files := ParseDiff(diff)
+	if len(files) == 0 {
+		return diff, nil
+	}
+
+	var kept []FileDiff
+	var removed []string
+	for _, f := range files {
+		if exclude(f.Path()) || (f.OldPath != f.Path() && f.OldPath != "/dev/null" && exclude(f.OldPath)) {
+			removed = append(removed, f.Path())
+			continue
+		}
+		kept = append(kept, f)
+	}
+
+	if len(removed) == 0 {
+		return diff, nil
+	}
+
+	return JoinDiffs(kept), removed
+}
+
+func (f *FileDiff) trimTrailingBlankLines() {
+	if len(f.Hunks) == 0 {
+		return
+	}
+	last := &f.Hunks[len(f.Hunks)-1]
+	for len(last.Lines) > 0 && last.Lines[len(last.Lines)-1] == "" {
+		last.Lines = last.Lines[:len(last.Lines)-1]
+	}
+}
+
+func parseDiffGitLine(line string) (string, string) {
+	rest := strings.TrimPrefix(line, "diff --git ")
+
+	// Quoted paths are used when names contain special characters
+	if strings.HasPrefix(rest, `"`) {
+		if end := strings.Index(rest[1:], `" `); end >= 0 {
+			oldPath := unquotePath(rest[:end+2])
+			newPath := unquotePath(strings.TrimSpace(rest[end+3:]))
+			return stripDiffPrefix(oldPath, "a/"), stripDiffPrefix(newPath, "b/")
+		}
+	}
+
+	// Unquoted paths are split on " b/", which is ambiguous only for names containing it
+	if idx := strings.Index(rest, " b/"); idx >= 0 {
+		return stripDiffPrefix(rest[:idx], "a/"), stripDiffPrefix(rest[idx+1:], "b/")
+	}
+
+	return rest, rest
+}
+
+func stripDiffPrefix(path, prefix string) string {
+	path = unquotePath(strings.TrimSpace(strings.SplitN(path, "\t", 2)[0]))
+	if path == "/dev/null" {
+		return path
+	}
+	return strings.TrimPrefix(path, prefix)
+}
+
+func unquotePath(path string) string {
+	if strings.HasPrefix(path, `"`) {
+		if unquoted, err := strconv.Unquote(path); err == nil {
+			return unquoted
+		}
+	}
+	return path
+}
+
+func atoiDefault(s string, def int) int {
+	if s == "" {
+		return def
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return def
+	}
+	return n
+}
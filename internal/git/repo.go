@@ -0,0 +1,88 @@
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// GetRepoRoot returns the absolute path of the top-level directory of the repository
+func GetRepoRoot() (string, error) {
+	cmd := exec.Command("git", "rev-parse", "--show-toplevel")
+	var out bytes.Buffer
+	var stderr bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if err != nil {
+		return "", fmt.Errorf("git rev-parse failed: %w, stderr: %s", err, stderr.String())
+	}
+
+	return strings.TrimSpace(out.String()), nil
+}
+
+// GetGitDir returns the absolute path of the repository's git directory
+func GetGitDir() (string, error) {
+	cmd := exec.Command("git", "rev-parse", "--absolute-git-dir")
+	var out bytes.Buffer
+	var stderr bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if err != nil {
+		return "", fmt.Errorf("git rev-parse failed: %w, stderr: %s", err, stderr.String())
+	}
+
+	return strings.TrimSpace(out.String()), nil
+}
+
+// GetHooksDir returns the absolute path of the directory git runs hooks from,
+// honouring core.hooksPath
+func GetHooksDir() (string, error) {
+	cmd := exec.Command("git", "rev-parse", "--git-path", "hooks")
+	var out bytes.Buffer
+	var stderr bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if err != nil {
+		return "", fmt.Errorf("git rev-parse failed: %w, stderr: %s", err, stderr.String())
+	}
+
+	return filepath.Abs(strings.TrimSpace(out.String()))
+}
+
+// GetHeadCommit returns the hash of the commit HEAD points to
+func GetHeadCommit() (string, error) {
+	cmd := exec.Command("git", "rev-parse", "HEAD")
+	var out bytes.Buffer
+	var stderr bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if err != nil {
+		return "", fmt.Errorf("git rev-parse failed: %w, stderr: %s", err, stderr.String())
+	}
+
+	return strings.TrimSpace(out.String()), nil
+}
+
+// GetUserEmail returns the configured user.email, empty when it is not set
+func GetUserEmail() string {
+	cmd := exec.Command("git", "config", "user.email")
+	var out bytes.Buffer
+	cmd.Stdout = &out
+
+	// git config exits with 1 when the key is not set
+	if err := cmd.Run(); err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(out.String())
+}
@@ -0,0 +1,308 @@
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// BackupRefPrefix is where backups of rewritten branches are kept
+const BackupRefPrefix = "refs/aig/backup/"
+
+// RangeCommit is a commit of a branch range with its full message
+type RangeCommit struct {
+	Hash    string
+	Parents []string
+	Author  string // "Name <email>"
+	Message string
+}
+
+// Subject returns the first line of the commit message
+func (c RangeCommit) Subject() string {
+	return strings.SplitN(c.Message, "\n", 2)[0]
+}
+
+// IsMerge reports whether the commit has more than one parent
+func (c RangeCommit) IsMerge() bool {
+	return len(c.Parents) > 1
+}
+
+// GetRangeCommits returns the commits reachable from HEAD but not from base,
+// oldest first
+func GetRangeCommits(base string) ([]RangeCommit, error) {
+	cmd := exec.Command("git", "log", "--reverse", "--topo-order", "--format=%H%x00%P%x00%an <%ae>%x00%B%x1e", base+"..HEAD", "--")
+	var out bytes.Buffer
+	var stderr bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if err != nil {
+		return nil, fmt.Errorf("git log failed: %w, stderr: %s", err, stderr.String())
+	}
+
+	var commits []RangeCommit
+	for _, record := range strings.Split(out.String(), "\x1e") {
+		parts := strings.SplitN(strings.TrimLeft(record, "\n"), "\x00", 4)
+		if len(parts) != 4 {
+			continue
+		}
+		commits = append(commits, RangeCommit{
+			Hash:    parts[0],
+			Parents: strings.Fields(parts[1]),
+			Author:  parts[2],
+			Message: strings.TrimSpace(parts[3]),
+		})
+	}
+	return commits, nil
+}
+
+// GetMergeBase returns the best common ancestor of HEAD and the given branch
+func GetMergeBase(branch string) (string, error) {
+	cmd := exec.Command("git", "merge-base", "HEAD", branch)
+	var out bytes.Buffer
+	var stderr bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if err != nil {
+		return "", fmt.Errorf("git merge-base %s failed: %w, stderr: %s", branch, err, stderr.String())
+	}
+
+	return strings.TrimSpace(out.String()), nil
+}
+
+// PublishedRefs returns the refs whose history must not be rewritten: local and
+// remote-tracking branches matching the protected patterns, and the upstream of
+// the current branch
+func PublishedRefs(patterns []string) ([]string, error) {
+	cmd := exec.Command("git", "for-each-ref", "--format=%(refname)", "refs/heads", "refs/remotes")
+	var out bytes.Buffer
+	var stderr bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if err != nil {
+		return nil, fmt.Errorf("git for-each-ref failed: %w, stderr: %s", err, stderr.String())
+	}
+
+	var refs []string
+	for _, ref := range strings.Fields(out.String()) {
+		var name string
+		switch {
+		case strings.HasPrefix(ref, "refs/heads/"):
+			name = strings.TrimPrefix(ref, "refs/heads/")
+		case strings.HasSuffix(ref, "/HEAD"):
+			continue
+		default:
+			// refs/remotes/<remote>/<branch>
+			parts := strings.SplitN(strings.TrimPrefix(ref, "refs/remotes/"), "/", 2)
+			if len(parts) != 2 {
+				continue
+			}
+			name = parts[1]
+		}
+		if IsProtectedBranch(name, patterns) {
+			refs = append(refs, ref)
+		}
+	}
+
+	upstream := exec.Command("git", "rev-parse", "--symbolic-full-name", "@{upstream}")
+	var upstreamOut bytes.Buffer
+	upstream.Stdout = &upstreamOut
+	// Branches without an upstream make this fail, which is fine
+	if upstream.Run() == nil {
+		if ref := strings.TrimSpace(upstreamOut.String()); ref != "" {
+			refs = append(refs, ref)
+		}
+	}
+
+	return refs, nil
+}
+
+// PublishedCommits returns which of the commits are reachable from refs
+func PublishedCommits(commits []RangeCommit, refs []string) (map[string]bool, error) {
+	published := make(map[string]bool)
+	if len(refs) == 0 || len(commits) == 0 {
+		return published, nil
+	}
+
+	// Everything reachable from HEAD minus what the refs reach is unpublished
+	args := append([]string{"rev-list", "HEAD", "--not"}, refs...)
+	cmd := exec.Command("git", args...)
+	var out bytes.Buffer
+	var stderr bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if err != nil {
+		return nil, fmt.Errorf("git rev-list failed: %w, stderr: %s", err, stderr.String())
+	}
+
+	unpublished := make(map[string]bool)
+	for _, hash := range strings.Fields(out.String()) {
+		unpublished[hash] = true
+	}
+	for _, c := range commits {
+		if !unpublished[c.Hash] {
+			published[c.Hash] = true
+		}
+	}
+	return published, nil
+}
+
+// RewriteMessages recreates the commits, oldest first, with the messages given
+// by hash. Trees, parents outside the list, authors, committers and dates are
+// kept, so only the hashes and messages change. Commits before the first new
+// message keep their hashes. It returns the new hash of the last commit; no ref
+// is updated.
+func RewriteMessages(commits []RangeCommit, messages map[string]string) (string, error) {
+	rewritten := make(map[string]string)
+	tip := ""
+
+	for _, c := range commits {
+		message, changed := messages[c.Hash]
+		for _, parent := range c.Parents {
+			if _, ok := rewritten[parent]; ok {
+				changed = true
+			}
+		}
+		if !changed {
+			tip = c.Hash
+			continue
+		}
+		if message == "" {
+			message = c.Message
+		}
+
+		meta, err := commitMetadata(c.Hash)
+		if err != nil {
+			return "", err
+		}
+
+		args := []string{"commit-tree", meta.tree}
+		for _, parent := range c.Parents {
+			if p, ok := rewritten[parent]; ok {
+				parent = p
+			}
+			args = append(args, "-p", parent)
+		}
+
+		cmd := exec.Command("git", args...)
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME="+meta.authorName,
+			"GIT_AUTHOR_EMAIL="+meta.authorEmail,
+			"GIT_AUTHOR_DATE="+meta.authorDate,
+			"GIT_COMMITTER_NAME="+meta.committerName,
+			"GIT_COMMITTER_EMAIL="+meta.committerEmail,
+			"GIT_COMMITTER_DATE="+meta.committerDate,
+		)
+		cmd.Stdin = strings.NewReader(message + "\n")
+		var out bytes.Buffer
+		var stderr bytes.Buffer
+		cmd.Stdout = &out
+		cmd.Stderr = &stderr
+
+		if err := cmd.Run(); err != nil {
+			return "", fmt.Errorf("git commit-tree failed for %s: %w, stderr: %s", c.Hash, err, stderr.String())
+		}
+
+		tip = strings.TrimSpace(out.String())
+		rewritten[c.Hash] = tip
+	}
+
+	return tip, nil
+}
+
+type commitMeta struct {
+	tree           string
+	authorName     string
+	authorEmail    string
+	authorDate     string
+	committerName  string
+	committerEmail string
+	committerDate  string
+}
+
+// commitMetadata reads the tree and identities of a commit, with dates in the
+// raw form git accepts back unchanged
+func commitMetadata(hash string) (*commitMeta, error) {
+	cmd := exec.Command("git", "log", "-1", "--date=raw", "--format=%T%x00%an%x00%ae%x00%ad%x00%cn%x00%ce%x00%cd", hash, "--")
+	var out bytes.Buffer
+	var stderr bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if err != nil {
+		return nil, fmt.Errorf("git log failed: %w, stderr: %s", err, stderr.String())
+	}
+
+	parts := strings.Split(strings.TrimSpace(out.String()), "\x00")
+	if len(parts) != 7 {
+		return nil, fmt.Errorf("unexpected git log output for %s", hash)
+	}
+	return &commitMeta{
+		tree:           parts[0],
+		authorName:     parts[1],
+		authorEmail:    parts[2],
+		authorDate:     parts[3],
+		committerName:  parts[4],
+		committerEmail: parts[5],
+		committerDate:  parts[6],
+	}, nil
+}
+
+// CreateSquashCommit creates a commit with HEAD's tree on top of parent and
+// returns its hash; no ref is updated
+func CreateSquashCommit(parent, message string) (string, error) {
+	cmd := exec.Command("git", "commit-tree", "HEAD^{tree}", "-p", parent)
+	cmd.Stdin = strings.NewReader(message + "\n")
+	var out bytes.Buffer
+	var stderr bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if err != nil {
+		return "", fmt.Errorf("git commit-tree failed: %w, stderr: %s", err, stderr.String())
+	}
+
+	return strings.TrimSpace(out.String()), nil
+}
+
+// CreateBackupRef points a new ref under BackupRefPrefix at the branch's
+// current tip and returns its name
+func CreateBackupRef(branch string) (string, error) {
+	ref := fmt.Sprintf("%s%s/%d", BackupRefPrefix, branch, time.Now().Unix())
+	cmd := exec.Command("git", "update-ref", ref, "refs/heads/"+branch)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if err != nil {
+		return "", fmt.Errorf("git update-ref failed: %w, stderr: %s", err, stderr.String())
+	}
+
+	return ref, nil
+}
+
+// UpdateBranch moves a branch from oldTip to newTip, failing if it moved in between
+func UpdateBranch(branch, newTip, oldTip, reason string) error {
+	cmd := exec.Command("git", "update-ref", "-m", reason, "refs/heads/"+branch, newTip, oldTip)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if err != nil {
+		return fmt.Errorf("git update-ref failed: %w, stderr: %s", err, stderr.String())
+	}
+
+	return nil
+}
@@ -0,0 +1,307 @@
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// StagedHunk is one independently stageable part of the staged changes
+type StagedHunk struct {
+	ID   string // H1, H2, ... in diff order
+	File int    // index into StagedChanges.Files
+	Hunk int    // index into the file's hunks, -1 when the hunk covers the whole file
+}
+
+// StagedChanges is the staged diff broken into hunks that can be committed
+// separately. Modified text files are split per hunk. New, deleted, renamed,
+// copied and binary files and mode changes form a single unit each since their
+// header cannot be applied twice.
+type StagedChanges struct {
+	Files []FileDiff
+	Hunks []StagedHunk
+}
+
+// wholeFileMarkers are header lines of changes that cannot be split by hunk
+var wholeFileMarkers = []string{
+	"new file mode",
+	"deleted file mode",
+	"old mode",
+	"rename from",
+	"copy from",
+	"Binary files",
+	"GIT binary patch",
+}
+
+// GetStagedChanges reads the staged diff, including binary contents, and splits it into hunks
+func GetStagedChanges() (*StagedChanges, error) {
+	cmd := exec.Command("git", "diff", "--cached", "--binary", "--no-color", "--no-ext-diff")
+	var out bytes.Buffer
+	var stderr bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if err != nil {
+		return nil, fmt.Errorf("git diff failed: %w, stderr: %s", err, stderr.String())
+	}
+
+	return SplitHunks(ParseDiff(out.String())), nil
+}
+
+// SplitHunks numbers the stageable hunks of parsed file diffs
+func SplitHunks(files []FileDiff) *StagedChanges {
+	changes := &StagedChanges{Files: files}
+	for i, f := range files {
+		if f.splittable() {
+			for j := range f.Hunks {
+				changes.Hunks = append(changes.Hunks, StagedHunk{File: i, Hunk: j})
+			}
+			continue
+		}
+		changes.Hunks = append(changes.Hunks, StagedHunk{File: i, Hunk: -1})
+	}
+	for i := range changes.Hunks {
+		changes.Hunks[i].ID = fmt.Sprintf("H%d", i+1)
+	}
+	return changes
+}
+
+// Path returns the path of the file the hunk belongs to
+func (s *StagedChanges) Path(h StagedHunk) string {
+	return s.Files[h.File].Path()
+}
+
+// Diff renders a single hunk as a diff of its file
+func (s *StagedChanges) Diff(h StagedHunk) string {
+	f := s.Files[h.File]
+	if h.Hunk >= 0 {
+		f.Hunks = []Hunk{f.Hunks[h.Hunk]}
+	}
+	return f.String()
+}
+
+// Unassigned checks that groups only name known hunks, each at most once, and
+// returns the hunks no group names
+func (s *StagedChanges) Unassigned(groups [][]string) ([]string, error) {
+	known := make(map[string]bool, len(s.Hunks))
+	for _, h := range s.Hunks {
+		known[h.ID] = true
+	}
+
+	used := make(map[string]bool)
+	for i, group := range groups {
+		if len(group) == 0 {
+			return nil, fmt.Errorf("commit %d has no hunks", i+1)
+		}
+		for _, id := range group {
+			if !known[id] {
+				return nil, fmt.Errorf("commit %d names unknown hunk %s", i+1, id)
+			}
+			if used[id] {
+				return nil, fmt.Errorf("hunk %s is assigned to more than one commit", id)
+			}
+			used[id] = true
+		}
+	}
+
+	var missing []string
+	for _, h := range s.Hunks {
+		if !used[h.ID] {
+			missing = append(missing, h.ID)
+		}
+	}
+	return missing, nil
+}
+
+// Patch builds a patch that applies the given hunks to the tree the staged
+// diff was taken against. Hunks of a file that are left out shift the new-side
+// line numbers of the ones that follow, so those are recomputed.
+func (s *StagedChanges) Patch(ids []string) string {
+	selected := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		selected[id] = true
+	}
+
+	var files []FileDiff
+	for i, f := range s.Files {
+		var hunks []Hunk
+		whole := false
+		for _, h := range s.Hunks {
+			if h.File != i || !selected[h.ID] {
+				continue
+			}
+			if h.Hunk < 0 {
+				whole = true
+				break
+			}
+			hunks = append(hunks, f.Hunks[h.Hunk])
+		}
+
+		switch {
+		case whole:
+			files = append(files, f)
+		case len(hunks) > 0:
+			f.Hunks = renumberHunks(f.Hunks, hunks)
+			files = append(files, f)
+		}
+	}
+
+	if len(files) == 0 {
+		return ""
+	}
+	return JoinDiffs(files) + "\n"
+}
+
+// renumberHunks fixes the new-side start of the selected hunks as if the other
+// hunks of the file did not exist
+func renumberHunks(all, selected []Hunk) []Hunk {
+	isSelected := make(map[int]bool, len(selected))
+	for _, h := range selected {
+		isSelected[h.OldStart] = true
+	}
+
+	var out []Hunk
+	allDelta, selectedDelta := 0, 0
+	for _, h := range all {
+		delta := h.NewLines - h.OldLines
+		if isSelected[h.OldStart] {
+			// Keep the offset git chose for empty ranges, which name the line before
+			quirk := h.NewStart - h.OldStart - allDelta
+			h.NewStart = h.OldStart + selectedDelta + quirk
+			h.Header = hunkHeaderRegex.ReplaceAllString(h.Header, formatHunkRange(h))
+			out = append(out, h)
+			selectedDelta += delta
+		}
+		allDelta += delta
+	}
+	return out
+}
+
+func formatHunkRange(h Hunk) string {
+	return fmt.Sprintf("@@ -%d,%d +%d,%d @@", h.OldStart, h.OldLines, h.NewStart, h.NewLines)
+}
+
+// splittable reports whether the file's hunks can be applied independently
+func (f FileDiff) splittable() bool {
+	if len(f.Hunks) == 0 {
+		return false
+	}
+	for _, line := range f.Header {
+		for _, marker := range wholeFileMarkers {
+			if strings.HasPrefix(line, marker) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// CommitGroup is one commit of a split: the hunks it takes and its message
+type CommitGroup struct {
+	Hunks   []string
+	Message string
+}
+
+// CommitGroups commits the staged changes as a series of commits, in order.
+// Each commit is staged with git apply --cached on top of the original HEAD so
+// the working tree is never touched. If any step fails, HEAD and the index are
+// restored to where they were.
+func (s *StagedChanges) CommitGroups(groups []CommitGroup) error {
+	head, err := GetHeadCommit()
+	if err != nil {
+		return fmt.Errorf("splitting needs an existing commit to start from: %w", err)
+	}
+	index, err := WriteTree()
+	if err != nil {
+		return err
+	}
+
+	var staged []string
+	for i, group := range groups {
+		staged = append(staged, group.Hunks...)
+		err := ReadTree(head)
+		if err == nil {
+			err = ApplyCachedPatch(s.Patch(staged))
+		}
+		if err == nil {
+			err = CreateCommit(group.Message)
+		}
+		if err != nil {
+			if rollbackErr := restoreSplit(head, index); rollbackErr != nil {
+				return fmt.Errorf("commit %d of %d failed: %v; rollback also failed: %w", i+1, len(groups), err, rollbackErr)
+			}
+			return fmt.Errorf("commit %d of %d failed, HEAD and the index were restored: %w", i+1, len(groups), err)
+		}
+	}
+
+	return nil
+}
+
+// restoreSplit moves HEAD back and restores the staged changes
+func restoreSplit(head, index string) error {
+	if err := ResetSoft(head); err != nil {
+		return err
+	}
+	return ReadTree(index)
+}
+
+// ApplyCachedPatch applies a patch to the index without touching the working tree
+func ApplyCachedPatch(patch string) error {
+	cmd := exec.Command("git", "apply", "--cached", "--whitespace=nowarn", "-")
+	cmd.Stdin = strings.NewReader(patch)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if err != nil {
+		return fmt.Errorf("git apply failed: %w, stderr: %s", err, stderr.String())
+	}
+
+	return nil
+}
+
+// WriteTree writes the index as a tree object and returns its hash
+func WriteTree() (string, error) {
+	cmd := exec.Command("git", "write-tree")
+	var out bytes.Buffer
+	var stderr bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if err != nil {
+		return "", fmt.Errorf("git write-tree failed: %w, stderr: %s", err, stderr.String())
+	}
+
+	return strings.TrimSpace(out.String()), nil
+}
+
+// ReadTree replaces the index with the contents of a tree
+func ReadTree(treeish string) error {
+	cmd := exec.Command("git", "read-tree", treeish)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if err != nil {
+		return fmt.Errorf("git read-tree failed: %w, stderr: %s", err, stderr.String())
+	}
+
+	return nil
+}
+
+// ResetSoft moves HEAD to commit, keeping the index and working tree
+func ResetSoft(commit string) error {
+	cmd := exec.Command("git", "reset", "--soft", commit)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if err != nil {
+		return fmt.Errorf("git reset failed: %w, stderr: %s", err, stderr.String())
+	}
+
+	return nil
+}
@@ -0,0 +1,80 @@
+package git
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const stagedDiff = `diff --git a/list.go b/list.go
+index 1111111..2222222 100644
+--- a/list.go
++++ b/list.go
+@@ -1,3 +1,5 @@ package list
++// List holds items
++// in order
+ type List struct {
+ 	items []string
+ }
+@@ -20,4 +22,3 @@ func (l *List) Add(s string) {
+ func (l *List) Len() int {
+-	// count items
+ 	return len(l.items)
+ }
+diff --git a/list_test.go b/list_test.go
+new file mode 100644
+index 0000000..3333333
+--- /dev/null
++++ b/list_test.go
+@@ -0,0 +1,2 @@
++package list
++// tests`
+
+func TestSplitHunks(t *testing.T) {
+	changes := SplitHunks(ParseDiff(stagedDiff))
+
+	var got []string
+	for _, h := range changes.Hunks {
+		got = append(got, h.ID+" "+changes.Path(h))
+	}
+	want := []string{"H1 list.go", "H2 list.go", "H3 list_test.go"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("hunks = %q, want %q", got, want)
+	}
+	if changes.Hunks[2].Hunk != -1 {
+		t.Errorf("a new file should be a single whole-file hunk")
+	}
+}
+
+func TestPatchRenumbersLaterHunks(t *testing.T) {
+	changes := SplitHunks(ParseDiff(stagedDiff))
+
+	// Without H1 the second hunk no longer moves down by two lines
+	patch := changes.Patch([]string{"H2"})
+	if !strings.Contains(patch, "@@ -20,4 +20,3 @@ func (l *List) Add(s string) {") {
+		t.Errorf("second hunk was not renumbered:\n%s", patch)
+	}
+	if strings.Contains(patch, "List holds items") || strings.Contains(patch, "list_test.go") {
+		t.Errorf("patch contains unselected hunks:\n%s", patch)
+	}
+
+	all := changes.Patch([]string{"H1", "H2", "H3"})
+	if !strings.Contains(all, "@@ -20,4 +22,3 @@") || !strings.HasSuffix(all, "+// tests\n") {
+		t.Errorf("full patch changed:\n%s", all)
+	}
+}
+
+func TestUnassigned(t *testing.T) {
+	changes := SplitHunks(ParseDiff(stagedDiff))
+
+	missing, err := changes.Unassigned([][]string{{"H2"}, {"H3"}})
+	if err != nil || !reflect.DeepEqual(missing, []string{"H1"}) {
+		t.Errorf("Unassigned = %q, %v, want [H1]", missing, err)
+	}
+	if _, err := changes.Unassigned([][]string{{"H1"}, {"H1", "H2"}}); err == nil {
+		t.Error("expected an error for a hunk in two commits")
+	}
+	if _, err := changes.Unassigned([][]string{{"H9"}}); err == nil {
+		t.Error("expected an error for an unknown hunk")
+	}
+}
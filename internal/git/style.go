@@ -0,0 +1,141 @@
+package git
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Limits of the commits used as style examples
+const (
+	minExampleLength = 10
+	maxExampleLength = 72
+	minExampleWords  = 3
+
+	// minScopeUses is how often a scope must appear in history to be offered
+	minScopeUses = 2
+	maxScopes    = 15
+)
+
+// conventionalSubject matches type(scope)!: subject and captures the scope
+var conventionalSubject = regexp.MustCompile(`^[a-zA-Z]+(?:\(([^()]+)\))?!?: \S`)
+
+// CommitStyle is the commit message style of a repository, learned from its history
+type CommitStyle struct {
+	Examples []string // recent subjects that show the style, newest first
+	Scopes   []string // scopes of conventional subjects, most used first
+}
+
+// LearnCommitStyle samples the last sample non-merge commits of the current
+// branch and returns up to examples of them as style examples
+func LearnCommitStyle(sample, examples int) (*CommitStyle, error) {
+	commits, err := GetCommits(CommitOptions{Number: sample, NoMerges: true})
+	if err != nil {
+		return nil, err
+	}
+	return AnalyzeCommitStyle(commits, examples), nil
+}
+
+// AnalyzeCommitStyle picks up to examples well-formed subjects from commits and
+// counts the scopes they use. Commits are expected newest first.
+func AnalyzeCommitStyle(commits []Commit, examples int) *CommitStyle {
+	style := &CommitStyle{}
+	seen := make(map[string]bool)
+	scopeUses := make(map[string]int)
+
+	for _, commit := range commits {
+		subject := strings.TrimSpace(commit.Message)
+
+		if m := conventionalSubject.FindStringSubmatch(subject); m != nil && m[1] != "" {
+			scopeUses[strings.TrimSpace(m[1])]++
+		}
+
+		if len(style.Examples) >= examples || !isStyleExample(commit, subject) {
+			continue
+		}
+		key := strings.ToLower(subject)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		style.Examples = append(style.Examples, subject)
+	}
+
+	for scope, uses := range scopeUses {
+		if uses >= minScopeUses {
+			style.Scopes = append(style.Scopes, scope)
+		}
+	}
+	sort.Slice(style.Scopes, func(i, j int) bool {
+		a, b := style.Scopes[i], style.Scopes[j]
+		if scopeUses[a] != scopeUses[b] {
+			return scopeUses[a] > scopeUses[b]
+		}
+		return a < b
+	})
+	if len(style.Scopes) > maxScopes {
+		style.Scopes = style.Scopes[:maxScopes]
+	}
+
+	return style
+}
+
+// isStyleExample reports whether a commit subject is worth imitating. Work in
+// progress, generated and very short or long subjects are left out.
+func isStyleExample(commit Commit, subject string) bool {
+	if len(subject) < minExampleLength || len(subject) > maxExampleLength {
+		return false
+	}
+	if len(strings.Fields(subject)) < minExampleWords {
+		return false
+	}
+	if strings.Contains(commit.Author, "[bot]") {
+		return false
+	}
+
+	lower := strings.ToLower(subject)
+	for _, prefix := range []string{"fixup!", "squash!", "amend!", "merge ", "revert \"", "wip", "initial commit"} {
+		if strings.HasPrefix(lower, prefix) {
+			return false
+		}
+	}
+	return true
+}
+
+// Limits below which a subject is too terse to describe a change
+const (
+	minSubjectLength = 10
+	minSubjectWords  = 2
+	maxSubjectLength = 100
+)
+
+// vagueSubject matches subjects that name no actual change, such as "fix stuff"
+var vagueSubject = regexp.MustCompile(`(?i)^(?:[a-z]+(?:\([^()]*\))?!?:\s*)?(?:fix(?:e[sd])?|update[sd]?|change[sd]?|tweak(?:s|ed)?|cleanup|clean up|minor|misc|more|small|some|final|temp|tmp|test(?:ing)?|wip|stuff|changes|it|this|things|code|files?|bugs?|work|\s|\.|,|!|and)+$`)
+
+// PoorMessageReason explains why a commit message says too little about its
+// change, or returns "" when it looks fine. fixup!, squash! and amend! subjects
+// are left alone since autosquash depends on them.
+func PoorMessageReason(message string) string {
+	subject := strings.TrimSpace(strings.SplitN(strings.TrimSpace(message), "\n", 2)[0])
+	lower := strings.ToLower(subject)
+
+	for _, prefix := range []string{"fixup!", "squash!", "amend!"} {
+		if strings.HasPrefix(lower, prefix) {
+			return ""
+		}
+	}
+
+	switch {
+	case subject == "":
+		return "empty message"
+	case strings.HasPrefix(lower, "wip"):
+		return "work in progress"
+	case vagueSubject.MatchString(subject):
+		return "vague"
+	case len(subject) < minSubjectLength || len(strings.Fields(subject)) < minSubjectWords:
+		return "too short"
+	case len(subject) > maxSubjectLength:
+		return "subject too long"
+	}
+	return ""
+}
@@ -0,0 +1,61 @@
+package git
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAnalyzeCommitStyle(t *testing.T) {
+	commits := []Commit{
+		{Message: "feat(api): add pagination to list endpoints"},
+		{Message: "wip"},
+		{Message: "fixup! feat(api): add pagination"},
+		{Message: "fix(cli): handle empty config files"},
+		{Message: "chore(deps): bump golang.org/x/net", Author: "dependabot[bot]"},
+		{Message: "fix(api): reject negative page sizes"},
+		{Message: "feat(api): add pagination to list endpoints"},
+		{Message: "docs: describe the ignore file format"},
+		{Message: "fix(cli): print usage on unknown flags"},
+		{Message: "refactor(store): split the writer"},
+	}
+
+	style := AnalyzeCommitStyle(commits, 4)
+
+	wantExamples := []string{
+		"feat(api): add pagination to list endpoints",
+		"fix(cli): handle empty config files",
+		"fix(api): reject negative page sizes",
+		"docs: describe the ignore file format",
+	}
+	if !reflect.DeepEqual(style.Examples, wantExamples) {
+		t.Errorf("examples = %q, want %q", style.Examples, wantExamples)
+	}
+
+	// Scopes used once, such as deps and store, are not offered
+	wantScopes := []string{"api", "cli"}
+	if !reflect.DeepEqual(style.Scopes, wantScopes) {
+		t.Errorf("scopes = %q, want %q", style.Scopes, wantScopes)
+	}
+}
+
+func TestPoorMessageReason(t *testing.T) {
+	tests := map[string]string{
+		"feat(api): add pagination to list endpoints": "",
+		"Add LICENSE file":                 "",
+		"fixup! feat(api): add pagination": "",
+		"wip":                              "work in progress",
+		"WIP: half of the parser":          "work in progress",
+		"fix stuff":                        "vague",
+		"fix: more fixes":                  "vague",
+		"update files":                     "vague",
+		"oops":                             "too short",
+		"refactor":                         "too short",
+		"":                                 "empty message",
+	}
+
+	for message, want := range tests {
+		if got := PoorMessageReason(message); got != want {
+			t.Errorf("PoorMessageReason(%q) = %q, want %q", message, got, want)
+		}
+	}
+}
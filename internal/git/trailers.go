@@ -0,0 +1,119 @@
+package git
+
+import (
+	"regexp"
+	"strings"
+)
+
+// trailerRegex matches "Token: value" and the conventional commit footers
+// "BREAKING CHANGE: value" and "Token #value"
+var trailerRegex = regexp.MustCompile(`^(BREAKING CHANGE|[A-Za-z0-9][A-Za-z0-9-]*)(: | #)\S`)
+
+// ParseTrailers returns the trailer lines of a commit message, such as
+// Signed-off-by or Co-authored-by. Trailers are the lines of the last paragraph
+// when every one of them has the "Token: value" or "Token #value" form.
+func ParseTrailers(message string) []string {
+	paragraphs := strings.Split(strings.TrimSpace(message), "\n\n")
+	if len(paragraphs) < 2 {
+		return nil
+	}
+
+	var trailers []string
+	for _, line := range strings.Split(paragraphs[len(paragraphs)-1], "\n") {
+		line = strings.TrimRight(line, " \t")
+		switch {
+		case trailerRegex.MatchString(line):
+			trailers = append(trailers, line)
+		case (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(trailers) > 0:
+			// A continuation of the previous trailer's value
+			trailers[len(trailers)-1] += "\n" + line
+		default:
+			return nil
+		}
+	}
+	return trailers
+}
+
+// AppendTrailers adds the trailers the message does not already carry to its
+// trailer block, starting one if needed
+func AppendTrailers(message string, trailers []string) string {
+	message = strings.TrimSpace(message)
+	existing := ParseTrailers(message)
+
+	have := make(map[string]bool, len(existing))
+	for _, t := range existing {
+		have[strings.ToLower(t)] = true
+	}
+
+	var missing []string
+	for _, t := range trailers {
+		if !have[strings.ToLower(t)] {
+			have[strings.ToLower(t)] = true
+			missing = append(missing, t)
+		}
+	}
+	if len(missing) == 0 {
+		return message
+	}
+
+	separator := "\n\n"
+	if len(existing) > 0 {
+		separator = "\n"
+	}
+	return message + separator + strings.Join(missing, "\n")
+}
+
+// HasTrailer reports whether the message carries a trailer with the given token
+func HasTrailer(message, token string) bool {
+	for _, t := range ParseTrailers(message) {
+		if strings.EqualFold(strings.SplitN(t, ":", 2)[0], token) {
+			return true
+		}
+	}
+	return false
+}
+
+// breakingHeaderRegex matches a conventional header marked as breaking with "!"
+var breakingHeaderRegex = regexp.MustCompile(`^[a-zA-Z]+(\([^)]*\))?!: (.+)$`)
+
+// issueFooterRegex matches footers that close issues, such as "Fixes #12" or "Closes: PROJ-7"
+var issueFooterRegex = regexp.MustCompile(`(?i)^(fix(es|ed)?|close[sd]?|resolve[sd]?)(: | #)`)
+
+// SquashFooters collects the footers of commits squashed into one: breaking
+// changes (including headers marked with "!"), issue references and
+// co-authors. Authors of the commits other than selfEmail become co-authors too.
+func SquashFooters(commits []RangeCommit, selfEmail string) []string {
+	var breaking, issues, coauthors []string
+	seen := make(map[string]bool)
+	add := func(list *[]string, footer string) {
+		if key := strings.ToLower(footer); !seen[key] {
+			seen[key] = true
+			*list = append(*list, footer)
+		}
+	}
+
+	for _, c := range commits {
+		hasBreaking := false
+		for _, t := range ParseTrailers(c.Message) {
+			token := strings.ToLower(strings.SplitN(t, ":", 2)[0])
+			switch {
+			case token == "breaking change" || token == "breaking-change":
+				hasBreaking = true
+				add(&breaking, "BREAKING CHANGE:"+strings.SplitN(t, ":", 2)[1])
+			case token == "co-authored-by":
+				add(&coauthors, t)
+			case issueFooterRegex.MatchString(t):
+				add(&issues, t)
+			}
+		}
+		if m := breakingHeaderRegex.FindStringSubmatch(c.Subject()); m != nil && !hasBreaking {
+			add(&breaking, "BREAKING CHANGE: "+m[2])
+		}
+
+		if c.Author != "" && (selfEmail == "" || !strings.HasSuffix(strings.ToLower(c.Author), "<"+strings.ToLower(selfEmail)+">")) {
+			add(&coauthors, "Co-authored-by: "+c.Author)
+		}
+	}
+
+	return append(append(breaking, issues...), coauthors...)
+}
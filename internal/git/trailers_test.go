@@ -0,0 +1,72 @@
+package git
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseTrailers(t *testing.T) {
+	tests := []struct {
+		message string
+		want    []string
+	}{
+		{"fix: handle empty input", nil},
+		{"fix: handle empty input\n\nReject blank names early.", nil},
+		{
+			"fix: handle empty input\n\nReject blank names.\n\nFixes: #12\nSigned-off-by: Jane Doe <jane@example.com>\n",
+			[]string{"Fixes: #12", "Signed-off-by: Jane Doe <jane@example.com>"},
+		},
+		{
+			"feat!: drop v1 routes\n\nBREAKING CHANGE: v1 clients must upgrade\nRefs #7",
+			[]string{"BREAKING CHANGE: v1 clients must upgrade", "Refs #7"},
+		},
+		{
+			// A body paragraph that merely starts with "Note: " is not a trailer block
+			"fix: x\n\nNote: this is prose\nthat continues here",
+			nil,
+		},
+	}
+
+	for _, tt := range tests {
+		if got := ParseTrailers(tt.message); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseTrailers(%q) = %q, want %q", tt.message, got, tt.want)
+		}
+	}
+}
+
+func TestAppendTrailers(t *testing.T) {
+	trailers := []string{"Co-authored-by: Ann <ann@example.com>", "Signed-off-by: Jane Doe <jane@example.com>"}
+
+	got := AppendTrailers("feat: add pagination\n\nPages hold 50 items.", trailers)
+	want := "feat: add pagination\n\nPages hold 50 items.\n\nCo-authored-by: Ann <ann@example.com>\nSigned-off-by: Jane Doe <jane@example.com>"
+	if got != want {
+		t.Errorf("AppendTrailers without trailers =\n%s\nwant\n%s", got, want)
+	}
+
+	// Trailers already present are not repeated, new ones join the block
+	got = AppendTrailers("feat: add pagination\n\nsigned-off-by: Jane Doe <jane@example.com>", trailers)
+	want = "feat: add pagination\n\nsigned-off-by: Jane Doe <jane@example.com>\nCo-authored-by: Ann <ann@example.com>"
+	if got != want {
+		t.Errorf("AppendTrailers with trailers =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestSquashFooters(t *testing.T) {
+	commits := []RangeCommit{
+		{Author: "Me <me@example.com>", Message: "feat(api)!: drop v1 routes"},
+		{Author: "Ann <ann@example.com>", Message: "fix: keep old ids\n\nFixes #12\nCo-authored-by: Bob <bob@example.com>"},
+		{Author: "Me <ME@example.com>", Message: "refactor: move handlers\n\nBREAKING CHANGE: handlers moved to pkg/api\nfixes #12"},
+	}
+
+	got := SquashFooters(commits, "me@example.com")
+	want := []string{
+		"BREAKING CHANGE: drop v1 routes",
+		"BREAKING CHANGE: handlers moved to pkg/api",
+		"Fixes #12",
+		"Co-authored-by: Bob <bob@example.com>",
+		"Co-authored-by: Ann <ann@example.com>",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("SquashFooters() =\n%q\nwant\n%q", got, want)
+	}
+}
@@ -0,0 +1,180 @@
+package ignore
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// FileName is the name of the repository-level file listing paths that must never
+// be sent to an AI provider
+const FileName = ".aigignore"
+
+// Matcher matches repository-relative paths against gitignore-style patterns
+type Matcher struct {
+	rules []rule
+}
+
+type rule struct {
+	pattern string
+	regex   *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// Load reads the .aigignore file at the root of the repository.
+// A missing file yields an empty matcher.
+func Load(repoRoot string) (*Matcher, error) {
+	path := filepath.Join(repoRoot, FileName)
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return New(nil), nil
+		}
+		return nil, fmt.Errorf("failed to open %s: %w", FileName, err)
+	}
+	defer file.Close()
+
+	var patterns []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		patterns = append(patterns, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", FileName, err)
+	}
+
+	return New(patterns), nil
+}
+
+// New builds a matcher from gitignore-style patterns. Blank lines and lines
+// starting with "#" are ignored, "!" negates a pattern and a trailing "/"
+// restricts it to directories.
+func New(patterns []string) *Matcher {
+	m := &Matcher{}
+	for _, p := range patterns {
+		p = strings.TrimRight(p, " \t\r")
+		if p == "" || strings.HasPrefix(p, "#") {
+			continue
+		}
+
+		r := rule{pattern: p}
+		if strings.HasPrefix(p, "!") {
+			r.negate = true
+			p = p[1:]
+		} else if strings.HasPrefix(p, `\`) {
+			p = p[1:]
+		}
+		if strings.HasSuffix(p, "/") {
+			r.dirOnly = true
+			p = strings.TrimSuffix(p, "/")
+		}
+		if p == "" {
+			continue
+		}
+
+		r.regex = compilePattern(p)
+		m.rules = append(m.rules, r)
+	}
+	return m
+}
+
+// Empty reports whether the matcher has no patterns
+func (m *Matcher) Empty() bool {
+	return m == nil || len(m.rules) == 0
+}
+
+// Match reports whether the repository-relative path is excluded. A path is
+// also excluded when one of its parent directories is.
+func (m *Matcher) Match(path string) bool {
+	if m.Empty() {
+		return false
+	}
+
+	path = strings.TrimPrefix(filepath.ToSlash(path), "./")
+	segments := strings.Split(path, "/")
+
+	// Check parent directories first; like git, a file inside an excluded
+	// directory cannot be re-included by a negated pattern
+	for i := 1; i < len(segments); i++ {
+		if m.matchOne(strings.Join(segments[:i], "/"), true) {
+			return true
+		}
+	}
+
+	return m.matchOne(path, false)
+}
+
+func (m *Matcher) matchOne(path string, isDir bool) bool {
+	excluded := false
+	for _, r := range m.rules {
+		if r.dirOnly && !isDir {
+			continue
+		}
+		if r.regex.MatchString(path) {
+			excluded = !r.negate
+		}
+	}
+	return excluded
+}
+
+// compilePattern converts a single gitignore-style pattern to a regular expression
+func compilePattern(pattern string) *regexp.Regexp {
+	// Patterns containing a slash are anchored to the repository root,
+	// all others match at any depth
+	anchored := strings.Contains(pattern, "/")
+	pattern = strings.TrimPrefix(pattern, "/")
+
+	var re strings.Builder
+	re.WriteString("^")
+	if !anchored {
+		re.WriteString("(?:.*/)?")
+	}
+
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			re.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "/**") && i+3 == len(pattern):
+			re.WriteString("/.*")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			re.WriteString(".*")
+			i++
+		case c == '*':
+			re.WriteString("[^/]*")
+		case c == '?':
+			re.WriteString("[^/]")
+		case c == '[':
+			if end := strings.IndexByte(pattern[i:], ']'); end > 0 {
+				class := pattern[i+1 : i+end]
+				if strings.HasPrefix(class, "!") {
+					class = "^" + class[1:]
+				}
+				re.WriteString("[" + class + "]")
+				i += end
+			} else {
+				re.WriteString(regexp.QuoteMeta(string(c)))
+			}
+		case c == '\\' && i+1 < len(pattern):
+			i++
+			re.WriteString(regexp.QuoteMeta(string(pattern[i])))
+		default:
+			re.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+
+	re.WriteString("$")
+
+	compiled, err := regexp.Compile(re.String())
+	if err != nil {
+		// Fall back to a literal match for patterns we cannot translate
+		return regexp.MustCompile("^" + regexp.QuoteMeta(pattern) + "$")
+	}
+	return compiled
+}
@@ -0,0 +1,106 @@
+package ignore
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		path     string
+		expected bool
+	}{
+		// Patterns without a slash match at any depth
+		{"basename at root", []string{"*.pem"}, "key.pem", true},
+		{"basename in directory", []string{"*.pem"}, "certs/prod/key.pem", true},
+		{"basename other extension", []string{"*.pem"}, "certs/key.pem.txt", false},
+		{"star stays in segment", []string{"secret*"}, "secrets/config.yaml", true},
+		{"question mark", []string{"id_?sa"}, "home/.ssh/id_rsa", true},
+		{"character class", []string{"file[0-9].txt"}, "file7.txt", true},
+		{"negated character class", []string{"file[!0-9].txt"}, "file7.txt", false},
+
+		// *.env.* catches environment variants but not .env itself
+		{"env variant", []string{"*.env.*"}, ".env.local", true},
+		{"named env variant", []string{"*.env.*"}, "deploy/prod.env.json", true},
+		{"plain env", []string{"*.env.*"}, ".env", false},
+		{"env in name", []string{"*.env.*"}, "environment.go", false},
+
+		// Patterns with a slash are anchored to the root
+		{"leading slash at root", []string{"/config.yaml"}, "config.yaml", true},
+		{"leading slash nested", []string{"/config.yaml"}, "app/config.yaml", false},
+		{"inner slash", []string{"docs/*.md"}, "docs/intro.md", true},
+		{"inner slash nested", []string{"docs/*.md"}, "src/docs/intro.md", false},
+		{"inner slash deeper", []string{"docs/*.md"}, "docs/api/intro.md", false},
+
+		// **
+		{"leading double star", []string{"**/fixtures"}, "a/b/fixtures/data.json", true},
+		{"leading double star at root", []string{"**/fixtures"}, "fixtures/data.json", true},
+		{"middle double star", []string{"a/**/b.txt"}, "a/x/y/b.txt", true},
+		{"middle double star direct", []string{"a/**/b.txt"}, "a/b.txt", true},
+		{"middle double star elsewhere", []string{"a/**/b.txt"}, "c/a/x/b.txt", false},
+		{"trailing double star", []string{"logs/**"}, "logs/2024/app.log", true},
+		{"trailing double star not itself", []string{"logs/**"}, "logs", false},
+
+		// A trailing slash matches directories only, and everything in them
+		{"directory contents", []string{"build/"}, "build/out/main", true},
+		{"directory nested", []string{"build/"}, "cmd/build/main", true},
+		{"directory pattern on a file", []string{"build/"}, "build", false},
+		{"parent directory excludes", []string{"vendor"}, "vendor/pkg/x.go", true},
+
+		// Negation
+		{"negated file", []string{"*.log", "!keep.log"}, "keep.log", false},
+		{"negated other file", []string{"*.log", "!keep.log"}, "drop.log", true},
+		{"negation order", []string{"!keep.log", "*.log"}, "keep.log", true},
+		{"negation inside excluded directory", []string{"secrets/", "!secrets/public.txt"}, "secrets/public.txt", true},
+
+		// Comments, blank lines and escapes
+		{"comment", []string{"# *.go", "", "   "}, "main.go", false},
+		{"escaped hash", []string{`\#notes`}, "#notes", true},
+		{"escaped bang", []string{`\!important`}, "!important", true},
+		{"windows separators", []string{"certs/*.pem"}, `certs\key.pem`, filepath.Separator == '\\'},
+		{"dot slash prefix", []string{"/config.yaml"}, "./config.yaml", true},
+		{"no patterns", nil, "main.go", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := New(tt.patterns).Match(tt.path); got != tt.expected {
+				t.Errorf("New(%q).Match(%q) = %v, want %v", tt.patterns, tt.path, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	root := t.TempDir()
+
+	m, err := Load(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !m.Empty() {
+		t.Error("a missing .aigignore should yield an empty matcher")
+	}
+
+	content := "# never send these\n.env\nsecrets/\r\n!secrets/README.md\n"
+	if err := os.WriteFile(filepath.Join(root, FileName), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if m, err = Load(root); err != nil {
+		t.Fatal(err)
+	}
+	for path, expected := range map[string]bool{
+		".env":              true,
+		"api/.env":          true,
+		"secrets/token":     true,
+		"secrets/README.md": true,
+		"main.go":           false,
+	} {
+		if got := m.Match(path); got != expected {
+			t.Errorf("Match(%q) = %v, want %v", path, got, expected)
+		}
+	}
+}
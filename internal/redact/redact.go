@@ -0,0 +1,348 @@
+// Package redact removes secrets and personal data from text before it is sent
+// to an AI provider
+package redact
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/tarantino19/aig/internal/git"
+	"github.com/tarantino19/aig/internal/ignore"
+)
+
+// Names of the builtin detectors
+const (
+	AWSKey       = "aws_key"
+	PrivateKey   = "private_key"
+	JWT          = "jwt"
+	Token        = "token"
+	HighEntropy  = "high_entropy"
+	Email        = "email"
+	CustomFinder = "custom"
+)
+
+// DefaultDetectors are enabled when no detectors are configured
+var DefaultDetectors = []string{AWSKey, PrivateKey, JWT, Token, HighEntropy, Email}
+
+// DefaultDenyPaths are files whose contents are never sent, in .gitignore syntax
+var DefaultDenyPaths = []string{
+	".env",
+	".env.*",
+	"*.pem",
+	"*.key",
+	"*.p12",
+	"*.pfx",
+	"*.keystore",
+	"id_rsa*",
+	"id_ed25519*",
+	".npmrc",
+	".pypirc",
+	".netrc",
+	"credentials.json",
+}
+
+// Limits of the high entropy detector
+const (
+	minEntropyLength = 20
+	minEntropy       = 4.0 // bits per character
+)
+
+// detector finds one kind of secret. When its expression has a capture group
+// only the group is replaced, so that "password = hunter2" keeps its key.
+type detector struct {
+	kind  string
+	re    *regexp.Regexp
+	check func(match string) bool
+}
+
+var builtin = map[string][]detector{
+	AWSKey: {
+		{kind: AWSKey, re: regexp.MustCompile(`\b(?:AKIA|ASIA|AGPA|AIDA|AROA)[0-9A-Z]{16}\b`)},
+		{kind: AWSKey, re: regexp.MustCompile(`(?i)aws_?secret_?access_?key["']?\s*[:=]\s*["']?([A-Za-z0-9/+=]{40})`)},
+	},
+	PrivateKey: {
+		{kind: PrivateKey, re: regexp.MustCompile(`-----BEGIN[A-Z ]*PRIVATE KEY( BLOCK)?-----[\s\S]*?-----END[A-Z ]*PRIVATE KEY( BLOCK)?-----`)},
+	},
+	JWT: {
+		{kind: JWT, re: regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{8,}\.eyJ[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]{8,}`)},
+	},
+	Token: {
+		{kind: Token, re: regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{40,})\b`)},
+		{kind: Token, re: regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}\b`)},
+		{kind: Token, re: regexp.MustCompile(`\b(?:sk|rk)-(?:proj-|live_|test_)?[A-Za-z0-9_-]{20,}\b`)},
+		{kind: Token, re: regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`)},
+		{kind: Token, re: regexp.MustCompile(`(?i)(?:password|passwd|secret|token|api_?key)["']?\s*[:=]\s*["']([^"'\s]{8,})["']`)},
+	},
+	HighEntropy: {
+		{kind: HighEntropy, re: regexp.MustCompile(`[A-Za-z0-9+/=_-]{20,}`), check: isHighEntropy},
+	},
+	Email: {
+		{kind: Email, re: regexp.MustCompile(`\b[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}\b`), check: isRealEmail},
+	},
+}
+
+// Options configures a Redactor
+type Options struct {
+	Detectors []string // builtin detectors to run, DefaultDetectors when nil
+	Patterns  []string // extra regular expressions, reported as custom
+	DenyPaths []string // files whose contents are never sent
+}
+
+// Redactor replaces secrets in text with placeholders
+type Redactor struct {
+	detectors []detector
+	deny      *ignore.Matcher
+}
+
+// New returns a Redactor for opts
+func New(opts Options) (*Redactor, error) {
+	names := opts.Detectors
+	if names == nil {
+		names = DefaultDetectors
+	}
+
+	r := &Redactor{deny: ignore.New(opts.DenyPaths)}
+	for _, name := range names {
+		found, ok := builtin[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown detector %q, available detectors: %s", name, strings.Join(Detectors(), ", "))
+		}
+		r.detectors = append(r.detectors, found...)
+	}
+	for _, pattern := range opts.Patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction pattern %q: %w", pattern, err)
+		}
+		r.detectors = append(r.detectors, detector{kind: CustomFinder, re: re})
+	}
+
+	// Run the most specific detectors first so that a private key is reported
+	// as one rather than as a run of high entropy lines
+	sort.SliceStable(r.detectors, func(i, j int) bool {
+		return r.detectors[i].kind != HighEntropy && r.detectors[j].kind == HighEntropy
+	})
+
+	return r, nil
+}
+
+// Detectors returns the names of the builtin detectors
+func Detectors() []string {
+	names := make([]string, 0, len(builtin))
+	for name := range builtin {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Match is a secret found by Find
+type Match struct {
+	Kind  string
+	Value string
+	Start int // byte offset of Value in the text
+}
+
+// Find returns every secret in text without replacing it, in order of position
+func (r *Redactor) Find(text string) []Match {
+	var found []Match
+	taken := make(map[int]bool)
+	for _, d := range r.detectors {
+		for _, m := range d.re.FindAllStringSubmatchIndex(text, -1) {
+			start, end := m[0], m[1]
+			if len(m) >= 4 && m[2] >= 0 {
+				start, end = m[2], m[3]
+			}
+			value := text[start:end]
+			// A value found by a more specific detector is reported once
+			if taken[start] || (d.check != nil && !d.check(value)) {
+				continue
+			}
+			taken[start] = true
+			found = append(found, Match{Kind: d.kind, Value: value, Start: start})
+		}
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i].Start < found[j].Start })
+	return found
+}
+
+// Session redacts the texts of a single request. A value found several times,
+// even in different texts, always gets the same placeholder.
+type Session struct {
+	r            *Redactor
+	placeholders map[string]string
+	counts       map[string]int
+	report       Report
+}
+
+// Denies reports whether a file is on the deny list and never sent
+func (r *Redactor) Denies(path string) bool {
+	return !r.deny.Empty() && r.deny.Match(path)
+}
+
+// Session starts redacting a new request
+func (r *Redactor) Session() *Session {
+	return &Session{
+		r:            r,
+		placeholders: make(map[string]string),
+		counts:       make(map[string]int),
+	}
+}
+
+// Report describes what a Session removed
+type Report struct {
+	Findings map[string]int // placeholders per detector
+	Denied   []string       // files left out because of the deny list
+}
+
+// Empty reports whether nothing was removed
+func (r Report) Empty() bool {
+	return len(r.Findings) == 0 && len(r.Denied) == 0
+}
+
+// String summarises the findings, such as "2 email, 1 aws_key"
+func (r Report) String() string {
+	kinds := make([]string, 0, len(r.Findings))
+	for kind := range r.Findings {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+
+	parts := make([]string, 0, len(kinds))
+	for _, kind := range kinds {
+		parts = append(parts, fmt.Sprintf("%d %s", r.Findings[kind], kind))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Report returns what the session has removed so far
+func (s *Session) Report() Report {
+	return s.report
+}
+
+// Diff leaves out the files on the deny list and redacts the rest
+func (s *Session) Diff(diff string) string {
+	if !s.r.deny.Empty() {
+		var denied []string
+		diff, denied = git.FilterDiff(diff, s.r.deny.Match)
+		s.report.Denied = append(s.report.Denied, denied...)
+	}
+	return s.Text(diff)
+}
+
+// Text replaces every secret in text with a placeholder
+func (s *Session) Text(text string) string {
+	for _, d := range s.r.detectors {
+		text = s.replace(text, d)
+	}
+	return text
+}
+
+func (s *Session) replace(text string, d detector) string {
+	matches := d.re.FindAllStringSubmatchIndex(text, -1)
+	if matches == nil {
+		return text
+	}
+
+	var out strings.Builder
+	last := 0
+	for _, m := range matches {
+		start, end := m[0], m[1]
+		if len(m) >= 4 && m[2] >= 0 {
+			start, end = m[2], m[3]
+		}
+		value := text[start:end]
+		if isPlaceholder(value) || (d.check != nil && !d.check(value)) {
+			continue
+		}
+
+		out.WriteString(text[last:start])
+		out.WriteString(s.placeholder(d.kind, value))
+		last = end
+	}
+	out.WriteString(text[last:])
+	return out.String()
+}
+
+// placeholder returns the stable placeholder for a value
+func (s *Session) placeholder(kind, value string) string {
+	if p, ok := s.placeholders[value]; ok {
+		return p
+	}
+	s.counts[kind]++
+	p := fmt.Sprintf("[REDACTED:%s:%d]", kind, s.counts[kind])
+	s.placeholders[value] = p
+
+	if s.report.Findings == nil {
+		s.report.Findings = make(map[string]int)
+	}
+	s.report.Findings[kind]++
+	return p
+}
+
+var placeholderRegex = regexp.MustCompile(`^\[?REDACTED:[a-z_]+:\d+\]?$`)
+
+func isPlaceholder(value string) bool {
+	return placeholderRegex.MatchString(value)
+}
+
+// isHighEntropy reports whether a token looks randomly generated. Hex strings
+// such as commit hashes and checksums are left alone, as are identifiers made
+// only of letters.
+func isHighEntropy(token string) bool {
+	if len(token) < minEntropyLength {
+		return false
+	}
+
+	var hasDigit, hasLetter, hasUpper, hexOnly = false, false, false, true
+	for _, r := range token {
+		switch {
+		case r >= '0' && r <= '9':
+			hasDigit = true
+		case r >= 'a' && r <= 'f':
+			hasLetter = true
+		case r >= 'A' && r <= 'Z':
+			hasLetter, hasUpper, hexOnly = true, true, false
+		case r >= 'a' && r <= 'z':
+			hasLetter, hexOnly = true, false
+		default:
+			hexOnly = false
+		}
+	}
+	if hexOnly || !hasDigit || !hasLetter || !hasUpper {
+		return false
+	}
+
+	return shannonEntropy(token) >= minEntropy
+}
+
+func shannonEntropy(s string) float64 {
+	counts := make(map[rune]int)
+	for _, r := range s {
+		counts[r]++
+	}
+	var entropy float64
+	n := float64(len(s))
+	for _, c := range counts {
+		p := float64(c) / n
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+// isRealEmail leaves out example addresses and git's noreply addresses, which
+// identify nobody
+func isRealEmail(address string) bool {
+	domain := strings.ToLower(address[strings.LastIndex(address, "@")+1:])
+	switch {
+	case domain == "example.com", domain == "example.org", domain == "example.net",
+		strings.HasSuffix(domain, ".example.com"),
+		strings.HasSuffix(domain, "users.noreply.github.com"),
+		domain == "localhost.localdomain":
+		return false
+	}
+	return true
+}
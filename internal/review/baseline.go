@@ -0,0 +1,324 @@
+package review
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tarantino19/aig/internal/ai"
+	"github.com/tarantino19/aig/internal/git"
+)
+
+// BaselineFile is the repository-relative path of the committed review baseline
+const BaselineFile = ".aig/review-baseline.json"
+
+// IgnoreMarker suppresses findings on the surrounding lines when it appears in a code comment
+const IgnoreMarker = "aig:ignore"
+
+// nearbyRadius is the number of lines on each side of a finding used for fingerprints
+const nearbyRadius = 2
+
+// Baseline is the set of accepted findings recorded by "aig review --baseline"
+type Baseline struct {
+	Version     int             `json:"version"`
+	GeneratedAt string          `json:"generated_at"`
+	Findings    []BaselineEntry `json:"findings"`
+}
+
+// BaselineEntry is a single accepted finding
+type BaselineEntry struct {
+	Fingerprint string `json:"fingerprint"`
+	Kind        string `json:"kind"`
+	File        string `json:"file,omitempty"`
+	Description string `json:"description"`
+}
+
+// Finding is a review result of any kind together with its location
+type Finding struct {
+	Kind        string // issue, suggestion, security, performance
+	File        string
+	Line        int
+	Description string
+}
+
+// LoadBaseline reads a baseline file. A missing file yields an empty baseline.
+func LoadBaseline(path string) (*Baseline, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &Baseline{Version: 1}, nil
+		}
+		return nil, fmt.Errorf("failed to read baseline: %w", err)
+	}
+
+	var baseline Baseline
+	if err := json.Unmarshal(data, &baseline); err != nil {
+		return nil, fmt.Errorf("failed to parse baseline %s: %w", path, err)
+	}
+
+	return &baseline, nil
+}
+
+// Save writes the baseline as indented JSON so that it diffs well in review
+func (b *Baseline) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create baseline directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode baseline: %w", err)
+	}
+
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write baseline: %w", err)
+	}
+
+	return nil
+}
+
+// Contains reports whether a fingerprint has been accepted
+func (b *Baseline) Contains(fingerprint string) bool {
+	for _, entry := range b.Findings {
+		if entry.Fingerprint == fingerprint {
+			return true
+		}
+	}
+	return false
+}
+
+// NewBaseline records every finding of a review against the diff it was produced from
+func NewBaseline(review *ai.Review, diff string) *Baseline {
+	files := git.ParseDiff(diff)
+	baseline := &Baseline{
+		Version:     1,
+		GeneratedAt: time.Now().UTC().Format(time.RFC3339),
+	}
+
+	seen := make(map[string]bool)
+	for _, finding := range Findings(review) {
+		fingerprint := Fingerprint(finding, files)
+		if seen[fingerprint] {
+			continue
+		}
+		seen[fingerprint] = true
+		baseline.Findings = append(baseline.Findings, BaselineEntry{
+			Fingerprint: fingerprint,
+			Kind:        finding.Kind,
+			File:        finding.File,
+			Description: finding.Description,
+		})
+	}
+
+	baseline.sortFindings()
+	return baseline
+}
+
+// RecordBaseline accepts every finding of a review in the baseline file at path.
+// The findings accepted before are kept unless replace is set. It returns the
+// number of findings of the review and the number of entries in the file.
+func RecordBaseline(path string, review *ai.Review, diff string, replace bool) (int, int, error) {
+	recorded := NewBaseline(review, diff)
+	baseline := recorded
+	if !replace {
+		var err error
+		if baseline, err = LoadBaseline(path); err != nil {
+			return 0, 0, err
+		}
+		baseline.Merge(recorded)
+	}
+
+	if err := baseline.Save(path); err != nil {
+		return 0, 0, fmt.Errorf("failed to save review baseline: %w", err)
+	}
+	return len(recorded.Findings), len(baseline.Findings), nil
+}
+
+// Merge adds the findings of other that are not accepted yet and returns how
+// many were added. Entries already in the baseline are kept.
+func (b *Baseline) Merge(other *Baseline) int {
+	added := 0
+	for _, entry := range other.Findings {
+		if b.Contains(entry.Fingerprint) {
+			continue
+		}
+		b.Findings = append(b.Findings, entry)
+		added++
+	}
+
+	b.Version = other.Version
+	b.GeneratedAt = other.GeneratedAt
+	b.sortFindings()
+	return added
+}
+
+// sortFindings orders the entries by file and fingerprint so that the file diffs well
+func (b *Baseline) sortFindings() {
+	sort.Slice(b.Findings, func(i, j int) bool {
+		if b.Findings[i].File != b.Findings[j].File {
+			return b.Findings[i].File < b.Findings[j].File
+		}
+		return b.Findings[i].Fingerprint < b.Findings[j].Fingerprint
+	})
+}
+
+// Findings flattens all findings of a review
+func Findings(review *ai.Review) []Finding {
+	var findings []Finding
+	for _, issue := range review.Issues {
+		findings = append(findings, Finding{Kind: "issue", File: issue.File, Line: issue.Line, Description: issue.Description})
+	}
+	for _, suggestion := range review.Suggestions {
+		findings = append(findings, Finding{Kind: "suggestion", File: suggestion.File, Line: suggestion.Line, Description: suggestion.Description})
+	}
+	for _, risk := range review.SecurityRisks {
+		findings = append(findings, Finding{Kind: "security", File: risk.File, Line: risk.Line, Description: risk.Description})
+	}
+	for _, perf := range review.Performance {
+		findings = append(findings, Finding{Kind: "performance", File: perf.File, Line: perf.Line, Description: perf.Description})
+	}
+	return findings
+}
+
+// Fingerprint identifies a finding by its file, normalised description and the
+// code around it, so that it survives unrelated edits that shift line numbers
+func Fingerprint(finding Finding, files []git.FileDiff) string {
+	nearby := nearbyCode(finding, files)
+	for i, line := range nearby {
+		nearby[i] = strings.Join(strings.Fields(line), " ")
+	}
+
+	h := sha256.New()
+	h.Write([]byte(finding.File))
+	h.Write([]byte{0})
+	h.Write([]byte(NormalizeDescription(finding.Description)))
+	h.Write([]byte{0})
+	h.Write([]byte(strings.Join(nearby, "\n")))
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+var (
+	digitsRegex      = regexp.MustCompile(`\d+`)
+	punctuationRegex = regexp.MustCompile(`[^a-z#_]+`)
+)
+
+// NormalizeDescription reduces a finding description to a form that is stable
+// across model runs: lowercase, numbers collapsed and punctuation removed
+func NormalizeDescription(description string) string {
+	normalized := strings.ToLower(description)
+	normalized = digitsRegex.ReplaceAllString(normalized, "#")
+	normalized = punctuationRegex.ReplaceAllString(normalized, " ")
+	return strings.Join(strings.Fields(normalized), " ")
+}
+
+// Suppress removes findings that are in the baseline or marked with an inline
+// aig:ignore comment and returns how many were hidden
+func Suppress(review *ai.Review, diff string, baseline *Baseline) int {
+	files := git.ParseDiff(diff)
+	hidden := 0
+
+	keep := func(kind, file string, line int, description string) bool {
+		finding := Finding{Kind: kind, File: file, Line: line, Description: description}
+		if isIgnoredInline(finding, files) || (baseline != nil && baseline.Contains(Fingerprint(finding, files))) {
+			hidden++
+			return false
+		}
+		return true
+	}
+
+	issues := review.Issues[:0]
+	for _, issue := range review.Issues {
+		if keep("issue", issue.File, issue.Line, issue.Description) {
+			issues = append(issues, issue)
+		}
+	}
+	review.Issues = issues
+
+	suggestions := review.Suggestions[:0]
+	for _, suggestion := range review.Suggestions {
+		if keep("suggestion", suggestion.File, suggestion.Line, suggestion.Description) {
+			suggestions = append(suggestions, suggestion)
+		}
+	}
+	review.Suggestions = suggestions
+
+	risks := review.SecurityRisks[:0]
+	for _, risk := range review.SecurityRisks {
+		if keep("security", risk.File, risk.Line, risk.Description) {
+			risks = append(risks, risk)
+		}
+	}
+	review.SecurityRisks = risks
+
+	performance := review.Performance[:0]
+	for _, perf := range review.Performance {
+		if keep("performance", perf.File, perf.Line, perf.Description) {
+			performance = append(performance, perf)
+		}
+	}
+	review.Performance = performance
+
+	return hidden
+}
+
+// isIgnoredInline reports whether the finding's line or the line above it carries an aig:ignore marker
+func isIgnoredInline(finding Finding, files []git.FileDiff) bool {
+	if finding.File == "" || finding.Line == 0 {
+		return false
+	}
+
+	for _, f := range files {
+		if f.Path() != finding.File {
+			continue
+		}
+		for _, h := range f.Hunks {
+			numbers := h.NewLineNumbers()
+			for i, line := range h.Lines {
+				n := numbers[i]
+				if (n == finding.Line || n == finding.Line-1) && strings.Contains(line, IgnoreMarker) {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
+
+// nearbyCode returns the new-file lines within nearbyRadius of the finding
+func nearbyCode(finding Finding, files []git.FileDiff) []string {
+	if finding.File == "" || finding.Line == 0 {
+		return nil
+	}
+
+	var lines []string
+	for _, f := range files {
+		if f.Path() != finding.File {
+			continue
+		}
+		for _, h := range f.Hunks {
+			numbers := h.NewLineNumbers()
+			for i, line := range h.Lines {
+				n := numbers[i]
+				if n == 0 || n < finding.Line-nearbyRadius || n > finding.Line+nearbyRadius {
+					continue
+				}
+				// Strip the diff marker so that a line reads the same whether it
+				// was added or is unchanged context
+				if len(line) > 0 {
+					line = line[1:]
+				}
+				lines = append(lines, line)
+			}
+		}
+	}
+
+	return lines
+}
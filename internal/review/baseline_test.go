@@ -0,0 +1,130 @@
+package review
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/tarantino19/aig/internal/ai"
+)
+
+const testDiff = `diff --git a/auth/login.go b/auth/login.go
+index 1111111..2222222 100644
+--- a/auth/login.go
++++ b/auth/login.go
+@@ -10,4 +10,6 @@ func Login(user string) error {
+ 	if user == "" {
+ 		return errEmpty
+ 	}
++	query := "SELECT * FROM users WHERE name = '" + user + "'"
++	password := "hunter2" // aig:ignore test fixture
+ 	return nil
+`
+
+func TestNormalizeDescription(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"SQL injection on line 13!", "sql injection on line #"},
+		{"  Possible   `nil` dereference.  ", "possible nil dereference"},
+		{"Use of hardcoded secret (line 14)", "use of hardcoded secret line #"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := NormalizeDescription(tt.input); got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestSuppress(t *testing.T) {
+	newReview := func() *ai.Review {
+		return &ai.Review{
+			Issues: []ai.Issue{
+				{File: "auth/login.go", Line: 13, Description: "SQL injection via string concatenation"},
+				{File: "auth/login.go", Line: 14, Description: "Hardcoded password"},
+			},
+			Suggestions: []ai.Suggestion{
+				{Description: "Consider adding tests"},
+			},
+		}
+	}
+
+	// Only the inline marker applies without a baseline
+	r := newReview()
+	if hidden := Suppress(r, testDiff, nil); hidden != 1 {
+		t.Fatalf("expected 1 finding hidden by aig:ignore, got %d", hidden)
+	}
+	if len(r.Issues) != 1 || r.Issues[0].Line != 13 {
+		t.Fatalf("expected only the SQL injection issue to remain, got %+v", r.Issues)
+	}
+
+	baseline := NewBaseline(r, testDiff)
+	if len(baseline.Findings) != 2 {
+		t.Fatalf("expected 2 baseline entries, got %d", len(baseline.Findings))
+	}
+
+	// The same findings reworded slightly by the model are still recognised
+	r = newReview()
+	r.Issues[0].Description = "SQL Injection via string concatenation."
+	if hidden := Suppress(r, testDiff, baseline); hidden != 3 {
+		t.Fatalf("expected all 3 findings hidden, got %d", hidden)
+	}
+	if len(r.Issues) != 0 || len(r.Suggestions) != 0 {
+		t.Fatalf("expected no remaining findings, got %+v %+v", r.Issues, r.Suggestions)
+	}
+
+	// A new finding is reported
+	r = newReview()
+	r.Issues = append(r.Issues, ai.Issue{File: "auth/login.go", Line: 13, Description: "Missing input validation"})
+	Suppress(r, testDiff, baseline)
+	if len(r.Issues) != 1 || r.Issues[0].Description != "Missing input validation" {
+		t.Fatalf("expected the new finding to remain, got %+v", r.Issues)
+	}
+}
+
+func TestRecordBaseline(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".aig", "review-baseline.json")
+	first := func() *ai.Review {
+		return &ai.Review{Issues: []ai.Issue{{File: "auth/login.go", Line: 13, Description: "SQL injection via string concatenation"}}}
+	}
+	second := func() *ai.Review {
+		return &ai.Review{Suggestions: []ai.Suggestion{{Description: "Consider adding tests"}}}
+	}
+
+	if recorded, total, err := RecordBaseline(path, first(), testDiff, false); err != nil || recorded != 1 || total != 1 {
+		t.Fatalf("first record = %d, %d, %v, want 1 of 1", recorded, total, err)
+	}
+
+	// A later baseline of other findings keeps the earlier ones
+	if recorded, total, err := RecordBaseline(path, second(), testDiff, false); err != nil || recorded != 1 || total != 2 {
+		t.Fatalf("second record = %d, %d, %v, want 1 of 2", recorded, total, err)
+	}
+	// Recording a finding again does not duplicate it
+	if _, total, err := RecordBaseline(path, first(), testDiff, false); err != nil || total != 2 {
+		t.Fatalf("repeated record total = %d, %v, want 2", total, err)
+	}
+
+	baseline, err := LoadBaseline(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, review := range []*ai.Review{first(), second()} {
+		if hidden := Suppress(review, testDiff, baseline); hidden != 1 {
+			t.Errorf("expected the merged baseline to hide the finding, hidden %d", hidden)
+		}
+	}
+
+	// Replacing starts over with only the current findings
+	if recorded, total, err := RecordBaseline(path, second(), testDiff, true); err != nil || recorded != 1 || total != 1 {
+		t.Fatalf("replace = %d, %d, %v, want 1 of 1", recorded, total, err)
+	}
+	if baseline, err = LoadBaseline(path); err != nil {
+		t.Fatal(err)
+	}
+	if len(baseline.Findings) != 1 || baseline.Findings[0].Kind != "suggestion" {
+		t.Errorf("replaced baseline = %+v, want only the suggestion", baseline.Findings)
+	}
+}
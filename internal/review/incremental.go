@@ -0,0 +1,295 @@
+package review
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/tarantino19/aig/internal/ai"
+	"github.com/tarantino19/aig/internal/cache"
+	"github.com/tarantino19/aig/internal/git"
+	"github.com/tarantino19/aig/pkg/prompts"
+)
+
+// CacheNamespace is the cache store holding per-hunk review results
+const CacheNamespace = "review"
+
+// Incremental reviews a diff hunk by hunk, reusing cached findings for hunks
+// that were already reviewed with the same prompt version and model. The hunks
+// that are not cached are reviewed together in a single request.
+type Incremental struct {
+	Provider     ai.Provider
+	Cache        *cache.Store // nil reviews every hunk without reading or writing the cache
+	ProviderName string
+	Model        string
+
+	// PerHunk sends every uncached hunk in a request of its own, for diffs too
+	// long for the model to review at once
+	PerHunk bool
+}
+
+// cachedHunkReview is the cache entry for one hunk. The hunk start is kept so that
+// line numbers can be moved along when the hunk shifts within its file.
+type cachedHunkReview struct {
+	NewStart int       `json:"new_start"`
+	Review   ai.Review `json:"review"`
+}
+
+// IncrementalStats reports how much of a review was served from the cache
+type IncrementalStats struct {
+	Hunks    int
+	Cached   int
+	Reviewed int
+	Skipped  int // hunks of files that may not be sent
+	Requests int // review requests sent to the provider
+}
+
+// pendingHunk is a hunk that is not in the cache yet
+type pendingHunk struct {
+	file git.FileDiff
+	hunk git.Hunk
+	key  string
+}
+
+// withholder is implemented by providers that never send some files
+type withholder interface {
+	Withholds(path string) bool
+}
+
+// Review reviews every hunk of diff and merges the results into a single review
+func (r *Incremental) Review(ctx context.Context, diff string, options ai.ReviewOptions) (*ai.Review, IncrementalStats, error) {
+	var stats IncrementalStats
+	var results []ai.Review
+	var pending []pendingHunk
+
+	w, _ := r.Provider.(withholder)
+	for _, file := range git.ParseDiff(diff) {
+		for _, hunk := range file.Hunks {
+			stats.Hunks++
+			if w != nil && w.Withholds(file.Path()) {
+				stats.Skipped++
+				continue
+			}
+
+			key := r.hunkKey(file.Path(), hunk, options)
+			var entry cachedHunkReview
+			found := false
+			if r.Cache != nil {
+				var err error
+				if found, err = r.Cache.Get(key, &entry); err != nil {
+					return nil, stats, err
+				}
+			}
+			if !found {
+				pending = append(pending, pendingHunk{file: file, hunk: hunk, key: key})
+				continue
+			}
+
+			shiftFindings(&entry.Review, hunk.NewStart-entry.NewStart)
+			results = append(results, entry.Review)
+			stats.Cached++
+		}
+	}
+
+	batches := [][]pendingHunk{pending}
+	if r.PerHunk {
+		batches = make([][]pendingHunk, len(pending))
+		for i := range pending {
+			batches[i] = pending[i : i+1]
+		}
+	}
+	for _, batch := range batches {
+		if len(batch) == 0 {
+			continue
+		}
+		reviewed, err := r.Provider.ReviewCode(ctx, batchDiff(batch), options)
+		stats.Requests++
+		if errors.Is(err, ai.ErrNothingToSend) {
+			// Every file of the batch is on the redaction deny list
+			stats.Skipped += len(batch)
+			continue
+		}
+		if err != nil {
+			return nil, stats, fmt.Errorf("failed to review %d hunk(s): %w", len(batch), err)
+		}
+
+		for i, result := range splitFindings(reviewed, batch) {
+			if r.Cache != nil {
+				if err := r.Cache.Put(batch[i].key, cachedHunkReview{NewStart: batch[i].hunk.NewStart, Review: result}); err != nil {
+					return nil, stats, err
+				}
+			}
+			results = append(results, result)
+		}
+		stats.Reviewed += len(batch)
+	}
+
+	return mergeReviews(results), stats, nil
+}
+
+// batchDiff renders pending hunks as one diff, keeping the hunks of a file together
+func batchDiff(batch []pendingHunk) string {
+	var files []git.FileDiff
+	index := make(map[string]int)
+	for _, p := range batch {
+		i, ok := index[p.file.Path()]
+		if !ok {
+			i = len(files)
+			index[p.file.Path()] = i
+			files = append(files, git.FileDiff{OldPath: p.file.OldPath, NewPath: p.file.NewPath, Header: p.file.Header})
+		}
+		files[i].Hunks = append(files[i].Hunks, p.hunk)
+	}
+
+	parts := make([]string, len(files))
+	for i, f := range files {
+		parts[i] = f.String()
+	}
+	return strings.Join(parts, "\n")
+}
+
+// splitFindings divides the review of a batch into one review per hunk. A
+// finding goes to the hunk of its file that contains its line, or the nearest
+// one; findings without a file go to the only file of the batch, or else to
+// the first hunk. The summary is kept with every hunk and merged back once.
+func splitFindings(review *ai.Review, batch []pendingHunk) []ai.Review {
+	results := make([]ai.Review, len(batch))
+	for i := range results {
+		results[i].Summary = strings.TrimSpace(review.Summary)
+	}
+
+	single := ""
+	for i, p := range batch {
+		if i == 0 {
+			single = p.file.Path()
+		} else if p.file.Path() != single {
+			single = ""
+			break
+		}
+	}
+	locate := func(file *string, line int) int {
+		if *file == "" {
+			*file = single
+		}
+		return hunkFor(batch, *file, line)
+	}
+
+	for _, issue := range review.Issues {
+		i := locate(&issue.File, issue.Line)
+		results[i].Issues = append(results[i].Issues, issue)
+	}
+	for _, suggestion := range review.Suggestions {
+		i := locate(&suggestion.File, suggestion.Line)
+		results[i].Suggestions = append(results[i].Suggestions, suggestion)
+	}
+	for _, risk := range review.SecurityRisks {
+		i := locate(&risk.File, risk.Line)
+		results[i].SecurityRisks = append(results[i].SecurityRisks, risk)
+	}
+	for _, perf := range review.Performance {
+		i := locate(&perf.File, perf.Line)
+		results[i].Performance = append(results[i].Performance, perf)
+	}
+	return results
+}
+
+// hunkFor returns the index of the hunk a finding at file and line belongs to
+func hunkFor(batch []pendingHunk, file string, line int) int {
+	file = strings.TrimPrefix(strings.TrimPrefix(file, "a/"), "b/")
+	best, bestDistance := 0, -1
+	for i, p := range batch {
+		if p.file.Path() != file {
+			continue
+		}
+		start, end := p.hunk.NewStart, p.hunk.NewStart+p.hunk.NewLines-1
+		distance := 0
+		switch {
+		case line <= 0:
+			// Unlocated findings go to the first hunk of their file
+			return i
+		case line < start:
+			distance = start - line
+		case line > end:
+			distance = line - end
+		}
+		if bestDistance < 0 || distance < bestDistance {
+			best, bestDistance = i, distance
+		}
+	}
+	return best
+}
+
+// mergeReviews combines hunk reviews into one, listing each distinct summary once
+func mergeReviews(results []ai.Review) *ai.Review {
+	merged := &ai.Review{
+		Issues:        []ai.Issue{},
+		Suggestions:   []ai.Suggestion{},
+		SecurityRisks: []ai.SecurityRisk{},
+		Performance:   []ai.PerformanceIssue{},
+	}
+
+	var summaries []string
+	seen := make(map[string]bool)
+	for _, result := range results {
+		if summary := strings.TrimSpace(result.Summary); summary != "" && !seen[summary] {
+			seen[summary] = true
+			summaries = append(summaries, summary)
+		}
+		merged.Issues = append(merged.Issues, result.Issues...)
+		merged.Suggestions = append(merged.Suggestions, result.Suggestions...)
+		merged.SecurityRisks = append(merged.SecurityRisks, result.SecurityRisks...)
+		merged.Performance = append(merged.Performance, result.Performance...)
+	}
+
+	merged.Summary = strings.Join(summaries, "\n\n")
+	return merged
+}
+
+// hunkKey identifies a hunk review by the hunk content, where it lives, the
+// review prompt version, the model and the options that shape the prompt
+func (r *Incremental) hunkKey(path string, hunk git.Hunk, options ai.ReviewOptions) string {
+	focusAreas := append([]string{}, options.FocusAreas...)
+	sort.Strings(focusAreas)
+
+	// The "@@" header is left out so that a hunk that merely moved keeps its entry
+	return cache.Key(
+		prompts.Version(prompts.ReviewTemplate),
+		r.ProviderName,
+		r.Model,
+		strings.Join(focusAreas, ","),
+		strconv.FormatBool(options.Security),
+		strconv.FormatBool(options.Performance),
+		path,
+		strings.Join(hunk.Lines, "\n"),
+	)
+}
+
+// shiftFindings moves the line numbers of located findings by delta
+func shiftFindings(review *ai.Review, delta int) {
+	if delta == 0 {
+		return
+	}
+	for i := range review.Issues {
+		if review.Issues[i].Line > 0 {
+			review.Issues[i].Line += delta
+		}
+	}
+	for i := range review.Suggestions {
+		if review.Suggestions[i].Line > 0 {
+			review.Suggestions[i].Line += delta
+		}
+	}
+	for i := range review.SecurityRisks {
+		if review.SecurityRisks[i].Line > 0 {
+			review.SecurityRisks[i].Line += delta
+		}
+	}
+	for i := range review.Performance {
+		if review.Performance[i].Line > 0 {
+			review.Performance[i].Line += delta
+		}
+	}
+}
@@ -0,0 +1,231 @@
+package review
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/tarantino19/aig/internal/ai"
+	"github.com/tarantino19/aig/internal/cache"
+	"github.com/tarantino19/aig/internal/git"
+)
+
+// fakeReviewer records the diffs it is asked to review and answers with review
+type fakeReviewer struct {
+	ai.Provider
+	review   func(diff string) *ai.Review
+	diffs    []string
+	withheld string
+}
+
+func (f *fakeReviewer) ReviewCode(ctx context.Context, diff string, options ai.ReviewOptions) (*ai.Review, error) {
+	f.diffs = append(f.diffs, diff)
+	return f.review(diff), nil
+}
+
+func (f *fakeReviewer) Withholds(path string) bool {
+	return path == f.withheld
+}
+
+// incrementalDiff builds a diff with two hunks in a.go, the second starting at
+// line start, and one hunk in b.go
+func incrementalDiff(start int, body string) string {
+	return `diff --git a/a.go b/a.go
+--- a/a.go
++++ b/a.go
+@@ -1,2 +1,3 @@
+ package a
++// Package a does things
+
+@@ -` + strconv.Itoa(start-1) + `,2 +` + strconv.Itoa(start) + `,3 @@ func A() {
+ 	x := 1
++	` + body + `
+ 	return x
+diff --git a/b.go b/b.go
+--- a/b.go
++++ b/b.go
+@@ -5,2 +5,3 @@
+ func B() {
++	panic("todo")
+ }`
+}
+
+func newIncremental(t *testing.T, provider ai.Provider, model string) *Incremental {
+	t.Helper()
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	store, err := cache.Open(CacheNamespace)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Incremental{Provider: provider, Cache: store, ProviderName: "openai", Model: model}
+}
+
+func TestIncrementalBatchesUncachedHunks(t *testing.T) {
+	provider := &fakeReviewer{review: func(diff string) *ai.Review {
+		return &ai.Review{
+			Summary: "Adds docs and a panic",
+			Issues: []ai.Issue{
+				{File: "a.go", Line: 21, Description: "x is shadowed"},
+				{File: "b.go", Line: 6, Description: "panic in library code"},
+			},
+			Suggestions: []ai.Suggestion{{Description: "add tests"}},
+		}
+	}}
+	r := newIncremental(t, provider, "gpt-4o-mini")
+
+	review, stats, err := r.Review(context.Background(), incrementalDiff(20, "y := 2"), ai.ReviewOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Requests != 1 || stats.Reviewed != 3 || stats.Cached != 0 {
+		t.Fatalf("first review stats = %+v, want one request for 3 hunks", stats)
+	}
+	if n := strings.Count(provider.diffs[0], "@@ -"); n != 3 {
+		t.Errorf("the request holds %d hunks, want 3:\n%s", n, provider.diffs[0])
+	}
+	if len(review.Issues) != 2 || len(review.Suggestions) != 1 || review.Summary != "Adds docs and a panic" {
+		t.Errorf("unexpected review %+v", review)
+	}
+
+	// Nothing changed, so nothing is sent
+	review, stats, err = r.Review(context.Background(), incrementalDiff(20, "y := 2"), ai.ReviewOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Requests != 0 || stats.Cached != 3 {
+		t.Errorf("second review stats = %+v, want everything cached", stats)
+	}
+	if len(review.Issues) != 2 || len(review.Suggestions) != 1 || review.Summary != "Adds docs and a panic" {
+		t.Errorf("cached review differs: %+v", review)
+	}
+}
+
+func TestIncrementalHunkKey(t *testing.T) {
+	provider := &fakeReviewer{review: func(diff string) *ai.Review {
+		if !strings.Contains(diff, "a.go") {
+			return &ai.Review{}
+		}
+		return &ai.Review{Issues: []ai.Issue{{File: "a.go", Line: 21, Description: "x is shadowed"}}}
+	}}
+	r := newIncremental(t, provider, "gpt-4o-mini")
+	ctx := context.Background()
+
+	if _, _, err := r.Review(ctx, incrementalDiff(20, "y := 2"), ai.ReviewOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The same content further down the file is a hit, with its findings moved along
+	review, stats, err := r.Review(ctx, incrementalDiff(30, "y := 2"), ai.ReviewOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Cached != 3 || stats.Requests != 0 {
+		t.Errorf("moved hunk stats = %+v, want a cache hit", stats)
+	}
+	if len(review.Issues) != 1 || review.Issues[0].Line != 31 {
+		t.Errorf("moved finding = %+v, want line 31", review.Issues)
+	}
+
+	// Changed content is a miss for that hunk only
+	_, stats, err = r.Review(ctx, incrementalDiff(20, "y := 3"), ai.ReviewOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Cached != 2 || stats.Reviewed != 1 {
+		t.Errorf("changed hunk stats = %+v, want 2 cached and 1 reviewed", stats)
+	}
+
+	// Another model misses every hunk
+	r.Model = "gpt-4o"
+	_, stats, err = r.Review(ctx, incrementalDiff(20, "y := 2"), ai.ReviewOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Cached != 0 || stats.Reviewed != 3 {
+		t.Errorf("other model stats = %+v, want every hunk reviewed", stats)
+	}
+}
+
+func TestIncrementalPerHunkAndWithheld(t *testing.T) {
+	provider := &fakeReviewer{review: func(diff string) *ai.Review { return &ai.Review{} }, withheld: "b.go"}
+	r := newIncremental(t, provider, "gpt-4o-mini")
+	r.PerHunk = true
+
+	_, stats, err := r.Review(context.Background(), incrementalDiff(20, "y := 2"), ai.ReviewOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Requests != 2 || stats.Reviewed != 2 || stats.Skipped != 1 {
+		t.Errorf("stats = %+v, want two requests and b.go skipped", stats)
+	}
+	for _, diff := range provider.diffs {
+		if strings.Contains(diff, "b.go") {
+			t.Errorf("a withheld file was sent:\n%s", diff)
+		}
+	}
+}
+
+func TestSplitFindings(t *testing.T) {
+	files := git.ParseDiff(incrementalDiff(20, "y := 2"))
+	var batch []pendingHunk
+	for _, f := range files {
+		for _, h := range f.Hunks {
+			batch = append(batch, pendingHunk{file: f, hunk: h})
+		}
+	}
+
+	review := &ai.Review{
+		Issues: []ai.Issue{
+			{File: "a.go", Line: 2},    // inside the first hunk
+			{File: "b/a.go", Line: 40}, // past the second hunk, the nearest one
+			{File: "b.go"},             // unlocated, first hunk of b.go
+			{File: "c.go", Line: 3},    // unknown file, first hunk
+		},
+	}
+	results := splitFindings(review, batch)
+
+	counts := []int{len(results[0].Issues), len(results[1].Issues), len(results[2].Issues)}
+	if counts[0] != 2 || counts[1] != 1 || counts[2] != 1 {
+		t.Errorf("findings per hunk = %v, want [2 1 1]", counts)
+	}
+}
+
+func TestShiftFindings(t *testing.T) {
+	review := ai.Review{
+		Issues:        []ai.Issue{{Line: 10}, {Line: 0}},
+		Suggestions:   []ai.Suggestion{{Line: 3}},
+		SecurityRisks: []ai.SecurityRisk{{Line: 7}},
+		Performance:   []ai.PerformanceIssue{{Line: 1}},
+	}
+	shiftFindings(&review, 5)
+
+	if review.Issues[0].Line != 15 || review.Issues[1].Line != 0 {
+		t.Errorf("issues = %+v, want located lines moved and unlocated ones kept", review.Issues)
+	}
+	if review.Suggestions[0].Line != 8 || review.SecurityRisks[0].Line != 12 || review.Performance[0].Line != 6 {
+		t.Errorf("unexpected shifted lines %+v", review)
+	}
+}
+
+func TestIncrementalWithoutCache(t *testing.T) {
+	provider := &fakeReviewer{review: func(diff string) *ai.Review { return &ai.Review{} }}
+	r := newIncremental(t, provider, "gpt-4o-mini")
+	store := r.Cache
+	r.Cache = nil
+
+	for i := 0; i < 2; i++ {
+		_, stats, err := r.Review(context.Background(), incrementalDiff(20, "y := 2"), ai.ReviewOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stats.Cached != 0 || stats.Reviewed != 3 {
+			t.Errorf("run %d stats = %+v, want every hunk reviewed", i, stats)
+		}
+	}
+
+	if stats, err := store.Stats(); err != nil || stats.Entries != 0 {
+		t.Errorf("the cache holds %d entries, want none written (%v)", stats.Entries, err)
+	}
+}
@@ -0,0 +1,184 @@
+// Package secrets scans staged changes for credentials so that they are never
+// committed
+package secrets
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/tarantino19/aig/internal/credentials"
+	"github.com/tarantino19/aig/internal/git"
+	"github.com/tarantino19/aig/internal/ignore"
+	"github.com/tarantino19/aig/internal/redact"
+)
+
+// AllowlistFile is the repository file listing findings that may be committed
+const AllowlistFile = ".aigsecrets"
+
+// InlineAllow marks a line whose secrets may be committed, such as a test fixture
+const InlineAllow = "aig:allow-secret"
+
+// DefaultDetectors are the redaction detectors that block a commit. Emails and
+// high entropy strings are left out since they are rarely credentials.
+var DefaultDetectors = []string{redact.AWSKey, redact.PrivateKey, redact.JWT, redact.Token}
+
+// Finding is a secret in an added line
+type Finding struct {
+	Path        string
+	Line        int
+	Kind        string
+	Secret      string
+	Fingerprint string // identifies the secret in the allowlist
+}
+
+// String renders the finding with the secret masked
+func (f Finding) String() string {
+	return fmt.Sprintf("%s:%d: %s %s (fingerprint %s)", f.Path, f.Line, f.Kind, credentials.Mask(f.Secret), f.Fingerprint)
+}
+
+// Fingerprint identifies a secret without revealing it
+func Fingerprint(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:8])
+}
+
+// Allowlist holds the fingerprints and paths that are not reported
+type Allowlist struct {
+	fingerprints map[string]bool
+	paths        *ignore.Matcher
+}
+
+// ParseAllowlist parses allowlist lines. A line is either a fingerprint or
+// path:<pattern> with a .gitignore-style pattern. Blank lines and lines starting
+// with # are ignored, as is anything after a fingerprint.
+func ParseAllowlist(lines []string) *Allowlist {
+	a := &Allowlist{fingerprints: make(map[string]bool)}
+	var paths []string
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if pattern, ok := strings.CutPrefix(line, "path:"); ok {
+			paths = append(paths, strings.TrimSpace(pattern))
+			continue
+		}
+		a.fingerprints[strings.ToLower(strings.Fields(line)[0])] = true
+	}
+	a.paths = ignore.New(paths)
+	return a
+}
+
+// LoadAllowlist reads the allowlist file at the root of the repository. A missing
+// file yields an empty allowlist.
+func LoadAllowlist(repoRoot string) (*Allowlist, error) {
+	file, err := os.Open(filepath.Join(repoRoot, AllowlistFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return ParseAllowlist(nil), nil
+		}
+		return nil, fmt.Errorf("failed to open %s: %w", AllowlistFile, err)
+	}
+	defer file.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", AllowlistFile, err)
+	}
+	return ParseAllowlist(lines), nil
+}
+
+// Scanner finds secrets in the lines a diff adds
+type Scanner struct {
+	redactor *redact.Redactor
+	allow    *Allowlist
+}
+
+// NewScanner returns a Scanner running the given detectors and patterns, see
+// redact.Options. allow may be nil.
+func NewScanner(detectors, patterns []string, allow *Allowlist) (*Scanner, error) {
+	if detectors == nil {
+		detectors = DefaultDetectors
+	}
+	r, err := redact.New(redact.Options{Detectors: detectors, Patterns: patterns})
+	if err != nil {
+		return nil, err
+	}
+	if allow == nil {
+		allow = ParseAllowlist(nil)
+	}
+	return &Scanner{redactor: r, allow: allow}, nil
+}
+
+// ScanDiff returns the secrets in the lines diff adds, ordered by file and line
+func (s *Scanner) ScanDiff(diff string) []Finding {
+	var findings []Finding
+	for _, file := range git.ParseDiff(diff) {
+		if file.IsDeleted() || s.allow.paths.Match(file.Path()) {
+			continue
+		}
+		findings = append(findings, s.scanFile(file)...)
+	}
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].Path != findings[j].Path {
+			return findings[i].Path < findings[j].Path
+		}
+		return findings[i].Line < findings[j].Line
+	})
+	return findings
+}
+
+// scanFile scans the added lines of a file as one text, so that secrets spanning
+// several lines such as private keys are found
+func (s *Scanner) scanFile(file git.FileDiff) []Finding {
+	var text strings.Builder
+	var lines []string
+	var numbers, offsets []int
+	for _, hunk := range file.Hunks {
+		newNumbers := hunk.NewLineNumbers()
+		for i, line := range hunk.Lines {
+			if !strings.HasPrefix(line, "+") {
+				continue
+			}
+			offsets = append(offsets, text.Len())
+			numbers = append(numbers, newNumbers[i])
+			lines = append(lines, line[1:])
+			text.WriteString(line[1:])
+			text.WriteString("\n")
+		}
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+
+	var findings []Finding
+	for _, m := range s.redactor.Find(text.String()) {
+		// The last line starting at or before the match
+		i := sort.SearchInts(offsets, m.Start+1) - 1
+		if strings.Contains(lines[i], InlineAllow) {
+			continue
+		}
+		fingerprint := Fingerprint(m.Value)
+		if s.allow.fingerprints[fingerprint] {
+			continue
+		}
+		findings = append(findings, Finding{
+			Path:        file.Path(),
+			Line:        numbers[i],
+			Kind:        m.Kind,
+			Secret:      m.Value,
+			Fingerprint: fingerprint,
+		})
+	}
+	return findings
+}
@@ -0,0 +1,187 @@
+// Package tickets finds issue tracker keys such as PROJ-123, eng-42 or #17 in
+// branch names and commit messages
+package tickets
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Names of the builtin patterns
+const (
+	Jira    = "jira"    // PROJ-123
+	Linear  = "linear"  // eng-123 in the lowercase branch names Linear creates
+	GitHub  = "github"  // #123 and "fixes 123"
+	Numeric = "numeric" // a bare 4-7 digit number between separators
+)
+
+// DefaultPatterns are used when no patterns are configured
+var DefaultPatterns = []string{Jira, Linear, GitHub, Numeric}
+
+// notProjectKeys are prefixes that look like ticket keys but are not, such as
+// UTF-8, or branch types such as fix-1234
+var notProjectKeys = map[string]bool{
+	"UTF": true, "SHA": true, "ISO": true, "RFC": true, "HTTP": true, "TLS": true,
+	"AES": true, "CVE": true, "ES": true, "X": true, "V": true,
+	"FEAT": true, "FEATURE": true, "FIX": true, "BUGFIX": true, "HOTFIX": true,
+	"RELEASE": true, "CHORE": true, "DOCS": true, "TEST": true, "REFACTOR": true,
+	"PERF": true, "BUILD": true, "CI": true, "STYLE": true, "REVERT": true,
+}
+
+// builtin holds the builtin patterns by name
+var builtin = map[string][]*Pattern{
+	Jira: {{
+		Name:      Jira,
+		re:        regexp.MustCompile(`\b([A-Z][A-Z0-9]+-\d+)\b`),
+		normalize: strings.ToUpper,
+	}},
+	Linear: {{
+		Name:      Linear,
+		re:        regexp.MustCompile(`(?:^|/)([a-z][a-z0-9]{1,9}-\d+)\b`),
+		normalize: strings.ToUpper,
+	}},
+	GitHub: {
+		{Name: GitHub, re: regexp.MustCompile(`(?:^|[\s(\[])#(\d+)\b`)},
+		{Name: GitHub, re: regexp.MustCompile(`(?i)\b(?:fix|fixes|fixed|close|closes|closed|resolve|resolves|resolved)\s+#?(\d+)\b`)},
+	},
+	Numeric: {{
+		Name: Numeric,
+		re:   regexp.MustCompile(`(?:^|[/_-])(\d{4,7})(?:[/_-]|$)`),
+	}},
+}
+
+// Pattern is a single way of writing a ticket key
+type Pattern struct {
+	Name string
+
+	re        *regexp.Regexp
+	normalize func(string) string
+}
+
+// Matcher finds ticket keys using a list of patterns, in order
+type Matcher struct {
+	patterns []*Pattern
+}
+
+// New returns a Matcher for the given patterns. Each one is either the name of
+// a builtin pattern or a regular expression whose first capture group, or whole
+// match if it has none, is the key.
+func New(patterns ...string) (*Matcher, error) {
+	m := &Matcher{}
+	for _, spec := range patterns {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			continue
+		}
+		if known, ok := builtin[strings.ToLower(spec)]; ok {
+			m.patterns = append(m.patterns, known...)
+			continue
+		}
+		re, err := regexp.Compile(spec)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ticket pattern %q: %w", spec, err)
+		}
+		m.patterns = append(m.patterns, &Pattern{Name: spec, re: re})
+	}
+	return m, nil
+}
+
+// Default returns a Matcher for DefaultPatterns
+func Default() *Matcher {
+	m, _ := New(DefaultPatterns...)
+	return m
+}
+
+// Validate checks that every pattern is a builtin name or a valid regular expression
+func Validate(patterns []string) error {
+	_, err := New(patterns...)
+	return err
+}
+
+// Find returns the first key found by the first pattern that matches, or ""
+func (m *Matcher) Find(text string) string {
+	for _, p := range m.patterns {
+		if keys := p.find(text, 1); len(keys) > 0 {
+			return keys[0]
+		}
+	}
+	return ""
+}
+
+// FindAll returns every distinct key in text, in pattern order
+func (m *Matcher) FindAll(text string) []string {
+	var keys []string
+	seen := make(map[string]bool)
+	for _, p := range m.patterns {
+		for _, key := range p.find(text, -1) {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	return keys
+}
+
+// Strip removes every key from text, for turning a branch name into a title
+func (m *Matcher) Strip(text string) string {
+	for _, p := range m.patterns {
+		text = p.re.ReplaceAllStringFunc(text, func(match string) string {
+			if len(p.find(match, 1)) == 0 {
+				return match
+			}
+			// Keep a leading separator so that neighbouring words stay apart
+			if strings.ContainsAny(match[:1], "/_- ") {
+				return match[:1]
+			}
+			return ""
+		})
+	}
+	return text
+}
+
+func (p *Pattern) find(text string, n int) []string {
+	var keys []string
+	for _, match := range p.re.FindAllStringSubmatch(text, n) {
+		key := match[0]
+		if len(match) > 1 {
+			key = match[1]
+		}
+		if p.normalize != nil {
+			key = p.normalize(key)
+		}
+		if project, _, found := strings.Cut(key, "-"); found && notProjectKeys[strings.ToUpper(project)] {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// IsNumeric reports whether key is a plain issue number, which trackers such as
+// GitHub write as #123
+func IsNumeric(key string) bool {
+	if key == "" {
+		return false
+	}
+	for _, r := range key {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Reference returns key the way it is written in prose: #123 or PROJ-123
+func Reference(key string) string {
+	if IsNumeric(key) {
+		return "#" + key
+	}
+	return key
+}
+
+// Format renders a ticket format, replacing {ticket} with the key
+func Format(format, key string) string {
+	return strings.ReplaceAll(format, "{ticket}", key)
+}
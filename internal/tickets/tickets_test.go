@@ -0,0 +1,58 @@
+package tickets
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFind(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"feature/PROJ-1234-new-login", "PROJ-1234"},
+		{"jane/eng-42-fix-sidebar", "ENG-42"},
+		{"fix/5678-fix-bug", "5678"},
+		{"feature/123456-six-digits", "123456"},
+		{"feature/1234-20250620-new-login", "1234"},
+		{"hotfix-12345", "12345"},
+		{"release/v1.0", ""},
+		{"no-ticket-feat", ""},
+		{"fix: handle UTF-8 input", ""},
+		{"fix: handle empty files (#17)", "17"},
+		{"Closes 99 and updates docs", "99"},
+	}
+
+	m := Default()
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			if got := m.Find(tt.text); got != tt.want {
+				t.Errorf("Find(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCustomPattern(t *testing.T) {
+	m, err := New(`ticket-(\d+)`, Jira)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := m.Find("feature/ticket-77-ABC-1"); got != "77" {
+		t.Errorf("expected the custom pattern to win, got %q", got)
+	}
+	if got := m.FindAll("ABC-1 then ticket-77 and ABC-1 again"); !reflect.DeepEqual(got, []string{"77", "ABC-1"}) {
+		t.Errorf("FindAll = %q", got)
+	}
+
+	if _, err := New(`(unclosed`); err == nil {
+		t.Error("expected an error for an invalid pattern")
+	}
+}
+
+func TestStrip(t *testing.T) {
+	got := Default().Strip("PROJ-12-add-login")
+	if got != "-add-login" {
+		t.Errorf("Strip = %q", got)
+	}
+}
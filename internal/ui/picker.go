@@ -0,0 +1,159 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/tarantino19/aig/internal/ai"
+)
+
+// PickAction is what the user chose to do in the commit message picker
+type PickAction int
+
+const (
+	PickCancel PickAction = iota
+	PickAccept
+	PickEdit
+	PickMore
+	PickRegenerate
+)
+
+// PickResult is the outcome of the commit message picker
+type PickResult struct {
+	Action PickAction
+	Index  int
+}
+
+// Subject lengths past these limits are flagged in the picker
+const (
+	subjectSoftLimit = 50
+	subjectHardLimit = 72
+)
+
+var (
+	selectedStyle = lipgloss.NewStyle().
+			Foreground(primaryColor).
+			Bold(true)
+
+	previewStyle = lipgloss.NewStyle().
+			Foreground(mutedColor).
+			PaddingLeft(6)
+)
+
+type pickerModel struct {
+	candidates []*ai.CommitMessage
+	cursor     int
+	result     PickResult
+}
+
+func (m pickerModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m pickerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+
+	switch key.String() {
+	case "up", "k":
+		if m.cursor > 0 {
+			m.cursor--
+		}
+	case "down", "j":
+		if m.cursor < len(m.candidates)-1 {
+			m.cursor++
+		}
+	case "enter":
+		m.result = PickResult{Action: PickAccept, Index: m.cursor}
+		return m, tea.Quit
+	case "e":
+		m.result = PickResult{Action: PickEdit, Index: m.cursor}
+		return m, tea.Quit
+	case "m":
+		m.result = PickResult{Action: PickMore, Index: m.cursor}
+		return m, tea.Quit
+	case "r":
+		m.result = PickResult{Action: PickRegenerate, Index: m.cursor}
+		return m, tea.Quit
+	case "q", "esc", "ctrl+c":
+		m.result = PickResult{Action: PickCancel, Index: m.cursor}
+		return m, tea.Quit
+	default:
+		// Digits jump straight to a candidate
+		if s := key.String(); len(s) == 1 && s[0] >= '1' && s[0] <= '9' {
+			if i := int(s[0] - '1'); i < len(m.candidates) {
+				m.cursor = i
+			}
+		}
+	}
+	return m, nil
+}
+
+func (m pickerModel) View() string {
+	var b strings.Builder
+	b.WriteString(headerStyle.Render("Choose a commit message:"))
+	b.WriteString("\n")
+
+	for i, c := range m.candidates {
+		marker := "  "
+		number := fmt.Sprintf("%d.", i+1)
+		if i == m.cursor {
+			marker = selectedStyle.Render("> ")
+			number = selectedStyle.Render(number)
+		}
+		fmt.Fprintf(&b, "%s%s %s %s\n", marker, number, renderHeader(c), subjectBadge(c.Header()))
+
+		if i == m.cursor && c.Body != "" {
+			b.WriteString(previewStyle.Render(truncateString(c.Body, 300)))
+			b.WriteString("\n")
+		}
+	}
+
+	b.WriteString("\n")
+	b.WriteString(mutedStyle.Render("↑/↓ move • enter use • e edit • m more like this • r regenerate • q cancel"))
+	b.WriteString("\n")
+	return b.String()
+}
+
+// renderHeader colors the type of a conventional header line
+func renderHeader(c *ai.CommitMessage) string {
+	header := c.Header()
+	if c.Type == "" || !strings.HasPrefix(header, c.Type) {
+		return header
+	}
+	return GetCommitTypeStyle(c.Type).Render(c.Type) + header[len(c.Type):]
+}
+
+// subjectBadge shows the header length, colored by how far it runs past the limits
+func subjectBadge(header string) string {
+	n := len([]rune(header))
+	style := lipgloss.NewStyle().Foreground(secondaryColor)
+	switch {
+	case n > subjectHardLimit:
+		style = lipgloss.NewStyle().Foreground(errorColor)
+	case n > subjectSoftLimit:
+		style = lipgloss.NewStyle().Foreground(warningColor)
+	}
+	return style.Render(fmt.Sprintf("[%d]", n))
+}
+
+// PickCommitMessage lets the user choose between candidate commit messages
+func PickCommitMessage(candidates []*ai.CommitMessage) (PickResult, error) {
+	final, err := tea.NewProgram(pickerModel{candidates: candidates}).Run()
+	if err != nil {
+		return PickResult{}, fmt.Errorf("failed to run picker: %w", err)
+	}
+	return final.(pickerModel).result, nil
+}
+
+// ListCommitMessages prints numbered candidates for non-interactive terminals
+func ListCommitMessages(candidates []*ai.CommitMessage) {
+	fmt.Println(headerStyle.Render("Candidate Commit Messages:"))
+	for i, c := range candidates {
+		fmt.Printf("%d. %s %s\n", i+1, renderHeader(c), subjectBadge(c.Header()))
+	}
+}
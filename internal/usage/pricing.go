@@ -0,0 +1,108 @@
+package usage
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Price is the cost of a model in US dollars per million tokens
+type Price struct {
+	Input  float64
+	Output float64
+}
+
+// DefaultPrices are the list prices of common models. Entries ending in "*" match
+// every model with that prefix; the longest match wins.
+var DefaultPrices = map[string]Price{
+	"gpt-4o":           {Input: 2.50, Output: 10.00},
+	"gpt-4o-mini":      {Input: 0.15, Output: 0.60},
+	"gpt-4.1":          {Input: 2.00, Output: 8.00},
+	"gpt-4.1-mini":     {Input: 0.40, Output: 1.60},
+	"gpt-4.1-nano":     {Input: 0.10, Output: 0.40},
+	"gpt-4-turbo":      {Input: 10.00, Output: 30.00},
+	"gpt-4":            {Input: 30.00, Output: 60.00},
+	"gpt-3.5-turbo":    {Input: 0.50, Output: 1.50},
+	"o1":               {Input: 15.00, Output: 60.00},
+	"o3-mini":          {Input: 1.10, Output: 4.40},
+	"o4-mini":          {Input: 1.10, Output: 4.40},
+	"gemini-1.5-flash": {Input: 0.075, Output: 0.30},
+	"gemini-1.5-pro":   {Input: 1.25, Output: 5.00},
+	"gemini-2.0-flash": {Input: 0.10, Output: 0.40},
+	"gemini-2.5-flash": {Input: 0.30, Output: 2.50},
+	"gemini-2.5-pro":   {Input: 1.25, Output: 10.00},
+}
+
+// PriceTable looks up model prices
+type PriceTable map[string]Price
+
+// ParsePrices parses "model=input/output" entries, in dollars per million tokens,
+// on top of the default prices
+func ParsePrices(entries []string) (PriceTable, error) {
+	table := make(PriceTable, len(DefaultPrices)+len(entries))
+	for model, price := range DefaultPrices {
+		table[model] = price
+	}
+
+	for _, entry := range entries {
+		model, prices, ok := strings.Cut(entry, "=")
+		model = strings.TrimSpace(model)
+		input, output, ok2 := strings.Cut(prices, "/")
+		if !ok || !ok2 || model == "" {
+			return nil, fmt.Errorf("invalid price %q, expected model=input/output", entry)
+		}
+
+		var price Price
+		var err error
+		if price.Input, err = parseDollars(input); err != nil {
+			return nil, fmt.Errorf("invalid input price in %q: %w", entry, err)
+		}
+		if price.Output, err = parseDollars(output); err != nil {
+			return nil, fmt.Errorf("invalid output price in %q: %w", entry, err)
+		}
+		table[model] = price
+	}
+
+	return table, nil
+}
+
+func parseDollars(s string) (float64, error) {
+	value, err := strconv.ParseFloat(strings.TrimPrefix(strings.TrimSpace(s), "$"), 64)
+	if err != nil {
+		return 0, err
+	}
+	if value < 0 {
+		return 0, fmt.Errorf("must not be negative")
+	}
+	return value, nil
+}
+
+// Lookup returns the price of a model. Exact entries win over "*" prefixes, and
+// longer prefixes over shorter ones.
+func (t PriceTable) Lookup(model string) (Price, bool) {
+	if price, ok := t[model]; ok {
+		return price, true
+	}
+
+	var prefixes []string
+	for key := range t {
+		if prefix, ok := strings.CutSuffix(key, "*"); ok && strings.HasPrefix(model, prefix) {
+			prefixes = append(prefixes, key)
+		}
+	}
+	if len(prefixes) == 0 {
+		return Price{}, false
+	}
+	sort.Slice(prefixes, func(i, j int) bool { return len(prefixes[i]) > len(prefixes[j]) })
+	return t[prefixes[0]], true
+}
+
+// Cost returns the price of a request in US dollars, and whether the model is priced
+func (t PriceTable) Cost(model string, promptTokens, completionTokens int) (float64, bool) {
+	price, ok := t.Lookup(model)
+	if !ok {
+		return 0, false
+	}
+	return (float64(promptTokens)*price.Input + float64(completionTokens)*price.Output) / 1e6, true
+}
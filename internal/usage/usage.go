@@ -0,0 +1,192 @@
+// Package usage records the tokens and cost of AI requests and reports on them
+package usage
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// FileName is the name of the usage log in the aig config directory
+const FileName = "usage.jsonl"
+
+// Record is the usage of a single request
+type Record struct {
+	Time             time.Time `json:"time"`
+	User             string    `json:"user,omitempty"`
+	Command          string    `json:"command"`
+	Kind             string    `json:"kind"`
+	Repo             string    `json:"repo,omitempty"`
+	Provider         string    `json:"provider"`
+	Model            string    `json:"model"`
+	PromptTokens     int       `json:"prompt_tokens"`
+	CompletionTokens int       `json:"completion_tokens"`
+	Estimated        bool      `json:"estimated,omitempty"` // the provider reported no usage
+	Cost             float64   `json:"cost"`                // US dollars
+	Unpriced         bool      `json:"unpriced,omitempty"`  // the model is not in the price table
+}
+
+// Store is the usage log file
+type Store struct {
+	Path string
+}
+
+// Open returns the usage log at path
+func Open(path string) *Store {
+	return &Store{Path: path}
+}
+
+// Add appends a record to the log, creating it if needed
+func (s *Store) Add(record Record) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to encode usage record: %w", err)
+	}
+	data = append(data, '\n')
+
+	if err := os.MkdirAll(filepath.Dir(s.Path), 0700); err != nil {
+		return fmt.Errorf("failed to create usage directory: %w", err)
+	}
+	file, err := os.OpenFile(s.Path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open usage log: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(data); err != nil {
+		return fmt.Errorf("failed to write usage log: %w", err)
+	}
+	return nil
+}
+
+// Since returns the records at or after since, oldest first. A missing log has
+// no records and unreadable lines are skipped.
+func (s *Store) Since(since time.Time) ([]Record, error) {
+	file, err := os.Open(s.Path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to open usage log: %w", err)
+	}
+	defer file.Close()
+
+	var records []Record
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var record Record
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			continue
+		}
+		if !record.Time.Before(since) {
+			records = append(records, record)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return records, fmt.Errorf("failed to read usage log: %w", err)
+	}
+	return records, nil
+}
+
+// MonthStart returns the first instant of the month containing t
+func MonthStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+}
+
+// Dimensions records can be grouped by
+var Dimensions = []string{"command", "model", "provider", "repo", "user", "day", "month"}
+
+// Row is the total usage of one group
+type Row struct {
+	Key              string  `json:"key,omitempty"`
+	Requests         int     `json:"requests"`
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	Cost             float64 `json:"cost"`
+	Estimated        bool    `json:"estimated,omitempty"` // some of the token counts are estimates
+	Unpriced         bool    `json:"unpriced,omitempty"`  // some of the requests could not be priced
+}
+
+// Total adds up records
+func Total(records []Record) Row {
+	var row Row
+	for _, r := range records {
+		row.add(r)
+	}
+	return row
+}
+
+func (row *Row) add(r Record) {
+	row.Requests++
+	row.PromptTokens += r.PromptTokens
+	row.CompletionTokens += r.CompletionTokens
+	row.Cost += r.Cost
+	row.Estimated = row.Estimated || r.Estimated
+	row.Unpriced = row.Unpriced || r.Unpriced
+}
+
+// Group adds up records per value of a dimension, most expensive first
+func Group(records []Record, by string) ([]Row, error) {
+	key, err := dimension(by)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make(map[string]*Row)
+	for _, r := range records {
+		k := key(r)
+		if k == "" {
+			k = "(none)"
+		}
+		row, ok := rows[k]
+		if !ok {
+			row = &Row{Key: k}
+			rows[k] = row
+		}
+		row.add(r)
+	}
+
+	result := make([]Row, 0, len(rows))
+	for _, row := range rows {
+		result = append(result, *row)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if by == "day" || by == "month" {
+			return result[i].Key < result[j].Key
+		}
+		if result[i].Cost != result[j].Cost {
+			return result[i].Cost > result[j].Cost
+		}
+		if result[i].Requests != result[j].Requests {
+			return result[i].Requests > result[j].Requests
+		}
+		return result[i].Key < result[j].Key
+	})
+	return result, nil
+}
+
+func dimension(by string) (func(Record) string, error) {
+	switch by {
+	case "command":
+		return func(r Record) string { return r.Command }, nil
+	case "model":
+		return func(r Record) string { return r.Model }, nil
+	case "provider":
+		return func(r Record) string { return r.Provider }, nil
+	case "repo":
+		return func(r Record) string { return r.Repo }, nil
+	case "user":
+		return func(r Record) string { return r.User }, nil
+	case "day":
+		return func(r Record) string { return r.Time.Local().Format("2006-01-02") }, nil
+	case "month":
+		return func(r Record) string { return r.Time.Local().Format("2006-01") }, nil
+	default:
+		return nil, fmt.Errorf("cannot group by %q, expected one of %v", by, Dimensions)
+	}
+}
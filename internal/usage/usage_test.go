@@ -0,0 +1,95 @@
+package usage
+
+import (
+	"math"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParsePrices(t *testing.T) {
+	table, err := ParsePrices([]string{"gpt-4o=1/2", "my-model-*=$0.5/1.5"})
+	if err != nil {
+		t.Fatalf("ParsePrices: %v", err)
+	}
+
+	tests := []struct {
+		model string
+		want  Price
+		ok    bool
+	}{
+		{"gpt-4o", Price{1, 2}, true},
+		{"gpt-4o-mini", DefaultPrices["gpt-4o-mini"], true},
+		{"my-model-large", Price{0.5, 1.5}, true},
+		{"unknown", Price{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := table.Lookup(tt.model)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("Lookup(%q) = %v, %v, want %v, %v", tt.model, got, ok, tt.want, tt.ok)
+		}
+	}
+
+	for _, bad := range []string{"gpt-4o", "gpt-4o=1", "=1/2", "gpt-4o=a/2", "gpt-4o=-1/2"} {
+		if _, err := ParsePrices([]string{bad}); err == nil {
+			t.Errorf("ParsePrices(%q) succeeded, want an error", bad)
+		}
+	}
+}
+
+func TestCost(t *testing.T) {
+	table := PriceTable{"m": {Input: 2, Output: 8}, "m-*": {Input: 1, Output: 1}, "m-large-*": {Input: 4, Output: 4}}
+
+	cost, ok := table.Cost("m", 1000000, 500000)
+	if !ok || math.Abs(cost-6) > 1e-9 {
+		t.Errorf("Cost(m) = %v, %v, want 6, true", cost, ok)
+	}
+	if cost, _ := table.Cost("m-large-2", 1000000, 0); cost != 4 {
+		t.Errorf("Cost(m-large-2) = %v, want the longest prefix price 4", cost)
+	}
+}
+
+func TestStoreAndGroup(t *testing.T) {
+	store := Open(filepath.Join(t.TempDir(), "usage", FileName))
+	now := time.Now()
+
+	records := []Record{
+		{Time: now.Add(-48 * time.Hour), Command: "commit", Model: "a", PromptTokens: 10, Cost: 1},
+		{Time: now, Command: "commit", Model: "b", PromptTokens: 20, Cost: 2},
+		{Time: now, Command: "review", Model: "a", PromptTokens: 30, Cost: 5, Unpriced: true},
+	}
+	for _, r := range records {
+		if err := store.Add(r); err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+	}
+
+	recent, err := store.Since(now.Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("Since: %v", err)
+	}
+	if len(recent) != 2 {
+		t.Fatalf("Since returned %d records, want 2", len(recent))
+	}
+
+	all, _ := store.Since(time.Time{})
+	rows, err := Group(all, "command")
+	if err != nil {
+		t.Fatalf("Group: %v", err)
+	}
+	if len(rows) != 2 || rows[0].Key != "review" || rows[1].Key != "commit" {
+		t.Fatalf("Group by command = %+v, want review before commit", rows)
+	}
+	if rows[1].Requests != 2 || rows[1].PromptTokens != 30 || rows[1].Cost != 3 {
+		t.Errorf("commit row = %+v", rows[1])
+	}
+
+	total := Total(all)
+	if total.Requests != 3 || total.Cost != 8 || !total.Unpriced {
+		t.Errorf("Total = %+v", total)
+	}
+
+	if _, err := Group(all, "color"); err == nil {
+		t.Error("Group by an unknown dimension succeeded")
+	}
+}
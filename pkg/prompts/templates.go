@@ -1,10 +1,37 @@
 package prompts
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
+	"sync"
+	"text/template"
 )
 
+// BuiltinVersion identifies the embedded templates. Bump it whenever one of them
+// changes so that cached results produced with the old prompt are invalidated.
+const BuiltinVersion = "9"
+
+// Template names
+const (
+	CommitTemplate  = "commit"
+	SummaryTemplate = "summary"
+	ReviewTemplate  = "review"
+	PRTemplate      = "pr"
+	SplitTemplate   = "split"
+)
+
+// templateExt is the file extension of prompt templates
+const templateExt = ".tmpl"
+
+//go:embed templates/*.tmpl
+var builtin embed.FS
+
 // Commit represents a git commit for prompts
 type Commit struct {
 	Hash    string
@@ -13,188 +40,209 @@ type Commit struct {
 	Message string
 }
 
-// GetCommitMessagePrompt returns the prompt for generating commit messages
-func GetCommitMessagePrompt(diff, commitType, scope string, conventional bool) string {
-	var prompt strings.Builder
-	
-	prompt.WriteString("Analyze the following git diff and generate a concise, conventional commit message.\n\n")
-	
-	prompt.WriteString("Rules:\n")
-	if conventional {
-		prompt.WriteString("1. Use conventional commit format: <type>(<scope>): <subject>\n")
-		prompt.WriteString("2. Types: feat, fix, docs, style, refactor, test, chore, perf, ci, build\n")
-		prompt.WriteString("3. Subject line max 50 characters\n")
-		prompt.WriteString("4. Use present tense (\"add\" not \"added\")\n")
-		prompt.WriteString("5. No period at the end of subject\n")
-		prompt.WriteString("6. Include body if changes are complex (wrap at 72 chars)\n")
-		prompt.WriteString("7. Include footer for breaking changes or issue references\n")
-	} else {
-		prompt.WriteString("1. Subject line max 50 characters\n")
-		prompt.WriteString("2. Use imperative mood (\"Add feature\" not \"Added feature\")\n")
-		prompt.WriteString("3. Capitalize the subject line\n")
-		prompt.WriteString("4. No period at the end\n")
-		prompt.WriteString("5. Include body if needed (wrap at 72 chars)\n")
-	}
-	
-	if commitType != "" {
-		prompt.WriteString(fmt.Sprintf("\nCommit type must be: %s\n", commitType))
-	}
-	
-	if scope != "" {
-		prompt.WriteString(fmt.Sprintf("Scope must be: %s\n", scope))
-	}
-	
-	prompt.WriteString("\nDiff:\n")
-	prompt.WriteString("```\n")
-	prompt.WriteString(diff)
-	prompt.WriteString("\n```\n\n")
-	
-	prompt.WriteString("Generate the commit message (respond with ONLY the commit message, no explanations):")
-	
-	return prompt.String()
+// CommitData is the data available to the commit template
+type CommitData struct {
+	Diff         string
+	Type         string   // required commit type, if any
+	TypeHint     string   // type suggested by the branch name, if any
+	Scope        string   // required scope, if any
+	Conventional bool     // use the conventional commit format
+	Types        []string // allowed commit types
+	Scopes       []string // allowed scopes, any scope when empty
+	Examples     []string // recent commit subjects showing the repository's style
+	Variant      int      // number of the alternative when several are requested, from 1
+	Like         string   // a message the new one should resemble
+	Avoid        []string // rejected messages not to repeat
+	Squashed     []string // subjects of the commits being squashed into one
 }
 
-// GetSummaryPrompt returns the prompt for generating commit summaries
-func GetSummaryPrompt(commits []Commit, groupByType, changelog bool) string {
-	var prompt strings.Builder
-	
-	prompt.WriteString("Summarize the following git commits ")
-	if changelog {
-		prompt.WriteString("in changelog format.\n\n")
-		prompt.WriteString("Format the output as a proper changelog entry with:\n")
-		prompt.WriteString("- Version header\n")
-		prompt.WriteString("- Date\n")
-		prompt.WriteString("- Grouped changes by type (Features, Bug Fixes, etc.)\n")
-		prompt.WriteString("- Clear, user-facing descriptions\n\n")
-	} else {
-		prompt.WriteString("in a clear, concise manner.\n\n")
-		if groupByType {
-			prompt.WriteString("Group commits by their type (feat, fix, docs, etc.).\n")
+// SummaryData is the data available to the summary template
+type SummaryData struct {
+	Commits     []Commit
+	GroupByType bool
+	Changelog   bool
+}
+
+// ReviewData is the data available to the review template
+type ReviewData struct {
+	Diff        string
+	FocusAreas  []string
+	Security    bool
+	Performance bool
+}
+
+// PRData is the data available to the pr template
+type PRData struct {
+	CurrentBranch string
+	TargetBranch  string
+	Diff          string
+	Commits       []Commit
+	Issues        []string
+	Platform      string
+}
+
+// Hunk is one numbered part of the staged changes for prompts
+type Hunk struct {
+	ID   string
+	Path string
+	Diff string
+}
+
+// SplitData is the data available to the split template
+type SplitData struct {
+	Hunks        []Hunk
+	TypeHint     string // type suggested by the branch name, if any
+	Conventional bool
+	Types        []string
+	Scopes       []string
+	Examples     []string
+}
+
+// Template is a parsed prompt template
+type Template struct {
+	Name    string
+	Source  string // "builtin" or the path of the override file
+	Version string // BuiltinVersion, or a hash of the override's contents
+	Text    string
+
+	tmpl *template.Template
+}
+
+var (
+	overrideMu   sync.RWMutex
+	overrideDirs []string
+)
+
+// SetOverrideDirs sets the directories searched for user templates, highest
+// precedence first. A template named <name>.tmpl in one of them replaces the
+// builtin template of that name.
+func SetOverrideDirs(dirs ...string) {
+	overrideMu.Lock()
+	defer overrideMu.Unlock()
+	overrideDirs = dirs
+}
+
+// Names returns the names of the builtin templates
+func Names() []string {
+	return []string{CommitTemplate, SummaryTemplate, ReviewTemplate, PRTemplate, SplitTemplate}
+}
+
+// Load returns the template in effect for name: the first override found in the
+// override directories, or the builtin template
+func Load(name string) (*Template, error) {
+	overrideMu.RLock()
+	dirs := overrideDirs
+	overrideMu.RUnlock()
+
+	for _, dir := range dirs {
+		if dir == "" {
+			continue
 		}
+		path := filepath.Join(dir, name+templateExt)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to read prompt template %s: %w", path, err)
+		}
+		sum := sha256.Sum256(data)
+		return parse(name, path, "custom-"+hex.EncodeToString(sum[:6]), string(data))
 	}
-	
-	prompt.WriteString("Commits:\n\n")
-	for _, commit := range commits {
-		prompt.WriteString(fmt.Sprintf("- %s: %s\n", commit.Hash[:7], commit.Message))
-	}
-	
-	prompt.WriteString("\nGenerate the summary:")
-	
-	return prompt.String()
+
+	return LoadBuiltin(name)
 }
 
-// GetReviewPrompt returns the prompt for code review
-func GetReviewPrompt(diff string, focusAreas []string, security, performance bool) string {
-	var prompt strings.Builder
-	
-	prompt.WriteString("Review the following code changes and provide constructive feedback.\n\n")
-	
-	prompt.WriteString("Focus on:\n")
-	prompt.WriteString("1. Potential bugs or errors\n")
-	prompt.WriteString("2. Code quality and best practices\n")
-	prompt.WriteString("3. Readability and maintainability\n")
-	
-	if security {
-		prompt.WriteString("4. Security vulnerabilities (PRIORITY)\n")
-	}
-	
-	if performance {
-		prompt.WriteString("5. Performance issues and optimization opportunities (PRIORITY)\n")
-	}
-	
-	if len(focusAreas) > 0 {
-		prompt.WriteString(fmt.Sprintf("6. Specific areas: %s\n", strings.Join(focusAreas, ", ")))
+// LoadBuiltin returns the embedded template for name
+func LoadBuiltin(name string) (*Template, error) {
+	data, err := builtin.ReadFile("templates/" + name + templateExt)
+	if err != nil {
+		return nil, fmt.Errorf("unknown prompt template %q, available templates: %s", name, strings.Join(Names(), ", "))
 	}
-	
-	prompt.WriteString("\nProvide:\n")
-	prompt.WriteString("- Summary of the changes\n")
-	prompt.WriteString("- List of issues found (if any)\n")
-	prompt.WriteString("- Suggestions for improvement\n")
-	if security {
-		prompt.WriteString("- Security risks and mitigations\n")
+	return parse(name, "builtin", BuiltinVersion, string(data))
+}
+
+// Version returns the version of the template in effect for name. It is part of
+// every cache key so that editing a template invalidates the cached results.
+func Version(name string) string {
+	t, err := Load(name)
+	if err != nil {
+		return "invalid"
 	}
-	if performance {
-		prompt.WriteString("- Performance concerns and solutions\n")
+	return t.Version
+}
+
+// Execute renders the template with data
+func (t *Template) Execute(data interface{}) (string, error) {
+	var out bytes.Buffer
+	if err := t.tmpl.Execute(&out, data); err != nil {
+		return "", fmt.Errorf("failed to render prompt template %s (%s): %w", t.Name, t.Source, err)
 	}
-	
-	prompt.WriteString("\nCode changes:\n")
-	prompt.WriteString("```diff\n")
-	prompt.WriteString(diff)
-	prompt.WriteString("\n```\n\n")
-	
-	prompt.WriteString("Provide a structured review using markdown headers (e.g., ## Summary, ## Issues, ## Suggestions, ## Security Risks, ## Performance Issues):")
-	
-	return prompt.String()
+	return strings.TrimRight(out.String(), "\n"), nil
 }
 
-// GetPRDescriptionPrompt returns the prompt for generating PR descriptions
-func GetPRDescriptionPrompt(currentBranch, targetBranch, diff string, commits []Commit, issueNumbers []string, platform string) string {
-	var prompt strings.Builder
-	
-	prompt.WriteString("Generate a comprehensive Pull Request description based on the following information.\n\n")
-	
-	prompt.WriteString("Branch Information:\n")
-	prompt.WriteString(fmt.Sprintf("- Current Branch: %s\n", currentBranch))
-	prompt.WriteString(fmt.Sprintf("- Target Branch: %s\n", targetBranch))
-	prompt.WriteString(fmt.Sprintf("- Platform: %s\n", platform))
-	
-	if len(issueNumbers) > 0 {
-		prompt.WriteString(fmt.Sprintf("- Related Issues: %s\n", strings.Join(issueNumbers, ", ")))
+func parse(name, source, version, text string) (*Template, error) {
+	tmpl, err := template.New(name).Funcs(funcs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse prompt template %s (%s): %w", name, source, err)
 	}
-	
-	prompt.WriteString("\nCommits in this branch:\n")
-	for i, commit := range commits {
-		if i >= 10 { // Limit to first 10 commits for prompt size
-			prompt.WriteString(fmt.Sprintf("... and %d more commits\n", len(commits)-10))
-			break
+	return &Template{Name: name, Source: source, Version: version, Text: text, tmpl: tmpl}, nil
+}
+
+// funcs are the helpers available to every template
+var funcs = template.FuncMap{
+	"join": strings.Join,
+	// short abbreviates a commit hash
+	"short": func(hash string) string {
+		if len(hash) > 7 {
+			return hash[:7]
 		}
-		prompt.WriteString(fmt.Sprintf("- %s: %s\n", commit.Hash[:7], commit.Message))
-	}
-	
-	prompt.WriteString("\nGenerate a PR description with the following structure:\n")
-	prompt.WriteString("1. **Title**: Concise, descriptive title (50 chars max)\n")
-	prompt.WriteString("2. **Summary**: Brief overview of what this PR accomplishes\n")
-	prompt.WriteString("3. **Changes**: Bullet points of key changes made\n")
-	prompt.WriteString("4. **Testing**: How the changes should be tested\n")
-	prompt.WriteString("5. **Breaking Changes**: Any breaking changes (if applicable)\n\n")
-	
-	prompt.WriteString("Requirements:\n")
-	prompt.WriteString("- Use clear, professional language\n")
-	prompt.WriteString("- Focus on business value and impact\n")
-	prompt.WriteString("- Include technical details where relevant\n")
-	prompt.WriteString("- Mention any dependencies or requirements\n")
-	
-	switch platform {
-	case "gitlab":
-		prompt.WriteString("- Use GitLab-specific formatting\n")
-		prompt.WriteString("- Use 'Closes #issue' for issue linking\n")
-	case "bitbucket":
-		prompt.WriteString("- Use Bitbucket-specific formatting\n")
-		prompt.WriteString("- Use 'Fixes #issue' for issue linking\n")
-	default: // github
-		prompt.WriteString("- Use GitHub-specific formatting\n")
-		prompt.WriteString("- Use 'Fixes #issue' for issue linking\n")
-	}
-	
-	prompt.WriteString("\nCode changes:\n")
-	prompt.WriteString("```diff\n")
-	// Truncate diff if too long to fit in prompt
-	if len(diff) > 8000 {
-		prompt.WriteString(diff[:8000])
-		prompt.WriteString("\n... (diff truncated for brevity)")
-	} else {
-		prompt.WriteString(diff)
+		return hash
+	},
+	// truncate cuts s to n bytes, appending suffix when it was cut
+	"truncate": func(s string, n int, suffix string) string {
+		if len(s) <= n {
+			return s
+		}
+		return s[:n] + suffix
+	},
+	"add":   func(a, b int) int { return a + b },
+	"sub":   func(a, b int) int { return a - b },
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+	"trim":  strings.TrimSpace,
+}
+
+// render loads and executes the template in effect for name
+func render(name string, data interface{}) (string, error) {
+	t, err := Load(name)
+	if err != nil {
+		return "", err
 	}
-	prompt.WriteString("\n```\n\n")
-	
-	prompt.WriteString("Respond with a JSON object containing:\n")
-	prompt.WriteString("{\n")
-	prompt.WriteString("  \"title\": \"PR title\",\n")
-	prompt.WriteString("  \"summary\": \"Brief summary paragraph\",\n")
-	prompt.WriteString("  \"changes\": [\"change 1\", \"change 2\", ...],\n")
-	prompt.WriteString("  \"testing\": \"Testing instructions\",\n")
-	prompt.WriteString("  \"breaking_changes\": [\"breaking change 1\", ...] // empty array if none\n")
-	prompt.WriteString("}\n")
-	
-	return prompt.String()
-} 
\ No newline at end of file
+	return t.Execute(data)
+}
+
+// GetCommitMessagePrompt returns the prompt for generating commit messages
+func GetCommitMessagePrompt(data CommitData) (string, error) {
+	return render(CommitTemplate, data)
+}
+
+// GetSummaryPrompt returns the prompt for generating commit summaries
+func GetSummaryPrompt(data SummaryData) (string, error) {
+	return render(SummaryTemplate, data)
+}
+
+// GetReviewPrompt returns the prompt for code review
+func GetReviewPrompt(data ReviewData) (string, error) {
+	return render(ReviewTemplate, data)
+}
+
+// GetPRDescriptionPrompt returns the prompt for generating PR descriptions
+func GetPRDescriptionPrompt(data PRData) (string, error) {
+	return render(PRTemplate, data)
+}
+
+// GetSplitPrompt returns the prompt for grouping staged hunks into commits
+func GetSplitPrompt(data SplitData) (string, error) {
+	return render(SplitTemplate, data)
+}
@@ -0,0 +1,73 @@
+package prompts
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBuiltinTemplatesRender(t *testing.T) {
+	data := map[string]interface{}{
+		CommitTemplate:  CommitData{Diff: "DIFF", Conventional: true, Scopes: []string{"api"}},
+		SummaryTemplate: SummaryData{Commits: []Commit{{Hash: "0123456789abcdef", Message: "feat: x"}}},
+		ReviewTemplate:  ReviewData{Diff: "DIFF", Security: true},
+		PRTemplate:      PRData{Diff: "DIFF", CurrentBranch: "feature", TargetBranch: "main", Platform: "github"},
+		SplitTemplate:   SplitData{Hunks: []Hunk{{ID: "H1", Path: "main.go", Diff: "DIFF"}}, TypeHint: "feat", Conventional: true},
+	}
+
+	for _, name := range Names() {
+		tmpl, err := LoadBuiltin(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out, err := tmpl.Execute(data[name])
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if strings.Contains(out, "<no value>") || strings.HasSuffix(out, "\n") {
+			t.Errorf("%s rendered unexpected output:\n%s", name, out)
+		}
+	}
+}
+
+func TestOverrideTemplate(t *testing.T) {
+	repoDir := t.TempDir()
+	userDir := t.TempDir()
+	defer SetOverrideDirs()
+
+	SetOverrideDirs(repoDir, userDir)
+	if v := Version(CommitTemplate); v != BuiltinVersion {
+		t.Fatalf("expected the builtin version without overrides, got %s", v)
+	}
+
+	if err := os.WriteFile(filepath.Join(userDir, "commit.tmpl"), []byte("user {{.Diff}}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(repoDir, "commit.tmpl"), []byte("repo {{.Diff}}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := GetCommitMessagePrompt(CommitData{Diff: "x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "repo x" {
+		t.Errorf("expected the repository template to win, got %q", out)
+	}
+
+	before := Version(CommitTemplate)
+	if err := os.WriteFile(filepath.Join(repoDir, "commit.tmpl"), []byte("repo v2 {{.Diff}}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if Version(CommitTemplate) == before {
+		t.Error("editing a template must change its version")
+	}
+
+	if err := os.WriteFile(filepath.Join(repoDir, "commit.tmpl"), []byte("{{.Missing}}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := GetCommitMessagePrompt(CommitData{}); err == nil {
+		t.Error("expected an error for a template referring to unknown data")
+	}
+}